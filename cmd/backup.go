@@ -6,9 +6,17 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/nonuplet/grimoire-archon/internal/adapter/snapshot"
+	"github.com/nonuplet/grimoire-archon/internal/domain"
 	"github.com/nonuplet/grimoire-archon/internal/usecase"
 )
 
+var (
+	// backupNote --note フラグ
+	backupNote string
+	// backupTags --tag フラグ
+	backupTags []string
+)
+
 // backupCmd backupコマンドの生成
 var backupCmd = &cobra.Command{
 	Use:   "backup <name>",
@@ -16,8 +24,10 @@ var backupCmd = &cobra.Command{
 	Long: `指定したゲームのバックアップを取ります。
 引数で .archon.yaml のコンフィグで指定したゲーム名を渡してください。
 保存先はコンフィグで指定した backup_dir 以下に、ゲームの name でディレクトリが作成されます。
+--note でメモを、--tag でタグ(複数指定可)をバックアップに付与できます。
 `,
-	Args: cobra.ExactArgs(1),
+	Args:        cobra.ExactArgs(1),
+	Annotations: recoverAnnotations(),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
 		game, ok := cfg.Games[name]
@@ -26,11 +36,16 @@ var backupCmd = &cobra.Command{
 		}
 
 		snap := snapshot.NewSnapshot(cfg.Archon, game, fs, cliUtil)
-		backupUsecase := usecase.NewBackupUsecase(cfg.Archon, game, snap, fs, cliUtil)
+		backupUsecase := usecase.NewBackupUsecase(cfg.Archon, game, snap, fs, cliUtil, locker)
 
 		fmt.Printf("%s のバックアップを取得します...\n", name)
 
-		if err := backupUsecase.Execute(); err != nil {
+		opts := domain.BackupOptions{
+			Trigger: domain.TriggerManual,
+			Note:    backupNote,
+			Tags:    backupTags,
+		}
+		if _, err := backupUsecase.Execute(cmd.Context(), opts); err != nil {
 			return fmt.Errorf("%s のバックアップに失敗しました : %w", name, err)
 		}
 
@@ -41,4 +56,8 @@ var backupCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(backupCmd)
+
+	// --note, --tag
+	backupCmd.Flags().StringVar(&backupNote, "note", "", "バックアップに付与するメモ")
+	backupCmd.Flags().StringArrayVar(&backupTags, "tag", nil, "バックアップに付与するタグ (複数指定可)")
 }
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nonuplet/grimoire-archon/internal/adapter/snapshot"
+	"github.com/nonuplet/grimoire-archon/internal/usecase"
+)
+
+// backupsCmd backupsコマンドの生成
+var backupsCmd = &cobra.Command{
+	Use:   "backups",
+	Short: "作成済みのバックアップを管理します。",
+	Long:  "作成済みのバックアップを管理します。サブコマンドを指定してください。",
+}
+
+// backupsMigrateCmd backups migrateコマンドの生成
+var backupsMigrateCmd = &cobra.Command{
+	Use:   "migrate [name]",
+	Short: "バックアップのメタデータを最新の形式に更新します。",
+	Long: `古いバージョンのarchonで作成したバックアップのメタデータを、最新の形式に書き換えます。
+引数で .archon.yaml のコンフィグで指定したゲーム名を渡してください。省略した場合は全てのゲームが対象になります。
+バックアップのzipファイルは上書きされます。
+`,
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: recoverAnnotations(),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		names, err := getTargetGameNames(args)
+		if err != nil {
+			return err
+		}
+
+		var failed []string
+		for _, name := range names {
+			game := cfg.Games[name]
+			snap := snapshot.NewSnapshot(cfg.Archon, game, fs, cliUtil)
+			backupsUsecase := usecase.NewBackupsUsecase(cfg.Archon, game, snap, fs, locker)
+
+			fmt.Printf("%s のバックアップを更新します...\n", name)
+			if err := backupsUsecase.Migrate(cmd.Context()); err != nil {
+				fmt.Printf("%s のバックアップの更新に失敗しました : %v\n", name, err)
+				failed = append(failed, name)
+			}
+		}
+
+		if len(failed) > 0 {
+			return fmt.Errorf("%v のバックアップの更新に失敗しました", failed)
+		}
+
+		fmt.Println("バックアップの更新が完了しました。")
+		return nil
+	},
+}
+
+// getTargetGameNames 引数で指定されたゲーム名を返す。指定がなければ全てのゲーム名を返す
+func getTargetGameNames(args []string) ([]string, error) {
+	if len(args) > 0 {
+		if _, ok := cfg.Games[args[0]]; !ok {
+			return nil, fmt.Errorf("%s は設定されていません。コンフィグを確認してください", args[0])
+		}
+		return args[:1], nil
+	}
+
+	names := make([]string, 0, len(cfg.Games))
+	for name := range cfg.Games {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func init() {
+	rootCmd.AddCommand(backupsCmd)
+	backupsCmd.AddCommand(backupsMigrateCmd)
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nonuplet/grimoire-archon/internal/adapter/snapshot"
+	"github.com/nonuplet/grimoire-archon/internal/usecase"
+)
+
+// backupsListTags --tag フラグ
+var backupsListTags []string
+
+// backupsListCmd backups listコマンドの生成
+var backupsListCmd = &cobra.Command{
+	Use:   "list <name>",
+	Short: "指定したゲームのバックアップ一覧を表示します。",
+	Long: `指定したゲームのバックアップ一覧を、古い順に表示します。
+引数で .archon.yaml のコンフィグで指定したゲーム名を渡してください。
+--tag を指定すると、そのタグを持つバックアップのみを表示します(複数指定した場合は全てのタグを持つもの)。
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		game, ok := cfg.Games[name]
+		if !ok {
+			return fmt.Errorf("%s は設定されていません。コンフィグを確認してください", name)
+		}
+
+		snap := snapshot.NewSnapshot(cfg.Archon, game, fs, cliUtil)
+		backupsUsecase := usecase.NewBackupsUsecase(cfg.Archon, game, snap, fs, locker)
+
+		archives, err := backupsUsecase.Find(cmd.Context(), backupsListTags)
+		if err != nil {
+			return fmt.Errorf("%s のバックアップ一覧の取得に失敗しました : %w", name, err)
+		}
+		if len(archives) == 0 {
+			fmt.Println("バックアップが見つかりません。")
+			return nil
+		}
+
+		for _, archive := range archives {
+			meta := archive.Meta
+			fmt.Printf("%s  %s", meta.CreatedAt.Local().Format("2006-01-02 15:04:05"), filepath.Base(archive.Path))
+			if len(meta.Tags) > 0 {
+				fmt.Printf("  [%s]", strings.Join(meta.Tags, ", "))
+			}
+			if meta.Note != "" {
+				fmt.Printf("  %s", meta.Note)
+			}
+			fmt.Println()
+		}
+		return nil
+	},
+}
+
+func init() {
+	backupsCmd.AddCommand(backupsListCmd)
+
+	// --tag
+	backupsListCmd.Flags().StringArrayVar(&backupsListTags, "tag", nil, "指定したタグを持つバックアップのみ表示します (複数指定可)")
+}
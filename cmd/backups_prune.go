@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nonuplet/grimoire-archon/internal/adapter/snapshot"
+	"github.com/nonuplet/grimoire-archon/internal/usecase"
+)
+
+// backupsPruneKeep --keep フラグ
+var backupsPruneKeep int
+
+// backupsPruneCmd backups pruneコマンドの生成
+var backupsPruneCmd = &cobra.Command{
+	Use:   "prune <name>",
+	Short: "古いバックアップを削除します。",
+	Long: `新しいものから --keep で指定した数だけバックアップを残し、それより古いバックアップを削除します。
+--keep を省略した場合は、コンフィグの schedule.keep (未設定の場合は 10) を使用します。
+`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: recoverAnnotations(),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		game, ok := cfg.Games[name]
+		if !ok {
+			return fmt.Errorf("%s は設定されていません。コンフィグを確認してください", name)
+		}
+
+		keep := backupsPruneKeep
+		if !cmd.Flags().Changed("keep") {
+			keep = game.Schedule.GetKeep()
+		}
+
+		snap := snapshot.NewSnapshot(cfg.Archon, game, fs, cliUtil)
+		backupsUsecase := usecase.NewBackupsUsecase(cfg.Archon, game, snap, fs, locker)
+		if err := backupsUsecase.Prune(cmd.Context(), keep); err != nil {
+			return fmt.Errorf("%s のバックアップの削除に失敗しました : %w", name, err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	backupsCmd.AddCommand(backupsPruneCmd)
+
+	// --keep
+	backupsPruneCmd.Flags().IntVar(&backupsPruneKeep, "keep", 0, "残すバックアップの数")
+}
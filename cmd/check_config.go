@@ -5,6 +5,10 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/nonuplet/grimoire-archon/internal/adapter/snapshot"
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+	"github.com/nonuplet/grimoire-archon/internal/infra/cgroup"
+	"github.com/nonuplet/grimoire-archon/internal/infra/network"
 	"github.com/nonuplet/grimoire-archon/internal/usecase"
 )
 
@@ -14,14 +18,21 @@ var checkConfigCmd = &cobra.Command{
 	Short: "コンフィグのチェックを行います。",
 	Long: `現在読み込んでいるコンフィグのチェックを行います。
 指定したコンフィグファイルをチェックしたい場合は、-c か --config を使って指定してください。
-例外的として、ゲームのRunコマンドのチェックは行いません。
+run の設定値、run.workdir と run.env_file の存在はチェックしますが、run.command の実行ファイルのチェックは行いません。
+runtime_env が wine, proton のゲームでは、バックアップに使用する Windows のプロファイルを表示します。
+ports は複数のゲームでの重複と、サーバが停止中のゲームのポートが他のプロセスに使用されていないかをチェックします。
+resources は設定値と、制限に必要な cgroup v2 のコントローラが archon.cgroup.root で使用できるかをチェックします。
+proton の場合は libraryfolders.vdf から全てのSteamライブラリを探し、compatdata が存在するライブラリを選びます。
 `,
 	Args: cobra.ExactArgs(0),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		checkConfigUsecase := usecase.NewCheckConfigUsecase(&cfg, fs, cliUtil)
+		newSnapshot := func(gameCfg *domain.GameConfig) usecase.Snapshot {
+			return snapshot.NewSnapshot(cfg.Archon, gameCfg, fs, cliUtil)
+		}
+		checkConfigUsecase := usecase.NewCheckConfigUsecase(&cfg, fs, cliUtil, newSnapshot, network.NewPortChecker(), procFinder, cgroup.NewCgroup(cfg.Archon))
 
 		fmt.Println("コンフィグをチェックします...")
-		if err := checkConfigUsecase.Execute(); err != nil {
+		if err := checkConfigUsecase.Execute(cmd.Context()); err != nil {
 			fmt.Println("エラーが見つかりました！")
 		} else {
 			fmt.Println("チェックが完了しました。エラーはありません。")
@@ -17,7 +17,8 @@ var cleanCmd = &cobra.Command{
 引数で .archon.yaml のコンフィグで指定したゲーム名を渡してください。
 バックアップを取っていない場合、エラーチェックが入ります。強制的に削除することも可能です。
 `,
-	Args: cobra.ExactArgs(1),
+	Args:        cobra.ExactArgs(1),
+	Annotations: recoverAnnotations(),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
 		game, ok := cfg.Games[name]
@@ -26,17 +27,17 @@ var cleanCmd = &cobra.Command{
 		}
 
 		snap := snapshot.NewSnapshot(cfg.Archon, game, fs, cliUtil)
-		backupUsecase := usecase.NewBackupUsecase(cfg.Archon, game, snap, fs, cliUtil)
-		cleanUsecase := usecase.NewCleanUsecase(cfg.Archon, game, fs, cliUtil)
+		backupUsecase := usecase.NewBackupUsecase(cfg.Archon, game, snap, fs, cliUtil, locker)
+		cleanUsecase := usecase.NewCleanUsecase(cfg.Archon, game, fs, cliUtil, locker, procFinder)
 
 		fmt.Printf("%s の削除前チェック中...\n", game.Name)
-		if err := backupUsecase.Check(); err != nil {
+		if err := backupUsecase.Check(cmd.Context()); err != nil {
 			return fmt.Errorf("%s の削除前チェックに失敗しました : %w", game.Name, err)
 		}
 
 		fmt.Printf("%s の削除処理を行います...\n", name)
 
-		if err := cleanUsecase.Execute(); err != nil {
+		if err := cleanUsecase.Execute(cmd.Context()); err != nil {
 			return fmt.Errorf("%s の削除に失敗しました : %w", name, err)
 		}
 
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nonuplet/grimoire-archon/internal/infra/credential"
+	"github.com/nonuplet/grimoire-archon/internal/usecase"
+)
+
+// credentialsCmd credentialsコマンドの生成
+var credentialsCmd = &cobra.Command{
+	Use:   "credentials",
+	Short: "暗号化済みの認証情報を管理します。",
+	Long: `steam.login.credential で参照する、暗号化済みの認証情報を管理します。サブコマンドを指定してください。
+認証情報は state_dir 以下の credentials.yaml に、パスフレーズから導出した鍵で暗号化して保存されます。
+パスフレーズは環境変数 ` + credential.KeyEnv + ` で指定します。update などで認証情報を使用する際にも必要です。
+`,
+}
+
+// credentialsSetCmd credentials setコマンドの生成
+var credentialsSetCmd = &cobra.Command{
+	Use:   "set <name>",
+	Short: "認証情報を保存します。",
+	Long:  "パスワードを入力させ、暗号化して保存します。同じ名前の認証情報がある場合は上書きします。",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		credentialsUsecase := usecase.NewCredentialsUsecase(newCredentialStore(), cliUtil)
+		return credentialsUsecase.Set(args[0], os.Getenv(credential.KeyEnv))
+	},
+}
+
+// credentialsDeleteCmd credentials deleteコマンドの生成
+var credentialsDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "認証情報を削除します。",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		credentialsUsecase := usecase.NewCredentialsUsecase(newCredentialStore(), cliUtil)
+		return credentialsUsecase.Delete(args[0])
+	},
+}
+
+// credentialsListCmd credentials listコマンドの生成
+var credentialsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "保存されている認証情報の名前を表示します。",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		credentialsUsecase := usecase.NewCredentialsUsecase(newCredentialStore(), cliUtil)
+		if err := credentialsUsecase.List(); err != nil {
+			return fmt.Errorf("認証情報の一覧の取得に失敗しました : %w", err)
+		}
+		return nil
+	},
+}
+
+// newCredentialStore 認証情報のストアを生成する
+func newCredentialStore() *credential.Store {
+	var dir string
+	if cfg.Archon != nil {
+		dir = cfg.Archon.GetStateDir()
+	}
+	return credential.NewStore(dir)
+}
+
+func init() {
+	rootCmd.AddCommand(credentialsCmd)
+	credentialsCmd.AddCommand(credentialsSetCmd)
+	credentialsCmd.AddCommand(credentialsDeleteCmd)
+	credentialsCmd.AddCommand(credentialsListCmd)
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nonuplet/grimoire-archon/internal/infra/launcher"
+	"github.com/nonuplet/grimoire-archon/internal/usecase"
+)
+
+// execCmd execコマンドの生成
+var execCmd = &cobra.Command{
+	Use:   "exec <name> -- <command> [args...]",
+	Short: "ゲームの実行環境でコマンドを実行します。",
+	Long: `指定したゲームのサーバと同じ実行環境で、インストールディレクトリをカレントディレクトリとしてコマンドを実行します。
+runtime_env が wine の場合は WINEPREFIX (prefix を設定している場合) と WINEDEBUG、
+proton の場合は STEAM_COMPAT_DATA_PATH, WINEPREFIX などを設定し、Protonに同梱されているwineを PATH に追加します。
+run.envs も設定されます。(例: archon exec mygame -- winecfg)
+コマンドの終了コードを、そのまま archon の終了コードとして返します。
+`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		game, ok := cfg.Games[name]
+		if !ok {
+			return fmt.Errorf("%s は設定されていません。コンフィグを確認してください", name)
+		}
+
+		execUsecase := usecase.NewExecUsecase(cfg.Archon, game, launcher.NewLauncher(cfg.Archon))
+		err := execUsecase.Execute(cmd.Context(), args[1:])
+
+		// 終了コードはスクリプトから利用できるよう、そのまま返す
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+			os.Exit(exitErr.ExitCode())
+		}
+		if err != nil {
+			return fmt.Errorf("%s の実行環境でのコマンドの実行に失敗しました : %w", name, err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(execCmd)
+}
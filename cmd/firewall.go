@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+	"github.com/nonuplet/grimoire-archon/internal/usecase"
+)
+
+// firewallFormat --format フラグ
+var firewallFormat string
+
+// firewallCmd firewallコマンドの生成
+var firewallCmd = &cobra.Command{
+	Use:   "firewall",
+	Short: "ファイアウォールのルールを管理します。",
+	Long:  "コンフィグの ports で指定したポートのファイアウォールのルールを管理します。サブコマンドを指定してください。",
+}
+
+// firewallExportCmd firewall exportコマンドの生成
+var firewallExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "全てのゲームのポートを許可するルールを出力します。",
+	Long: `全てのゲームの ports で指定したポートを許可するファイアウォールのルールを、標準出力に出力します。
+--format で形式 (nftables, ufw, iptables) を指定します。ルールの適用は行いません。
+  nftables: nft -f で読み込める形式です。inet filter テーブルの input チェインにルールを追加します。
+  ufw, iptables: ルールを追加するシェルスクリプトです。
+ports が不正な場合や、複数のゲームで同じポートが設定されている場合はエラーになります。
+`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		firewallUsecase := usecase.NewFirewallUsecase(&cfg)
+		rules, err := firewallUsecase.Export(domain.FirewallFormat(firewallFormat))
+		if err != nil {
+			return fmt.Errorf("ファイアウォールのルールの出力に失敗しました : %w", err)
+		}
+		fmt.Print(rules)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(firewallCmd)
+	firewallCmd.AddCommand(firewallExportCmd)
+
+	// --format
+	firewallExportCmd.Flags().StringVar(&firewallFormat, "format", string(domain.FirewallNftables), "出力する形式 (nftables, ufw, iptables)")
+}
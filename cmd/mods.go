@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+	"github.com/nonuplet/grimoire-archon/internal/infra/workshop"
+	"github.com/nonuplet/grimoire-archon/internal/usecase"
+)
+
+// modsCmd modsコマンドの生成
+var modsCmd = &cobra.Command{
+	Use:   "mods",
+	Short: "Steamワークショップのアイテム(Mod)を管理します。",
+	Long:  "workshop で指定したSteamワークショップのアイテム(Mod)を管理します。サブコマンドを指定してください。",
+}
+
+// modsSyncCmd mods syncコマンドの生成
+var modsSyncCmd = &cobra.Command{
+	Use:   "sync <name>",
+	Short: "ワークショップのアイテムを同期します。",
+	Long: `workshop.items に指定したアイテムをsteamcmdの workshop_download_item でダウンロードし、
+install_dir 以下の workshop.mods_dir に配置します。workshop.mode が link の場合はコピーせずにシンボリックリンクを作成します。
+同期済みのバージョンは state_dir/workshop/<name>.lock.yaml に記録し、ワークショップで更新されていないアイテムはスキップします。
+workshop.items から外したアイテムは mods_dir から削除されます。
+`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: recoverAnnotations(),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		game, ok := cfg.Games[name]
+		if !ok {
+			return fmt.Errorf("%s は設定されていません。コンフィグを確認してください", name)
+		}
+
+		if err := newModsUsecase(game).Sync(cmd.Context()); err != nil {
+			return fmt.Errorf("%s のワークショップの同期に失敗しました : %w", name, err)
+		}
+		return nil
+	},
+}
+
+// newModsUsecase ModsUsecaseの生成
+func newModsUsecase(game *domain.GameConfig) *usecase.ModsUsecase {
+	return usecase.NewModsUsecase(cfg.Archon, game, newSteamCmd(), workshop.NewClient(), fs, locker, procFinder)
+}
+
+func init() {
+	rootCmd.AddCommand(modsCmd)
+	modsCmd.AddCommand(modsSyncCmd)
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+	"github.com/nonuplet/grimoire-archon/internal/infra/wine"
+	"github.com/nonuplet/grimoire-archon/internal/usecase"
+)
+
+// prefixCmd prefixコマンドの生成
+var prefixCmd = &cobra.Command{
+	Use:   "prefix",
+	Short: "ゲーム専用のWine/Protonのプレフィックスを管理します。",
+	Long: `prefix で設定した、ゲーム専用のWine/Protonのプレフィックスを管理します。サブコマンドを指定してください。
+プレフィックスは prefix.path (デフォルトで state_dir/prefixes/<name>) に作成されます。
+prefix を設定したゲームのバックアップでは、WINEPREFIX などの環境変数の代わりにこのプレフィックスを使用します。
+`,
+}
+
+// prefixInitCmd prefix initコマンドの生成
+var prefixInitCmd = &cobra.Command{
+	Use:   "init <name>",
+	Short: "プレフィックスを作成します。",
+	Long: `プレフィックスを作成し、prefix.winetricks に指定したverbをwinetricksでインストールします。
+runtime_env が wine の場合は wineboot、proton の場合は prefix.proton に指定したProtonで wineboot を実行します。
+作成済みの場合は winetricks のみ実行します。
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		game, ok := cfg.Games[name]
+		if !ok {
+			return fmt.Errorf("%s は設定されていません。コンフィグを確認してください", name)
+		}
+
+		if err := newPrefixUsecase(game).Init(cmd.Context()); err != nil {
+			return fmt.Errorf("%s のプレフィックスの作成に失敗しました : %w", name, err)
+		}
+		return nil
+	},
+}
+
+// prefixInfoCmd prefix infoコマンドの生成
+var prefixInfoCmd = &cobra.Command{
+	Use:   "info <name>",
+	Short: "プレフィックスの状態を表示します。",
+	Long:  "プレフィックスのパス、作成済みかどうか、winetricksでインストール済みのverbを表示します。",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		game, ok := cfg.Games[name]
+		if !ok {
+			return fmt.Errorf("%s は設定されていません。コンフィグを確認してください", name)
+		}
+
+		status, err := newPrefixUsecase(game).Info()
+		if err != nil {
+			return fmt.Errorf("%s のプレフィックスの状態の取得に失敗しました : %w", name, err)
+		}
+
+		fmt.Printf("実行環境: %s\n", game.RuntimeEnv)
+		fmt.Printf("プレフィックス: %s\n", status.Dir)
+		if game.RuntimeEnv == domain.RuntimeEnvProton {
+			fmt.Printf("WINEPREFIX: %s\n", status.WinePrefix)
+		}
+		if status.Initialized {
+			fmt.Println("状態: 作成済み")
+		} else {
+			fmt.Printf("状態: 未作成 (archon prefix init %s で作成できます)\n", name)
+		}
+		if len(status.Verbs) > 0 {
+			fmt.Printf("winetricks: %s\n", strings.Join(status.Verbs, ", "))
+		}
+		return nil
+	},
+}
+
+// prefixResetCmd prefix resetコマンドの生成
+var prefixResetCmd = &cobra.Command{
+	Use:   "reset <name>",
+	Short: "プレフィックスを削除して作り直します。",
+	Long: `プレフィックスを削除し、prefix init と同様に作り直します。
+プレフィックス内のセーブデータや設定も削除されるため、必要であれば先にバックアップしてください。
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		game, ok := cfg.Games[name]
+		if !ok {
+			return fmt.Errorf("%s は設定されていません。コンフィグを確認してください", name)
+		}
+
+		if err := newPrefixUsecase(game).Reset(cmd.Context()); err != nil {
+			return fmt.Errorf("%s のプレフィックスのリセットに失敗しました : %w", name, err)
+		}
+		return nil
+	},
+}
+
+// newPrefixUsecase PrefixUsecaseの生成
+func newPrefixUsecase(game *domain.GameConfig) *usecase.PrefixUsecase {
+	return usecase.NewPrefixUsecase(cfg.Archon, game, wine.NewPrefix(), fs, cliUtil, locker, procFinder)
+}
+
+func init() {
+	rootCmd.AddCommand(prefixCmd)
+	prefixCmd.AddCommand(prefixInitCmd)
+	prefixCmd.AddCommand(prefixInfoCmd)
+	prefixCmd.AddCommand(prefixResetCmd)
+}
@@ -1,26 +1,44 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
 
 	"github.com/nonuplet/grimoire-archon/internal/adapter/snapshot"
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+	"github.com/nonuplet/grimoire-archon/internal/infra/filesystem"
 	"github.com/nonuplet/grimoire-archon/internal/usecase"
 )
 
-// cleanCmd restoreコマンドの生成
+var (
+	// restoreMirror --mirror フラグ
+	restoreMirror bool
+	// restoreAllowExternalSymlinks --allow-external-symlinks フラグ
+	restoreAllowExternalSymlinks bool
+	// restoreTags --tag フラグ
+	restoreTags []string
+	// restoreLatest --latest フラグ
+	restoreLatest bool
+)
+
+// restoreCmd restoreコマンドの生成
 var restoreCmd = &cobra.Command{
-	Use:   "restore <name> <archive>",
+	Use:   "restore <name> [archive]",
 	Short: "指定したゲームのバックアップを復元します。",
 	Long: `指定したゲームのバックアップを復元します。
 第一引数に .archon.yaml のコンフィグで指定したゲーム名を渡してください。
 第二引数に元にするバックアップデータ(.zip)を指定してください。
+第二引数の代わりに --latest を指定すると、最新のバックアップを復元します。--tag と組み合わせると、そのタグを持つ最新のバックアップを復元します。
+--mirror を指定すると、復元先のディレクトリをバックアップの内容と完全に一致させます(バックアップにないファイルは削除されます)。
+バックアップの外を指すシンボリックリンク(絶対パス等)を含むバックアップは、--allow-external-symlinks を指定した場合のみ復元します。
 `,
-	Args: cobra.ExactArgs(2),
+	Args:        cobra.RangeArgs(1, 2),
+	Annotations: recoverAnnotations(),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
-		zipPath := args[1]
 
 		game, ok := cfg.Games[name]
 		if !ok {
@@ -28,11 +46,36 @@ var restoreCmd = &cobra.Command{
 		}
 
 		snap := snapshot.NewSnapshot(cfg.Archon, game, fs, cliUtil)
-		restoreUsecase := usecase.NewRestoreUsecase(cfg.Archon, game, snap, fs)
+		restoreUsecase := usecase.NewRestoreUsecase(cfg.Archon, game, snap, fs, locker)
+
+		// 復元するアーカイブの決定
+		var zipPath string
+		switch {
+		case len(args) == 2 && (restoreLatest || len(restoreTags) > 0):
+			return fmt.Errorf("アーカイブを指定した場合、--latest, --tag は使用できません")
+		case len(args) == 2:
+			zipPath = args[1]
+		case restoreLatest:
+			backupsUsecase := usecase.NewBackupsUsecase(cfg.Archon, game, snap, fs, locker)
+			latest, err := backupsUsecase.FindLatest(cmd.Context(), restoreTags)
+			if err != nil {
+				return fmt.Errorf("%s の復元に失敗しました : %w", name, err)
+			}
+			zipPath = latest.Path
+			fmt.Printf("バックアップ %s を使用します。\n", filepath.Base(zipPath))
+		default:
+			return fmt.Errorf("復元するアーカイブを指定するか、--latest を指定してください")
+		}
 
 		fmt.Printf("%s の復元処理を行います...\n", name)
 
-		if err := restoreUsecase.Execute(zipPath); err != nil {
+		err := restoreUsecase.Execute(cmd.Context(), zipPath, domain.RestoreOptions{
+			Mirror:                restoreMirror,
+			AllowExternalSymlinks: restoreAllowExternalSymlinks,
+		})
+		if errors.Is(err, filesystem.ErrExternalSymlink) {
+			return fmt.Errorf("%s の復元に失敗しました : %w\nリンク先に問題がない場合は --allow-external-symlinks を指定してください", name, err)
+		} else if err != nil {
 			return fmt.Errorf("%s の復元に失敗しました : %w", name, err)
 		}
 
@@ -43,4 +86,14 @@ var restoreCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(restoreCmd)
+
+	// --tag, --latest
+	restoreCmd.Flags().StringArrayVar(&restoreTags, "tag", nil, "--latest で、指定したタグを持つバックアップに絞り込みます (複数指定可)")
+	restoreCmd.Flags().BoolVar(&restoreLatest, "latest", false, "最新のバックアップを復元します")
+
+	// --mirror
+	restoreCmd.Flags().BoolVar(&restoreMirror, "mirror", false, "復元先のディレクトリをバックアップと完全に一致させます (バックアップにないファイルを削除します)")
+
+	// --allow-external-symlinks
+	restoreCmd.Flags().BoolVar(&restoreAllowExternalSymlinks, "allow-external-symlinks", false, "バックアップの外を指すシンボリックリンクも復元します")
 }
@@ -1,9 +1,14 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
+	"time"
 
 	"github.com/goccy/go-yaml"
 	"github.com/spf13/cobra"
@@ -11,30 +16,75 @@ import (
 	"github.com/nonuplet/grimoire-archon/internal/domain"
 	"github.com/nonuplet/grimoire-archon/internal/infra/cli"
 	"github.com/nonuplet/grimoire-archon/internal/infra/filesystem"
+	"github.com/nonuplet/grimoire-archon/internal/infra/lock"
+	"github.com/nonuplet/grimoire-archon/internal/infra/process"
+	"github.com/nonuplet/grimoire-archon/internal/usecase"
 )
 
 var (
-	cfgPath string
-	cfg     domain.Config
-	fs      *filesystem.FileSystem
-	cliUtil *cli.Util
+	cfgPath     string
+	lockWait    bool
+	lockTimeout time.Duration
+	cfg         domain.Config
+	fs          *filesystem.FileSystem
+	cliUtil     *cli.Util
+	locker      *lock.Locker
+	procFinder  *process.Finder
 )
 
+// annotationRecover このアノテーションを持つコマンドの実行前に、前回異常終了した操作の後始末を行う
+const annotationRecover = "archon/recover"
+
+// recoverAnnotations インストール先やバックアップを操作するコマンドに設定するアノテーションを返す
+func recoverAnnotations() map[string]string {
+	return map[string]string{annotationRecover: "true"}
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "archon",
 	Short: "ゲームサーバ管理用CLIツール",
 	Long:  "ゲームサーバのインストール、セーブデータバックアップなどの操作を提供するCLIツールです。",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// 前回異常終了した操作の後始末
+		// version, help, check-config など、インストール先やバックアップに触れないコマンドでは行わない
+		if _, ok := cmd.Annotations[annotationRecover]; !ok {
+			return nil
+		}
+		recoverUsecase := usecase.NewRecoverUsecase(&cfg, fs, locker)
+		return recoverUsecase.Execute(cmd.Context())
+	},
 }
 
 // Execute execute
 func Execute() {
-	err := rootCmd.Execute()
+	err := rootCmd.ExecuteContext(newSignalContext())
+	if errors.Is(err, errUpdateAvailable) {
+		os.Exit(exitUpdateAvailable)
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v", err)
 		os.Exit(1)
 	}
 }
 
+// newSignalContext Ctrl-C (SIGINT), SIGTERM でキャンセルされるコンテキストを生成する
+// 1回目のシグナルでコンテキストをキャンセルし、各処理は後始末をして終了する。
+// 確認待ちなどで止まっている場合に備えて、2回目のシグナルではそのまま強制終了する。
+func newSignalContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		fmt.Fprintln(os.Stderr, "\n中断しています... もう一度 Ctrl-C を押すと強制終了します。")
+		cancel()
+		signal.Stop(sigCh)
+	}()
+
+	return ctx
+}
+
 func init() {
 	fs = filesystem.NewFileSystem()
 	cliUtil = cli.NewCliUtil()
@@ -42,6 +92,10 @@ func init() {
 
 	// -c, --config
 	rootCmd.PersistentFlags().StringVarP(&cfgPath, "config", "c", "", "コンフィグファイル (デフォルトで ./archon.yaml, なければ ~/.archon.yamlを読み込みます)")
+
+	// --wait, --timeout
+	rootCmd.PersistentFlags().BoolVar(&lockWait, "wait", false, "同じゲームへの他の操作が実行中の場合、終了を待機します")
+	rootCmd.PersistentFlags().DurationVar(&lockTimeout, "timeout", 0, "--wait で待機する最大時間 (例: 10m, 0 で無期限)")
 }
 
 // initConfig コンフィグファイルの読み込み
@@ -69,6 +123,10 @@ func initConfig() {
 		fmt.Fprint(os.Stderr, "ゲーム設定が見つかりません。config.yaml に games セクションを追加してください。\n")
 		os.Exit(1)
 	}
+
+	// 操作ロックの初期化
+	locker = lock.NewLocker(cfg.Archon.GetLockDir(), lockWait, lockTimeout)
+	procFinder = process.NewFinder(cfg.Archon)
 }
 
 func getConfigPath() string {
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nonuplet/grimoire-archon/internal/adapter/snapshot"
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+	"github.com/nonuplet/grimoire-archon/internal/infra/cli"
+	"github.com/nonuplet/grimoire-archon/internal/infra/launcher"
+	"github.com/nonuplet/grimoire-archon/internal/infra/network"
+	"github.com/nonuplet/grimoire-archon/internal/usecase"
+)
+
+// scheduleCmd scheduleコマンドの生成
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "定期実行ジョブを管理します。",
+	Long:  "コンフィグの schedule で指定した定期実行ジョブを管理します。サブコマンドを指定してください。",
+}
+
+// scheduleRunCmd schedule runコマンドの生成
+var scheduleRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "定期実行ジョブのスケジューラを起動します。",
+	Long: `コンフィグの schedule で指定したジョブ (backup, update, restart, prune) を、停止されるまで実行し続けます。
+確認プロンプトには全てデフォルトの回答で自動応答します。
+同じゲームの前回のジョブが実行中の場合、そのジョブはスキップされます。
+スケジューラの停止中に実行されなかったジョブは、起動時に1回だけ実行されます。
+実行状態は state_dir (デフォルトで backup_dir/.archon) に保存されます。
+`,
+	Args:        cobra.NoArgs,
+	Annotations: recoverAnnotations(),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jobs, err := getScheduledJobs()
+		if err != nil {
+			return err
+		}
+
+		logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+		scheduleUsecase := usecase.NewScheduleUsecase(cfg.Archon, jobs, runScheduledJob, fs, locker, logger)
+
+		logger.Info("スケジューラを起動しました", "jobs", len(jobs))
+		return scheduleUsecase.Run(cmd.Context())
+	},
+}
+
+// getScheduledJobs 全てのゲームの定期実行ジョブを返す
+func getScheduledJobs() ([]domain.ScheduledJob, error) {
+	names := make([]string, 0, len(cfg.Games))
+	for name := range cfg.Games {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var jobs []domain.ScheduledJob
+	for _, name := range names {
+		gameJobs, err := cfg.Games[name].Schedule.GetJobs(name)
+		if err != nil {
+			return nil, fmt.Errorf("%s の schedule が不正です: %w", name, err)
+		}
+		jobs = append(jobs, gameJobs...)
+	}
+	return jobs, nil
+}
+
+// runScheduledJob 定期実行ジョブを実行する
+// スケジューラではユーザーが応答できないため、確認プロンプトには自動で応答する。
+func runScheduledJob(ctx context.Context, job domain.ScheduledJob) error {
+	game := cfg.Games[job.Game]
+	autoCli := cli.NewNonInteractiveCliUtil()
+	snap := snapshot.NewSnapshot(cfg.Archon, game, fs, autoCli)
+	serverLauncher := launcher.NewLauncher(cfg.Archon)
+	serverUsecase := usecase.NewServerUsecase(cfg.Archon, game, cfg.Games, serverLauncher, serverLauncher, network.NewPortChecker(), newServerSnapshot, locker, procFinder)
+
+	switch job.Kind {
+	case domain.JobBackup:
+		backupUsecase := usecase.NewBackupUsecase(cfg.Archon, game, snap, fs, autoCli, locker)
+		_, err := backupUsecase.Execute(ctx, domain.BackupOptions{Trigger: domain.TriggerSchedule})
+		return err
+	case domain.JobUpdate:
+		// 最新の場合はサーバを停止しない
+		updateUsecase := newUpdateUsecase(game, autoCli)
+		status, err := updateUsecase.Check(ctx)
+		switch {
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "%s の更新の確認に失敗したため、そのまま更新します: %v\n", job.Game, err)
+		case !status.Available():
+			fmt.Printf("%s は最新です。(%s)\n", job.Game, status.InstalledVersion)
+			return nil
+		}
+
+		// サーバが実行中であれば停止してから更新し、更新後に再び起動する
+		return serverUsecase.WhileStopped(ctx, "update", func() error {
+			return updateUsecase.Execute(ctx)
+		})
+	case domain.JobRestart:
+		return serverUsecase.Restart(ctx)
+	case domain.JobPrune:
+		backupsUsecase := usecase.NewBackupsUsecase(cfg.Archon, game, snap, fs, locker)
+		return backupsUsecase.Prune(ctx, game.Schedule.GetKeep())
+	default:
+		return fmt.Errorf("不明なジョブです: %s", job.Kind)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(scheduleCmd)
+	scheduleCmd.AddCommand(scheduleRunCmd)
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nonuplet/grimoire-archon/internal/adapter/snapshot"
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+	"github.com/nonuplet/grimoire-archon/internal/infra/launcher"
+	"github.com/nonuplet/grimoire-archon/internal/infra/network"
+	"github.com/nonuplet/grimoire-archon/internal/usecase"
+)
+
+// startCmd startコマンドの生成
+var startCmd = &cobra.Command{
+	Use:   "start <name>",
+	Short: "指定したゲームのサーバを起動します。",
+	Long: `指定したゲームのサーバを run.command で起動します。run.args は分割・展開されずにそのまま引数として渡されます。
+run.workdir (デフォルトで install_dir) で起動し、run.env_file と run.envs の環境変数を設定します。
+Linux では run.user, run.umask, run.nice, run.ionice も適用されます。
+ports に指定したポートが他のプロセスに使用されている場合は起動しません。
+Linux では resources を設定すると、サーバを専用の cgroup v2 に配置してメモリ、CPU、プロセス数、I/Oの重みを制限します。
+Linux では isolation.enabled を有効にすると、サーバを新しい user, mount, PID 名前空間のサンドボックスで起動します。
+サンドボックス内では install_dir, prefix, backup_targets, isolation.writable 以外は読み取り専用になり、/tmp は専用のものになります。
+サーバはバックグラウンドで動作し、出力は state_dir 以下の logs/<ゲーム名>.log に保存されます。
+`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: recoverAnnotations(),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serverUsecase, err := newServerUsecase(args[0])
+		if err != nil {
+			return err
+		}
+		if err := serverUsecase.Start(cmd.Context()); err != nil {
+			return fmt.Errorf("%s の起動に失敗しました : %w", args[0], err)
+		}
+		return nil
+	},
+}
+
+// stopCmd stopコマンドの生成
+var stopCmd = &cobra.Command{
+	Use:   "stop <name>",
+	Short: "指定したゲームのサーバを停止します。",
+	Long: `指定したゲームのサーバに終了を要求し、終了するまで待機します。
+run.stop_command が設定されている場合はそれを実行し、そうでなければ run.stop_signal (デフォルトで SIGTERM) を送ります。
+run.stop_timeout (デフォルトで30秒) 以内に終了しない場合は強制終了します。
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serverUsecase, err := newServerUsecase(args[0])
+		if err != nil {
+			return err
+		}
+		if err := serverUsecase.Stop(cmd.Context()); err != nil {
+			return fmt.Errorf("%s の停止に失敗しました : %w", args[0], err)
+		}
+		return nil
+	},
+}
+
+// restartCmd restartコマンドの生成
+var restartCmd = &cobra.Command{
+	Use:         "restart <name>",
+	Short:       "指定したゲームのサーバを再起動します。",
+	Long:        "指定したゲームのサーバを停止してから起動します。実行中でない場合はそのまま起動します。",
+	Args:        cobra.ExactArgs(1),
+	Annotations: recoverAnnotations(),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		serverUsecase, err := newServerUsecase(args[0])
+		if err != nil {
+			return err
+		}
+		if err := serverUsecase.Restart(cmd.Context()); err != nil {
+			return fmt.Errorf("%s の再起動に失敗しました : %w", args[0], err)
+		}
+		return nil
+	},
+}
+
+// newServerUsecase ゲーム名から ServerUsecase を生成する
+func newServerUsecase(name string) (*usecase.ServerUsecase, error) {
+	game, ok := cfg.Games[name]
+	if !ok {
+		return nil, fmt.Errorf("%s は設定されていません。コンフィグを確認してください", name)
+	}
+	serverLauncher := launcher.NewLauncher(cfg.Archon)
+	return usecase.NewServerUsecase(cfg.Archon, game, cfg.Games, serverLauncher, serverLauncher, network.NewPortChecker(), newServerSnapshot, locker, procFinder), nil
+}
+
+// newServerSnapshot isolation で書き込みを許可する backup_targets のパスの解決に使用する Snapshot を生成する
+func newServerSnapshot(gameCfg *domain.GameConfig) usecase.Snapshot {
+	return snapshot.NewSnapshot(cfg.Archon, gameCfg, fs, cliUtil)
+}
+
+func init() {
+	rootCmd.AddCommand(startCmd)
+	rootCmd.AddCommand(stopCmd)
+	rootCmd.AddCommand(restartCmd)
+}
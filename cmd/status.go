@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nonuplet/grimoire-archon/internal/infra/cgroup"
+	"github.com/nonuplet/grimoire-archon/internal/usecase"
+)
+
+// statusCmd statusコマンドの生成
+var statusCmd = &cobra.Command{
+	Use:   "status <name>",
+	Short: "指定したゲームのサーバの状態を表示します。",
+	Long: `指定したゲームのサーバが実行中かどうかを表示します。
+steam が設定されている場合は、steam.branch とインストール済みのブランチ・ビルドIDを表示します。
+resources が設定されている場合は、ゲームの cgroup からメモリ、CPU時間、プロセス数、I/O の使用状況と上限を表示します。
+cgroup は archon.cgroup.root (デフォルトで /sys/fs/cgroup) の archon.cgroup.parent (デフォルトで archon.slice) 以下の <ゲーム名> です。
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		game, ok := cfg.Games[name]
+		if !ok {
+			return fmt.Errorf("%s は設定されていません。コンフィグを確認してください", name)
+		}
+
+		statusUsecase := usecase.NewStatusUsecase(game, fs, procFinder, cgroup.NewCgroup(cfg.Archon))
+		if err := statusUsecase.Execute(); err != nil {
+			return fmt.Errorf("%s の状態の取得に失敗しました : %w", name, err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+}
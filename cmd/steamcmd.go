@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nonuplet/grimoire-archon/internal/infra/download"
+	"github.com/nonuplet/grimoire-archon/internal/infra/steamcmd"
+	"github.com/nonuplet/grimoire-archon/internal/usecase"
+)
+
+// steamcmdCmd steamcmdコマンドの生成
+var steamcmdCmd = &cobra.Command{
+	Use:   "steamcmd",
+	Short: "archon が使用するsteamcmdを管理します。",
+	Long:  "archon が使用するsteamcmdを管理します。サブコマンドを指定してください。",
+}
+
+// steamcmdInstallCmd steamcmd installコマンドの生成
+var steamcmdInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "steamcmdをダウンロードしてインストールします。",
+	Long: `steamcmdのアーカイブを steamcmd.download_url (デフォルトで公式のURL) からダウンロードし、
+steamcmd.install_dir (デフォルトで state_dir/steamcmd) に展開します。展開後、steamcmd自体を一度更新します。
+steamcmd.path が設定されていない場合、archon はこのsteamcmdを使用します。
+`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		steamCmdUsecase := usecase.NewSteamCmdUsecase(cfg.Archon, newSteamCmd(), download.NewDownloader(), fs, locker)
+		if err := steamCmdUsecase.Install(cmd.Context()); err != nil {
+			return fmt.Errorf("steamcmdのインストールに失敗しました : %w", err)
+		}
+		return nil
+	},
+}
+
+// newSteamCmd コンフィグに従ってSteamCmdを生成する
+func newSteamCmd() *steamcmd.SteamCmd {
+	return steamcmd.NewSteamCmd(cfg.Archon.GetSteamCmdPath(), cfg.Archon.GetSteamCmdInstallDir(), newCredentialStore())
+}
+
+func init() {
+	rootCmd.AddCommand(steamcmdCmd)
+	steamcmdCmd.AddCommand(steamcmdInstallCmd)
+}
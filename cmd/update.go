@@ -1,21 +1,63 @@
 package cmd
 
 import (
-	"context"
+	"errors"
 	"fmt"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
 
-	"github.com/nonuplet/grimoire-archon/internal/infra/steamcmd"
+	"github.com/nonuplet/grimoire-archon/internal/adapter/snapshot"
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+	"github.com/nonuplet/grimoire-archon/internal/infra/cli"
+	"github.com/nonuplet/grimoire-archon/internal/infra/download"
+	"github.com/nonuplet/grimoire-archon/internal/infra/launcher"
+	"github.com/nonuplet/grimoire-archon/internal/infra/provider"
 	"github.com/nonuplet/grimoire-archon/internal/usecase"
 )
 
+// exitUpdateAvailable update --check で更新がある場合の終了コード
+const exitUpdateAvailable = 2
+
+// errUpdateAvailable update --check で更新があることを示す。Execute で終了コード exitUpdateAvailable に変換する
+var errUpdateAvailable = errors.New("更新があります")
+
+var (
+	// updateCheck --check フラグ
+	updateCheck bool
+	// updateRollback --rollback フラグ
+	updateRollback bool
+)
+
 // updateCmd updateコマンドの生成
 var updateCmd = &cobra.Command{
 	Use:   "update <name>",
 	Short: "指定したゲームを更新します。",
-	Long:  "指定したゲームを更新します。引数で .archon.yaml のコンフィグで指定したゲーム名を渡してください。",
-	Args:  cobra.ExactArgs(1),
+	Long: `指定したゲームを更新します。引数で .archon.yaml のコンフィグで指定したゲーム名を渡してください。
+install.provider でインストール元 (steam, http, minecraft-vanilla, papermc) を指定します。未指定の場合は steam です。
+  steam: steam の設定に従ってsteamcmdでインストールします。通信の失敗など一時的なエラーの場合は、間隔をあけて最大3回まで実行します。
+  http: install.url のアーカイブ (zip, tar) をダウンロードし、install.sha256 を確認して展開します。Terraria などに使用します。
+  minecraft-vanilla: Mojangのバージョンマニフェストから install.version (latest, snapshot, バージョン) のサーバをダウンロードします。
+  papermc: PaperMCのAPIから install.project (デフォルトで paper) の install.version の最新ビルドをダウンロードします。
+
+update.backup を有効にすると、更新前にバックアップ対象とインストール済みのバージョンを表すファイルをバックアップします。
+更新または update.health_check による確認に失敗した場合、update.rollback (ask, auto, never) に従って更新前の状態に戻します。
+update.mode を staged にすると、install_dir と同じ階層に複製 (<install_dir>.archon-staging) を作成して更新し、
+更新後の確認に成功した場合のみ install_dir と入れ替えます。失敗した場合、install_dir は変更されません。
+複製にはリフリンクを使用し、使用できない場合はコピーします。(update.hardlink を有効にするとハードリンクを使用します)
+更新前の install_dir は <install_dir>.archon-previous に保存され、--rollback で戻せます。
+
+server_config と preserve に指定したファイルは、更新後に更新前の内容に戻されます。
+配布元の既定値が前回の更新から変わっている場合は、その差分を表示します。
+
+workshop.sync_on_update を有効にすると、更新後にワークショップのアイテムを同期します。(mods sync を参照)
+
+steam.branch を指定すると、そのブランチ(beta)に更新します。steam.validate を指定するとファイルの検証も行います。
+--check を指定すると、更新は行わずにインストール済みのバージョンと配信中の最新バージョンを比較します。
+終了コードは、最新の場合は 0、更新がある場合は 2、エラーの場合は 1 です。
+`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: recoverAnnotations(),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
 
@@ -24,13 +66,21 @@ var updateCmd = &cobra.Command{
 			return fmt.Errorf("%s は設定されていません。コンフィグを確認してください", name)
 		}
 
-		ctx := context.Background()
-		steam := steamcmd.NewSteamCmd()
-		updateUsecase := usecase.NewUpdateUsecase(game, steam, fs)
+		updateUsecase := newUpdateUsecase(game, cliUtil)
+
+		if updateCheck {
+			return checkUpdate(cmd, name, updateUsecase)
+		}
+		if updateRollback {
+			if err := updateUsecase.Rollback(cmd.Context()); err != nil {
+				return fmt.Errorf("%s を更新前の状態に戻せませんでした : %w", name, err)
+			}
+			return nil
+		}
 
 		fmt.Printf("%s を更新中...\n", name)
 
-		if err := updateUsecase.Execute(ctx); err != nil {
+		if err := updateUsecase.Execute(cmd.Context()); err != nil {
 			return fmt.Errorf("%s のアップデートに失敗しました : %w", name, err)
 		}
 
@@ -39,6 +89,76 @@ var updateCmd = &cobra.Command{
 	},
 }
 
+// checkUpdate 更新の有無を表示する
+// スクリプトから利用できるよう、更新がある場合は errUpdateAvailable を返し、Execute で終了コード 2 で終了する
+func checkUpdate(cmd *cobra.Command, name string, updateUsecase *usecase.UpdateUsecase) error {
+	status, err := updateUsecase.Check(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("%s の更新の確認に失敗しました : %w", name, err)
+	}
+
+	switch {
+	case status.InstalledVersion == "":
+		fmt.Printf("%s はインストールされていません。(最新: %s)\n", name, versionLabel(status.Branch, status.LatestVersion))
+	case status.InstalledBranch != status.Branch:
+		fmt.Printf("%s のブランチが変更されています。(%s → %s)\n",
+			name, versionLabel(status.InstalledBranch, status.InstalledVersion), versionLabel(status.Branch, status.LatestVersion))
+	case status.Available():
+		fmt.Printf("%s の更新があります。(%s → %s)\n", name, versionLabel(status.Branch, status.InstalledVersion), status.LatestVersion)
+	default:
+		fmt.Printf("%s は最新です。(%s)\n", name, versionLabel(status.Branch, status.InstalledVersion))
+		return nil
+	}
+
+	// エラーではないため、cobra のエラー・使い方の表示は行わない
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	return errUpdateAvailable
+}
+
+// versionLabel ブランチがある場合は "ブランチ:バージョン" の形式で返す
+func versionLabel(branch, version string) string {
+	if branch == "" {
+		return version
+	}
+	return branch + ":" + version
+}
+
+// newInstallProvider install.provider に従ってインストール元を生成する
+func newInstallProvider(game *domain.GameConfig) usecase.InstallProvider {
+	downloadDir := filepath.Join(cfg.Archon.GetStateDir(), "downloads")
+	switch game.Install.GetProvider() {
+	case domain.InstallProviderHTTP:
+		return provider.NewHTTP(fs, download.NewDownloader(), downloadDir)
+	case domain.InstallProviderMinecraft:
+		return provider.NewMinecraft(fs, download.NewDownloader(), downloadDir)
+	case domain.InstallProviderPaperMC:
+		return provider.NewPaperMC(fs, download.NewDownloader(), downloadDir)
+	default:
+		return provider.NewSteam(newSteamCmd(), fs)
+	}
+}
+
+// newUpdateUsecase UpdateUsecaseの生成
+// update.rollback が auto の場合、ロールバック時の確認には自動で応答する。
+func newUpdateUsecase(game *domain.GameConfig, c *cli.Util) *usecase.UpdateUsecase {
+	restoreCli := c
+	if game.Update.GetRollback() == domain.RollbackAuto {
+		restoreCli = cli.NewNonInteractiveCliUtil()
+	}
+	newSnapshot := func(gameCfg *domain.GameConfig) usecase.Snapshot {
+		return snapshot.NewSnapshot(cfg.Archon, gameCfg, fs, restoreCli)
+	}
+
+	return usecase.NewUpdateUsecase(cfg.Archon, game, newInstallProvider(game), fs, c, locker, procFinder, launcher.NewLauncher(cfg.Archon), newSnapshot, newModsUsecase(game))
+}
+
 func init() {
 	rootCmd.AddCommand(updateCmd)
+
+	// --check
+	updateCmd.Flags().BoolVar(&updateCheck, "check", false, "更新は行わずに、更新があるかを確認します")
+	// --rollback
+	updateCmd.Flags().BoolVar(&updateRollback, "rollback", false, "update.mode が staged の場合に、更新前の状態に戻します")
+	updateCmd.MarkFlagsMutuallyExclusive("check", "rollback")
 }
@@ -1,6 +1,7 @@
 package snapshot
 
 import (
+	"context"
 	"io"
 	"os"
 
@@ -19,9 +20,15 @@ type Snapshot struct {
 type FileSystem interface {
 	Stat(path string) (os.FileInfo, error)
 	ReadFile(path string) ([]byte, error)
+	ReadDir(path string) ([]os.DirEntry, error)
 	WriteFile(path string, data []byte, perm os.FileMode) error
 	MkdirAll(path string, perm os.FileMode) error
-	CopyFileOrDir(src, dst string, overwrite bool) error
+	CopyFileOrDir(ctx context.Context, src, dst string, overwrite bool) error
+	ListExtraFiles(src, dst string) (replaced, extras []string, err error)
+	RemoveAll(path string) error
+	ReadZipEntry(zipPath, name string) ([]byte, error)
+	ListZipEntries(zipPath string) ([]string, error)
+	ReplaceZipEntry(zipPath, name string, data []byte) error
 }
 
 // Cli cli操作のインターフェース
@@ -1,6 +1,7 @@
 package snapshot
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
 	"time"
@@ -12,7 +13,7 @@ import (
 
 // CopyToTmp は gameConfig で指定されたバックアップ対象ファイルを tmpDir にコピーします。
 // コピーしたファイルの FileEntry 一覧を返します。
-func (snap Snapshot) CopyToTmp(tmpDir string) ([]domain.FileEntry, error) {
+func (snap Snapshot) CopyToTmp(ctx context.Context, tmpDir string) ([]domain.FileEntry, error) {
 	bt := snap.gameCfg.BackupTargets
 	if bt.IsEmpty() {
 		return nil, nil
@@ -20,25 +21,10 @@ func (snap Snapshot) CopyToTmp(tmpDir string) ([]domain.FileEntry, error) {
 
 	resolvers := snap.buildResolvers()
 
-	type targetSpec struct {
-		baseType domain.BaseType
-		patterns []string
-	}
-
-	specs := []targetSpec{
-		{domain.BaseTypeInstallDir, bt.InstallDir},
-		{domain.BaseTypeUserHome, bt.UserHome},
-		{domain.BaseTypeAppdataLocal, bt.WinAppdataLocal},
-		{domain.BaseTypeAppdataLocalLow, bt.WinAppdataLocalLow},
-		{domain.BaseTypeAppdataRoaming, bt.WinAppdataRoaming},
-		{domain.BaseTypeWinDocuments, bt.WinDocuments},
-		{domain.BaseTypeAbsolute, bt.Absolute},
-	}
-
 	var entries []domain.FileEntry
 
 	// 各タイプ(install_dir, user_home, ...)ごとに処理
-	for _, spec := range specs {
+	for _, spec := range targetSpecs(bt) {
 		// 各タイプのリゾルバ取得
 		resolver, ok := resolvers[spec.baseType]
 		if !ok {
@@ -54,7 +40,7 @@ func (snap Snapshot) CopyToTmp(tmpDir string) ([]domain.FileEntry, error) {
 			dst := filepath.Join(tmpDir, string(spec.baseType), pattern)
 
 			// コピーする
-			newEntry, err := snap.copyEntries(src, dst, spec.baseType, pattern)
+			newEntry, err := snap.copyEntries(ctx, src, dst, spec.baseType, pattern)
 			if err != nil {
 				return nil, err
 			}
@@ -81,7 +67,7 @@ func (snap Snapshot) SaveMetaData(path string, meta *domain.Metadata) error {
 
 // copyEntries は src を dst へコピーし、FileEntry 一覧を返します。
 // ファイル/ディレクトリの判定は util.CopyFileOrDir に委譲します。
-func (snap Snapshot) copyEntries(src, dst string, baseType domain.BaseType, originalPath string) (domain.FileEntry, error) {
+func (snap Snapshot) copyEntries(ctx context.Context, src, dst string, baseType domain.BaseType, originalPath string) (domain.FileEntry, error) {
 	// コピー元のinfo取得
 	info, err := snap.fs.Stat(src)
 	if err != nil {
@@ -89,7 +75,7 @@ func (snap Snapshot) copyEntries(src, dst string, baseType domain.BaseType, orig
 	}
 
 	// コピー
-	if err := snap.fs.CopyFileOrDir(src, dst, false); err != nil {
+	if err := snap.fs.CopyFileOrDir(ctx, src, dst, false); err != nil {
 		return domain.FileEntry{}, fmt.Errorf("コピーに失敗しました: %w", err)
 	}
 
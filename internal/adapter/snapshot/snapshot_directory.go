@@ -1,6 +1,7 @@
 package snapshot
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -10,7 +11,7 @@ import (
 func (snap Snapshot) CheckAndCreateSnapshotDir() error {
 	snapshotPath := filepath.Join(snap.archonCfg.BackupDir, snap.gameCfg.Name)
 
-	if _, err := snap.fs.Stat(snapshotPath); os.IsNotExist(err) {
+	if _, err := snap.fs.Stat(snapshotPath); errors.Is(err, os.ErrNotExist) {
 		// Ask
 		ok, askErr := snap.cli.AskYesNo(os.Stdin, fmt.Sprintf("バックアップ用ディレクトリ '%s' が存在しません。作成しますか?", snapshotPath), true)
 		if askErr != nil {
@@ -0,0 +1,216 @@
+package snapshot
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+// metaFileName はスナップショット内のメタデータファイル名です。
+const metaFileName = "metadata.yaml"
+
+// metaMigration はメタデータを1つ新しいバージョンへ変換する関数です。
+// 生のYAMLをデコードしたマップを受け取り、その場で書き換えます。
+type metaMigration = func(raw map[string]any) error
+
+// metaMigrations は "変換元バージョン" をキーとしたマイグレーションの一覧です。
+// domain.MetaVersion をインクリメントした場合、旧バージョンからのマイグレーションをここに登録します。
+// 例: "1" -> "2" のマイグレーションは metaMigrations["1"] に登録します。
+var metaMigrations = map[string]metaMigration{
+	"1": migrateMetaV1ToV2,
+	"2": migrateMetaV2ToV3,
+}
+
+// migrateMetaV1ToV2 はバージョン1のメタデータをバージョン2に変換します。
+// バージョン2ではタグ, メモ, 作成のきっかけ(trigger)が追加されました。
+// バージョン1のバックアップは作成のきっかけが不明なため、trigger は空のままにします。
+func migrateMetaV1ToV2(raw map[string]any) error {
+	if _, ok := raw["tags"]; !ok {
+		raw["tags"] = []any{}
+	}
+	return nil
+}
+
+// migrateMetaV2ToV3 はバージョン2のメタデータをバージョン3に変換します。
+// バージョン3では実行環境(runtime_env)とWindowsユーザープロファイル(win_profile)が追加されました。
+// バージョン2以前のバックアップでは不明なため空のままにし、リストア時はパスから推定します。
+func migrateMetaV2ToV3(_ map[string]any) error {
+	return nil
+}
+
+// loadMetaData 指定したパスからメタデータをロード
+func (snap Snapshot) loadMetaData(path string) (*domain.Metadata, error) {
+	if _, err := snap.fs.Stat(path); err != nil {
+		return nil, fmt.Errorf("metadata.yamlの取得に失敗しました: %w", err)
+	}
+
+	data, err := snap.fs.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("metadata.yamlのリードに失敗しました: %w", err)
+	}
+
+	meta, _, err := decodeMetaData(data)
+	if err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// LoadArchiveMetaData はスナップショットのzipファイルからメタデータを読み込みます。
+// 古いバージョンのメタデータはメモリ上で現在のバージョンに変換されます。
+func (snap Snapshot) LoadArchiveMetaData(zipPath string) (*domain.Metadata, error) {
+	entryName, err := snap.archiveMetaPath(zipPath)
+	if err != nil {
+		return nil, err
+	}
+	data, err := snap.fs.ReadZipEntry(zipPath, entryName)
+	if err != nil {
+		return nil, fmt.Errorf("metadata.yamlの読み込みに失敗しました: %w", err)
+	}
+
+	meta, _, err := decodeMetaData(data)
+	if err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// MigrateArchive はスナップショットのzipファイル内のメタデータを現在のバージョンに書き換えます。
+// 書き換えを行った場合は true を返します。すでに最新の場合は何もせず false を返します。
+func (snap Snapshot) MigrateArchive(zipPath string) (bool, error) {
+	entryName, err := snap.archiveMetaPath(zipPath)
+	if err != nil {
+		return false, err
+	}
+	data, err := snap.fs.ReadZipEntry(zipPath, entryName)
+	if err != nil {
+		return false, fmt.Errorf("metadata.yamlの読み込みに失敗しました: %w", err)
+	}
+
+	meta, migrated, err := decodeMetaData(data)
+	if err != nil {
+		return false, err
+	}
+	if !migrated {
+		return false, nil
+	}
+
+	newData, err := yaml.Marshal(meta)
+	if err != nil {
+		return false, fmt.Errorf("metadata.yamlのマーシャリングに失敗しました: %w", err)
+	}
+	if err := snap.fs.ReplaceZipEntry(zipPath, entryName, newData); err != nil {
+		return false, fmt.Errorf("metadata.yamlの書き換えに失敗しました: %w", err)
+	}
+
+	return true, nil
+}
+
+// ArchiveRoot はスナップショットのzipファイル内の、メタデータを含むディレクトリの名前を返します。
+// zipファイルの名前が変更されていても、展開後のディレクトリを特定するために使用します。
+func (snap Snapshot) ArchiveRoot(zipPath string) (string, error) {
+	entryName, err := snap.archiveMetaPath(zipPath)
+	if err != nil {
+		return "", err
+	}
+	return path.Dir(entryName), nil
+}
+
+// archiveMetaPath はzipファイル内のメタデータのパスを返します。
+// スナップショットは <archive名>/metadata.yaml の構成で保存されています。
+// zipファイルは名前を変更・コピーされている場合があるため、zipファイルの名前ではなく中身から探します。
+func (snap Snapshot) archiveMetaPath(zipPath string) (string, error) {
+	names, err := snap.fs.ListZipEntries(zipPath)
+	if err != nil {
+		return "", fmt.Errorf("zipファイルの読み込みに失敗しました: %w", err)
+	}
+
+	var found []string
+	for _, name := range names {
+		dir, file := path.Split(name)
+		if file == metaFileName && dir != "" && !strings.Contains(strings.TrimSuffix(dir, "/"), "/") {
+			found = append(found, name)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("zipファイル内に %s が見つかりません。archon で作成したスナップショットか確認してください", metaFileName)
+	case 1:
+		return found[0], nil
+	default:
+		return "", fmt.Errorf("zipファイル内に %s が複数あります (%s)", metaFileName, strings.Join(found, ", "))
+	}
+}
+
+// decodeMetaData はメタデータのバージョンを判定し、必要であれば現在のバージョンまで変換してデコードします。
+// 変換を行った場合は migrated が true になります。
+func decodeMetaData(data []byte) (meta *domain.Metadata, migrated bool, err error) {
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, false, fmt.Errorf("metadata.yamlのデコードに失敗しました: %w", err)
+	}
+	if raw == nil {
+		return nil, false, fmt.Errorf("metadata.yamlが空です")
+	}
+
+	version, err := getMetaVersion(raw)
+	if err != nil {
+		return nil, false, err
+	}
+
+	current, err := strconv.Atoi(domain.MetaVersion)
+	if err != nil {
+		return nil, false, fmt.Errorf("現在のメタデータバージョン %s が不正です: %w", domain.MetaVersion, err)
+	}
+	if version > current {
+		return nil, false, fmt.Errorf(
+			"メタデータのバージョン(%d)がこのarchonの対応バージョン(%d)より新しいため読み込めません。archonを更新してください", version, current)
+	}
+
+	// 1バージョンずつ順に変換する
+	for v := version; v < current; v++ {
+		migrate, ok := metaMigrations[strconv.Itoa(v)]
+		if !ok {
+			return nil, false, fmt.Errorf("メタデータのバージョン %d から %d へのマイグレーションが登録されていません", v, v+1)
+		}
+		if err := migrate(raw); err != nil {
+			return nil, false, fmt.Errorf("メタデータのバージョン %d から %d へのマイグレーションに失敗しました: %w", v, v+1, err)
+		}
+		raw["version"] = strconv.Itoa(v + 1)
+		migrated = true
+	}
+
+	// 変換後のマップを構造体へ詰め替える
+	normalized, err := yaml.Marshal(raw)
+	if err != nil {
+		return nil, false, fmt.Errorf("metadata.yamlの再エンコードに失敗しました: %w", err)
+	}
+	meta = &domain.Metadata{}
+	if err := yaml.Unmarshal(normalized, meta); err != nil {
+		return nil, false, fmt.Errorf("metadata.yamlのデコードに失敗しました: %w", err)
+	}
+
+	return meta, migrated, nil
+}
+
+// getMetaVersion はデコード済みのメタデータからバージョン番号を取得します。
+func getMetaVersion(raw map[string]any) (int, error) {
+	v, ok := raw["version"]
+	if !ok || v == nil {
+		return 0, fmt.Errorf("metadata.yamlにバージョン情報がありません")
+	}
+
+	version, err := strconv.Atoi(fmt.Sprint(v))
+	if err != nil {
+		return 0, fmt.Errorf("metadata.yamlのバージョン %v が不正です: %w", v, err)
+	}
+	if version < 1 {
+		return 0, fmt.Errorf("metadata.yamlのバージョン %d が不正です", version)
+	}
+	return version, nil
+}
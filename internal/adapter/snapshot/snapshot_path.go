@@ -49,3 +49,55 @@ func (snap Snapshot) buildResolvers() map[domain.BaseType]pathResolver {
 		domain.BaseTypeAbsolute:        resolveAbsolute,
 	}
 }
+
+// targetSpec は BaseType ごとのバックアップ対象です。
+type targetSpec struct {
+	baseType domain.BaseType
+	patterns []string
+}
+
+// targetSpecs は backup_targets を BaseType ごとの一覧に変換します。
+func targetSpecs(bt *domain.BackupTargetConfig) []targetSpec {
+	return []targetSpec{
+		{domain.BaseTypeInstallDir, bt.InstallDir},
+		{domain.BaseTypeUserHome, bt.UserHome},
+		{domain.BaseTypeAppdataLocal, bt.WinAppdataLocal},
+		{domain.BaseTypeAppdataLocalLow, bt.WinAppdataLocalLow},
+		{domain.BaseTypeAppdataRoaming, bt.WinAppdataRoaming},
+		{domain.BaseTypeWinDocuments, bt.WinDocuments},
+		{domain.BaseTypeAbsolute, bt.Absolute},
+	}
+}
+
+// TargetPaths は backup_targets の全てのパスを、現在の実行環境での絶対パスに解決して返します。
+// サンドボックスで書き込みを許可するセーブデータのパスに使用します。
+func (snap Snapshot) TargetPaths() ([]string, error) {
+	bt := snap.gameCfg.BackupTargets
+	if bt.IsEmpty() {
+		return nil, nil
+	}
+
+	resolvers := snap.buildResolvers()
+	var paths []string
+	for _, spec := range targetSpecs(bt) {
+		resolver := resolvers[spec.baseType]
+		for _, pattern := range spec.patterns {
+			path, err := resolver(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("ベースパスの解決に失敗しました (type=%s, pattern=%s): %w", spec.baseType, pattern, err)
+			}
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// GetWinProfile は現在の実行環境における Windows ユーザープロファイルディレクトリを返します。
+// ネイティブのLinux環境など、Windowsのプロファイルが存在しない場合は空文字列を返します。
+func (snap Snapshot) GetWinProfile() string {
+	profile, err := snap.resolveWinProfile()
+	if err != nil {
+		return ""
+	}
+	return profile
+}
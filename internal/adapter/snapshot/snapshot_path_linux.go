@@ -26,9 +26,34 @@ func (snap Snapshot) resolveWinAppdata(subDir string) (string, error) {
 		return filepath.Join(snap.archonCfg.AppdataDir, string(dirType)), nil
 	}
 
-	home, err := os.UserHomeDir()
+	profile, err := snap.resolveWinProfile()
 	if err != nil {
-		return "", fmt.Errorf("ホームディレクトリが取得できません: %w", err)
+		return "", err
+	}
+
+	if dirType == domain.DirectoryDocuments {
+		return filepath.Join(profile, string(domain.DirectoryDocuments)), nil
+	}
+	return filepath.Join(profile, "AppData", string(dirType)), nil
+}
+
+// resolveWinProfile は RuntimeEnv に応じた Windows のユーザープロファイルディレクトリ(C:\Users\<user> に相当)を返します。
+// プレフィックスやSteamライブラリは、サーバを実行するユーザー (run.user が設定されている場合はそのユーザー) のものを使用します。
+func (snap Snapshot) resolveWinProfile() (string, error) {
+	home, userName, err := snap.gameCfg.RunUser()
+	if err != nil {
+		return "", err
+	}
+
+	// ゲーム専用のプレフィックスが設定されていれば、環境変数より優先する
+	if prefixDir := snap.gameCfg.GetPrefixDir(snap.archonCfg); prefixDir != "" {
+		winePrefix := domain.WinePrefixDir(snap.gameCfg.RuntimeEnv, prefixDir)
+		switch snap.gameCfg.RuntimeEnv {
+		case domain.RuntimeEnvWine:
+			return snap.wineUserProfile(winePrefix, userName), nil
+		case domain.RuntimeEnvProton:
+			return filepath.Join(winePrefix, "drive_c", "users", "steamuser"), nil
+		}
 	}
 
 	switch snap.gameCfg.RuntimeEnv {
@@ -36,46 +61,48 @@ func (snap Snapshot) resolveWinAppdata(subDir string) (string, error) {
 		return "", fmt.Errorf("linuxのネイティブ環境でAppDataを取得しようとしました。")
 
 	case domain.RuntimeEnvWine:
-		// Wine: ~/.wine/drive_c/users/<user>/AppData/<subDir>
+		// Wine: ~/.wine/drive_c/users/<user>
 		winePrefix := os.Getenv("WINEPREFIX")
 		if winePrefix == "" {
 			winePrefix = filepath.Join(home, ".wine")
 		}
-		if dirType == domain.DirectoryDocuments {
-			return filepath.Join(winePrefix, "drive_c", "users", filepath.Base(home), string(domain.DirectoryDocuments)), nil
-		}
-		return filepath.Join(winePrefix, "drive_c", "users", filepath.Base(home), "AppData", string(dirType)), nil
+		return snap.wineUserProfile(winePrefix, userName), nil
 
 	case domain.RuntimeEnvProton:
-		compatDataPath := os.Getenv("STEAM_COMPAT_DATA_PATH")
-
-		// 環境変数で STEAM_COMPAT_DATA_PATH が指定されている場合(Protonを手動実行している場合)
-		if compatDataPath != "" {
-			pfx := filepath.Join(compatDataPath, "pfx")
-			if dirType == domain.DirectoryDocuments {
-				return filepath.Join(pfx, "drive_c", "users", "steamuser", string(domain.DirectoryDocuments)), nil
-			}
-			return filepath.Join(pfx, "drive_c", "users", "steamuser", "AppData", string(dirType)), nil
+		// サーバの起動時と同じく、環境変数の STEAM_COMPAT_DATA_PATH、Steamライブラリの compatdata の順に探す
+		compatData, err := snap.gameCfg.ResolveProtonCompatData(snap.archonCfg, snap.fs, home, os.Getenv("STEAM_ROOT"), os.Getenv("STEAM_COMPAT_DATA_PATH"))
+		if err != nil {
+			return "", err
 		}
+		return filepath.Join(compatData, "pfx", "drive_c", "users", "steamuser"), nil
 
-		// そうでない場合(Steamクライアントを使っている場合)
-		// ProtonはデフォルトでSteam の compatdata 以下に保存する
-		if snap.gameCfg.Steam == nil || snap.gameCfg.Steam.AppID == "" {
-			return "", fmt.Errorf("proton 環境では steam 設定が必要です")
-		}
+	default:
+		return "", fmt.Errorf("未知の RuntimeEnvが指定されています: %s", snap.gameCfg.RuntimeEnv)
+	}
+}
 
-		steamRoot := os.Getenv("STEAM_ROOT")
-		if steamRoot == "" {
-			steamRoot = filepath.Join(home, ".steam", "steam")
-		}
+// wineUserProfile は wine のプレフィックスの drive_c/users/<userName> を返します。
+// 存在しない場合 (別のユーザーでプレフィックスを作成した場合など) は、プレフィックス内のユーザーが1人であればそのユーザーのものを返します。
+func (snap Snapshot) wineUserProfile(winePrefix, userName string) string {
+	usersDir := filepath.Join(winePrefix, "drive_c", "users")
+	profile := filepath.Join(usersDir, userName)
+	if _, err := snap.fs.Stat(profile); err == nil {
+		return profile
+	}
 
-		pfx := filepath.Join(steamRoot, "steamapps", "compatdata", snap.gameCfg.Steam.AppID, "pfx")
-		if dirType == domain.DirectoryDocuments {
-			return filepath.Join(pfx, "drive_c", "users", "steamuser", string(domain.DirectoryDocuments)), nil
+	entries, err := snap.fs.ReadDir(usersDir)
+	if err != nil {
+		return profile
+	}
+	var users []string
+	for _, entry := range entries {
+		// Public は全ユーザーの共有のプロファイル
+		if entry.IsDir() && entry.Name() != "Public" {
+			users = append(users, entry.Name())
 		}
-		return filepath.Join(pfx, "drive_c", "users", "steamuser", "AppData", string(dirType)), nil
-
-	default:
-		return "", fmt.Errorf("未知の RuntimeEnvが指定されています: %s", snap.gameCfg.RuntimeEnv)
 	}
+	if len(users) == 1 {
+		return filepath.Join(usersDir, users[0])
+	}
+	return profile
 }
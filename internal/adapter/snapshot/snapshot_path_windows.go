@@ -53,3 +53,21 @@ func (snap Snapshot) resolveWinAppdata(subDir string) (string, error) {
 		return "", fmt.Errorf("未知の RuntimeEnvが指定されています: %s", snap.gameCfg.RuntimeEnv)
 	}
 }
+
+// resolveWinProfile は Windows のユーザープロファイルディレクトリ(C:\Users\<user>)を返します。
+func (snap Snapshot) resolveWinProfile() (string, error) {
+	switch snap.gameCfg.RuntimeEnv {
+	case domain.RuntimeEnvWine, domain.RuntimeEnvProton:
+		return "", fmt.Errorf("Windows環境で wine または proton が選択されています。")
+
+	case "", domain.RuntimeEnvNative:
+		path, err := windows.KnownFolderPath(windows.FOLDERID_Profile, 0)
+		if err != nil {
+			return "", fmt.Errorf("ユーザープロファイルディレクトリの取得に失敗しました: %w", err)
+		}
+		return path, nil
+
+	default:
+		return "", fmt.Errorf("未知の RuntimeEnvが指定されています: %s", snap.gameCfg.RuntimeEnv)
+	}
+}
@@ -1,32 +1,35 @@
 package snapshot
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
-
-	"github.com/goccy/go-yaml"
+	"slices"
 
 	"github.com/nonuplet/grimoire-archon/internal/domain"
 )
 
 // RestoreFromTmp は archiveDir で指定されたディレクトリ内のバックアップデータをリストアします。
-func (snap Snapshot) RestoreFromTmp(archiveDir string) error {
+// opts.Mirror が true の場合、復元が完了した後にアーカイブに含まれないファイルをリストア先から削除します。
+func (snap Snapshot) RestoreFromTmp(ctx context.Context, archiveDir string, opts domain.RestoreOptions) error {
 	// metadata.yamlのロード
-	metaYaml := filepath.Join(archiveDir, "metadata.yaml")
+	metaYaml := filepath.Join(archiveDir, metaFileName)
 	meta, err := snap.loadMetaData(metaYaml)
 	if err != nil {
 		return fmt.Errorf("metadata.yamlのロードに失敗しました: %w", err)
 	}
 
-	// OSの違いをチェック
-	if osErr := snap.checkDifferentOs(meta.Os); osErr != nil {
-		return osErr
+	// 実行環境(OS, wine/proton)の違いをチェックし、パスを変換
+	translations, envErr := snap.checkDifferentRuntime(meta)
+	if envErr != nil {
+		return envErr
 	}
 
 	// バックアップリストにないファイルをリストアップ
-	notDefined := snap.getNotDefinedFiles(meta)
+	notDefined := snap.getNotDefinedFiles(meta, translations)
 	if len(notDefined) > 0 {
 		fmt.Printf("コンフィグに設定した BackupTargets 以外のファイルが見つかりました。\n\n")
 		for _, file := range notDefined {
@@ -57,88 +60,173 @@ func (snap Snapshot) RestoreFromTmp(archiveDir string) error {
 		}
 	}
 
+	// ミラーモードの場合、削除対象のファイルを確認
+	var replacedFiles, extraFiles []string
+	if opts.Mirror {
+		replacedFiles, extraFiles, err = snap.getExtraFiles(meta, archiveDir)
+		if err != nil {
+			return fmt.Errorf("削除対象ファイルの確認に失敗しました: %w", err)
+		}
+	}
+	if len(replacedFiles) > 0 || len(extraFiles) > 0 {
+		fmt.Printf("ミラーモードのため、アーカイブに含まれない以下のファイルは削除されます。\n\n")
+		for _, file := range append(slices.Clone(replacedFiles), extraFiles...) {
+			fmt.Printf("- %s\n", file)
+		}
+		ok, err := snap.cli.AskYesNo(os.Stdin, "\n対象のファイルを削除してもよろしいですか？", false)
+		if err != nil {
+			return fmt.Errorf("ユーザーの回答取得に失敗しました: %w", err)
+		}
+		if !ok {
+			return fmt.Errorf("リストアを中止しました")
+		}
+	}
+
+	// ファイルとディレクトリが入れ替わったパスは上書きできないため、コピーの前に削除する
+	for _, file := range replacedFiles {
+		if err := snap.fs.RemoveAll(file); err != nil {
+			return fmt.Errorf("ファイルの削除に失敗しました: %w", err)
+		}
+	}
+
 	fmt.Println("バックアップで復元しています...")
-	if err := snap.copyArchivedFiles(meta, archiveDir); err != nil {
+	if err := snap.copyArchivedFiles(ctx, meta, archiveDir); err != nil {
 		return fmt.Errorf("復元に失敗しました: %w", err)
 	}
 
+	// 復元に失敗した場合にデータを失わないよう、アーカイブに含まれないファイルは復元が完了してから削除する
+	for _, file := range extraFiles {
+		if err := snap.fs.RemoveAll(file); err != nil {
+			return fmt.Errorf("復元は完了しましたが、アーカイブに含まれないファイルの削除に失敗しました: %w", err)
+		}
+	}
+
 	return nil
 }
 
-// loadMetaData 指定したパスからメタデータをロード
-func (snap Snapshot) loadMetaData(path string) (*domain.Metadata, error) {
-	if _, err := snap.fs.Stat(path); err != nil {
-		return nil, fmt.Errorf("metadata.yamlの取得に失敗しました: %w", err)
+// checkDifferentRuntime はバックアップ元と現在の実行環境の違いを確認します。
+// Windowsのユーザープロファイル配下のパスは現在の環境に合わせて変換し、変換内容をユーザーに確認します。
+// 確認済みの変換内容を返します。
+func (snap Snapshot) checkDifferentRuntime(meta *domain.Metadata) ([]pathTranslation, error) {
+	currentOs := runtime.GOOS
+	currentEnv := normalizeRuntimeEnv(snap.gameCfg.RuntimeEnv)
+	differentOs := currentOs != meta.Os
+	// 古いメタデータでは実行環境が記録されていないため、比較しない
+	differentEnv := meta.RuntimeEnv != "" && normalizeRuntimeEnv(meta.RuntimeEnv) != currentEnv
+
+	translations := snap.translateEntries(meta)
+	if !differentOs && !differentEnv && len(translations) == 0 {
+		return nil, nil
 	}
 
-	data, err := snap.fs.ReadFile(path)
+	if differentOs || differentEnv {
+		fmt.Printf("バックアップ元の実行環境(%s/%s)と現在の実行環境(%s/%s)が異なります。\n",
+			meta.Os, runtimeEnvLabel(meta.RuntimeEnv), currentOs, currentEnv)
+		fmt.Printf("AppData, Documents 配下のファイルは現在の実行環境のパスに復元されます。\n")
+	}
+	if len(translations) > 0 {
+		fmt.Printf("以下のパスは現在の実行環境に合わせて変換されます。\n\n")
+		for _, t := range translations {
+			fmt.Printf("- %s: %s\n  -> %s: %s\n", t.from.BaseType, t.from.OriginalPath, t.to.BaseType, t.to.OriginalPath)
+		}
+		fmt.Println()
+	}
+
+	ok, err := snap.cli.AskYesNo(os.Stdin, "リストアを実行しますか?", false)
 	if err != nil {
-		return nil, fmt.Errorf("metadata.yamlのリードに失敗しました: %w", err)
+		return nil, fmt.Errorf("ユーザーの回答取得に失敗しました: %w", err)
 	}
+	if !ok {
+		return nil, fmt.Errorf("リストアを中止しました")
+	}
+	return translations, nil
+}
 
-	var meta domain.Metadata
-	if err := yaml.Unmarshal(data, &meta); err != nil {
-		return nil, fmt.Errorf("metadata.yamlのデコードに失敗しました: %w", err)
+// normalizeRuntimeEnv は未指定の RuntimeEnv を native として扱います。
+func normalizeRuntimeEnv(env domain.RuntimeEnv) domain.RuntimeEnv {
+	if env == "" {
+		return domain.RuntimeEnvNative
 	}
-	return &meta, nil
+	return env
 }
 
-func (snap Snapshot) checkDifferentOs(archivedOs string) error {
-	currentOs := runtime.GOOS
-	if currentOs != archivedOs {
-		q := fmt.Sprintf("バックアップ元のOS(%s)と現在のOS(%s)が異なります。実行しますか?", archivedOs, currentOs)
-		ok, err := snap.cli.AskYesNo(os.Stdin, q, false)
-		if err != nil {
-			return fmt.Errorf("ユーザーの回答取得に失敗しました: %w", err)
-		}
-		if !ok {
-			return fmt.Errorf("リストアを中止しました")
-		}
+// runtimeEnvLabel は表示用の RuntimeEnv を返します。記録されていない場合は unknown を返します。
+func runtimeEnvLabel(env domain.RuntimeEnv) string {
+	if env == "" {
+		return "unknown"
 	}
-	return nil
+	return string(env)
 }
 
 // getNotDefinedFiles はバックアップリストにないファイルを取得します。
 // archivedEntries のうち snap.gameCfg.BackupTargets に定義されていないエントリを返します。
-func (snap Snapshot) getNotDefinedFiles(meta *domain.Metadata) []domain.FileEntry {
-	archivedEntries := meta.Files
+// 実行環境の違いでパスを変換したエントリ (translations) は BaseType やパスが変わるため、リストア先のパスでも比較し、
+// 変換前のエントリがターゲットに定義されている場合も定義済みとして扱います。(変換内容は確認済みのため、再度確認しない)
+func (snap Snapshot) getNotDefinedFiles(meta *domain.Metadata, translations []pathTranslation) []domain.FileEntry {
 	targets := snap.gameCfg.BackupTargets
+	resolvers := snap.buildResolvers()
 
-	// BaseType ごとに対応するターゲットリストへマッピング
-	targetMap := map[domain.BaseType][]string{
-		domain.BaseTypeInstallDir:      snap.getTargetList(targets, domain.BaseTypeInstallDir),
-		domain.BaseTypeUserHome:        snap.getTargetList(targets, domain.BaseTypeUserHome),
-		domain.BaseTypeAppdataLocal:    snap.getTargetList(targets, domain.BaseTypeAppdataLocal),
-		domain.BaseTypeAppdataLocalLow: snap.getTargetList(targets, domain.BaseTypeAppdataLocalLow),
-		domain.BaseTypeAppdataRoaming:  snap.getTargetList(targets, domain.BaseTypeAppdataRoaming),
-		domain.BaseTypeWinDocuments:    snap.getTargetList(targets, domain.BaseTypeWinDocuments),
-		domain.BaseTypeAbsolute:        snap.getTargetList(targets, domain.BaseTypeAbsolute),
+	// ターゲットを BaseType とパスの組、およびリストア先のパスで登録する
+	type targetKey struct {
+		baseType domain.BaseType
+		path     string
+	}
+	defined := make(map[targetKey]bool)
+	definedDst := make(map[string]bool)
+	for _, baseType := range []domain.BaseType{
+		domain.BaseTypeInstallDir,
+		domain.BaseTypeUserHome,
+		domain.BaseTypeAppdataLocal,
+		domain.BaseTypeAppdataLocalLow,
+		domain.BaseTypeAppdataRoaming,
+		domain.BaseTypeWinDocuments,
+		domain.BaseTypeAbsolute,
+	} {
+		for _, t := range snap.getTargetList(targets, baseType) {
+			defined[targetKey{baseType, t}] = true
+			if dst, err := resolvers[baseType](t); err == nil {
+				definedDst[filepath.Clean(dst)] = true
+			}
+		}
 	}
 
-	var notDefined []domain.FileEntry
-	for _, entry := range archivedEntries {
-		list, ok := targetMap[entry.BaseType]
+	isDefined := func(entry domain.FileEntry) bool {
+		if defined[targetKey{entry.BaseType, entry.OriginalPath}] {
+			return true
+		}
+		resolver, ok := resolvers[entry.BaseType]
 		if !ok {
 			// 未知の BaseType はターゲット未定義とみなす
-			notDefined = append(notDefined, entry)
-			continue
+			return false
 		}
+		dst, err := resolver(entry.OriginalPath)
+		return err == nil && definedDst[filepath.Clean(dst)]
+	}
 
-		found := false
-		for _, t := range list {
-			if t == entry.OriginalPath {
-				found = true
-				break
-			}
+	var notDefined []domain.FileEntry
+	for _, entry := range meta.Files {
+		if isDefined(entry) {
+			continue
 		}
-		if !found {
-			notDefined = append(notDefined, entry)
+		if from, ok := translatedFrom(translations, entry); ok && isDefined(from) {
+			continue
 		}
+		notDefined = append(notDefined, entry)
 	}
 
 	return notDefined
 }
 
+// translatedFrom は変換後のエントリ entry の変換前のエントリを返します。変換されていない場合は false を返します。
+func translatedFrom(translations []pathTranslation, entry domain.FileEntry) (domain.FileEntry, bool) {
+	for _, t := range translations {
+		if t.to.BaseType == entry.BaseType && t.to.OriginalPath == entry.OriginalPath {
+			return t.from, true
+		}
+	}
+	return domain.FileEntry{}, false
+}
+
 // getTargetList は BackupTargetConfig から指定した BaseType に対応するパスリストを返します。
 func (snap Snapshot) getTargetList(targets *domain.BackupTargetConfig, baseType domain.BaseType) []string {
 	if targets == nil {
@@ -185,7 +273,7 @@ func (snap Snapshot) getOverwriteFiles(meta *domain.Metadata) []domain.FileEntry
 
 		if _, err := snap.fs.Stat(dst); err == nil {
 			overwriteFiles = append(overwriteFiles, entry)
-		} else if !os.IsNotExist(err) {
+		} else if !errors.Is(err, os.ErrNotExist) {
 			// 参照権限等の理由で確認できない場合も、上書き対象として扱う
 			overwriteFiles = append(overwriteFiles, entry)
 		}
@@ -194,7 +282,35 @@ func (snap Snapshot) getOverwriteFiles(meta *domain.Metadata) []domain.FileEntry
 	return overwriteFiles
 }
 
-func (snap Snapshot) copyArchivedFiles(meta *domain.Metadata, archiveDir string) error {
+// getExtraFiles はリストア先に存在し、アーカイブに含まれないファイルのリストを返します。
+// アーカイブ内でディレクトリとして保存されているエントリのみが対象です。
+// アーカイブではファイルとディレクトリが入れ替わっているパスは replaced に、アーカイブに存在しないパスは extras に分けて返します。
+func (snap Snapshot) getExtraFiles(meta *domain.Metadata, archiveDir string) (replaced, extras []string, err error) {
+	resolvers := snap.buildResolvers()
+
+	for _, entry := range meta.Files {
+		resolver, ok := resolvers[entry.BaseType]
+		if !ok {
+			continue
+		}
+		dst, err := resolver(entry.OriginalPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("パス解決に失敗しました: %w", err)
+		}
+
+		src := filepath.Join(archiveDir, entry.ArchivePath)
+		entryReplaced, entryExtras, err := snap.fs.ListExtraFiles(src, dst)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s の比較に失敗しました: %w", dst, err)
+		}
+		replaced = append(replaced, entryReplaced...)
+		extras = append(extras, entryExtras...)
+	}
+
+	return replaced, extras, nil
+}
+
+func (snap Snapshot) copyArchivedFiles(ctx context.Context, meta *domain.Metadata, archiveDir string) error {
 	resolvers := snap.buildResolvers()
 
 	if len(meta.Files) == 0 {
@@ -212,7 +328,7 @@ func (snap Snapshot) copyArchivedFiles(meta *domain.Metadata, archiveDir string)
 			return fmt.Errorf("パス解決に失敗しました: %w", err)
 		}
 
-		if err := snap.fs.CopyFileOrDir(src, dst, true); err != nil {
+		if err := snap.fs.CopyFileOrDir(ctx, src, dst, true); err != nil {
 			return fmt.Errorf("ファイル/ディレクトリのコピーに失敗しました: %w", err)
 		}
 	}
@@ -0,0 +1,131 @@
+package snapshot
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+// pathTranslation はリストア時に変換されたパスの記録です。
+type pathTranslation struct {
+	from domain.FileEntry
+	to   domain.FileEntry
+}
+
+// winProfilePattern は Windows ユーザープロファイル配下のパスを検出する正規表現です。
+// Windowsネイティブ (C:/Users/<user>/...) と wine/proton のプレフィックス (.../drive_c/users/<user>/...) に対応します。
+// パス区切りは "/" に正規化してから照合します。
+var winProfilePattern = regexp.MustCompile(`(?i)^(?:[a-z]:/users/[^/]+|.*/drive_c/users/[^/]+)/(.+)$`)
+
+// winSubDirs はプロファイル直下のディレクトリと BaseType の対応です。
+var winSubDirs = []struct {
+	prefix   []string
+	baseType domain.BaseType
+}{
+	{[]string{"AppData", "LocalLow"}, domain.BaseTypeAppdataLocalLow},
+	{[]string{"AppData", "Local"}, domain.BaseTypeAppdataLocal},
+	{[]string{"AppData", "Roaming"}, domain.BaseTypeAppdataRoaming},
+	{[]string{"Documents"}, domain.BaseTypeWinDocuments},
+}
+
+// translateEntries はバックアップ元と現在の実行環境(Windows, wine, proton)の違いを吸収するため、
+// Windows ユーザープロファイル配下を指す絶対パスのエントリを現在の環境のパスに変換します。
+// AppData, Documents 配下は対応する win_* の BaseType に変換し、現在の実行環境のプレフィックスで解決されるようにします。
+// それ以外のプロファイル配下のパスは、ユーザー名部分(steamuser, Windowsのユーザー名等)を現在の環境のものに書き換えます。
+// meta.Files は変換後のエントリで上書きされ、変換内容の一覧を返します。
+func (snap Snapshot) translateEntries(meta *domain.Metadata) []pathTranslation {
+	resolvers := snap.buildResolvers()
+	targetProfile := snap.GetWinProfile()
+
+	var translations []pathTranslation
+	for i, entry := range meta.Files {
+		if entry.BaseType != domain.BaseTypeAbsolute {
+			continue
+		}
+
+		rest, ok := trimWinProfile(entry.OriginalPath, meta.WinProfile, meta.Os == "windows")
+		if !ok {
+			continue
+		}
+
+		translated, ok := translateProfilePath(entry, rest, targetProfile)
+		if !ok {
+			continue
+		}
+
+		// 変換後も同じパスに解決される場合は何もしない
+		resolver, ok := resolvers[translated.BaseType]
+		if !ok {
+			continue
+		}
+		dst, err := resolver(translated.OriginalPath)
+		if err != nil || filepath.Clean(dst) == filepath.Clean(entry.OriginalPath) {
+			continue
+		}
+
+		meta.Files[i] = translated
+		translations = append(translations, pathTranslation{from: entry, to: translated})
+	}
+
+	return translations
+}
+
+// translateProfilePath はプロファイルからの相対パス rest を、現在の環境のエントリに変換します。
+func translateProfilePath(entry domain.FileEntry, rest, targetProfile string) (domain.FileEntry, bool) {
+	elems := strings.Split(rest, "/")
+
+	// AppData, Documents 配下は win_* の BaseType に変換する
+	for _, sub := range winSubDirs {
+		if len(elems) <= len(sub.prefix) || !hasPrefixFold(elems, sub.prefix) {
+			continue
+		}
+		translated := entry
+		translated.BaseType = sub.baseType
+		translated.OriginalPath = strings.Join(elems[len(sub.prefix):], "/")
+		return translated, true
+	}
+
+	// それ以外はプロファイルのパスを置き換える
+	if targetProfile == "" {
+		return domain.FileEntry{}, false
+	}
+	translated := entry
+	translated.OriginalPath = filepath.Join(targetProfile, filepath.FromSlash(rest))
+	return translated, true
+}
+
+// trimWinProfile は path から Windows ユーザープロファイル部分を取り除いた相対パス("/"区切り)を返します。
+// profile が空の場合(古いメタデータ)は、パスの形式からプロファイル部分を推定します。
+func trimWinProfile(path, profile string, caseInsensitive bool) (string, bool) {
+	normalized := strings.ReplaceAll(path, `\`, "/")
+
+	if profile != "" {
+		prefix := strings.TrimSuffix(strings.ReplaceAll(profile, `\`, "/"), "/") + "/"
+		if len(normalized) <= len(prefix) {
+			return "", false
+		}
+		head := normalized[:len(prefix)]
+		if head == prefix || (caseInsensitive && strings.EqualFold(head, prefix)) {
+			return normalized[len(prefix):], true
+		}
+		return "", false
+	}
+
+	m := winProfilePattern.FindStringSubmatch(normalized)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// hasPrefixFold は elems が prefix で始まるかを、大文字小文字を区別せずに判定します。
+func hasPrefixFold(elems, prefix []string) bool {
+	for i, p := range prefix {
+		if !strings.EqualFold(elems[i], p) {
+			return false
+		}
+	}
+	return true
+}
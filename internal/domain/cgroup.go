@@ -0,0 +1,251 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultCgroupRoot は cgroup v2 の一般的なマウント先です。
+	DefaultCgroupRoot = "/sys/fs/cgroup"
+	// DefaultCgroupParent は各ゲームの cgroup を作成する親の cgroup です。
+	DefaultCgroupParent = "archon.slice"
+	// CgroupMax は cgroup v2 で上限なしを表す値です。
+	CgroupMax = "max"
+	// CPUPeriod は cpu.max の期間(マイクロ秒)です。
+	CPUPeriod = 100000
+	// DefaultIOWeight は io.weight のデフォルト値です。
+	DefaultIOWeight = 100
+)
+
+// CgroupControllers は resources で使用する cgroup v2 のコントローラです。
+var CgroupControllers = []string{"memory", "cpu", "pids", "io"}
+
+// CgroupUsage は cgroup のリソースの使用状況です。
+type CgroupUsage struct {
+	// MemoryMax はメモリの上限です。上限なしの場合は空文字列です。
+	MemoryMax string
+	// PidsMax はプロセス数の上限です。上限なしの場合は空文字列です。
+	PidsMax string
+	// CPUMax は cpu.max の値です。
+	CPUMax string
+	// MemoryCurrent は現在のメモリ使用量(バイト)です。
+	MemoryCurrent int64
+	// MemoryPeak は最大のメモリ使用量(バイト)です。取得できない場合は0です。
+	MemoryPeak int64
+	// OOMKills はメモリの上限によって強制終了されたプロセスの数です。
+	OOMKills int64
+	// CPUUsageUsec はCPUの累計使用時間(マイクロ秒)です。
+	CPUUsageUsec int64
+	// CPUThrottledUsec は cpu.max によって制限された累計時間(マイクロ秒)です。
+	CPUThrottledUsec int64
+	// PidsCurrent は現在のプロセス数です。
+	PidsCurrent int64
+	// IOReadBytes はデバイスからの累計読み込みバイト数です。
+	IOReadBytes int64
+	// IOWriteBytes はデバイスへの累計書き込みバイト数です。
+	IOWriteBytes int64
+}
+
+// CPUCores は cpu.max の上限をコア数で返します。上限なしの場合は0を返します。
+func (u *CgroupUsage) CPUCores() float64 {
+	quota, period, ok := strings.Cut(u.CPUMax, " ")
+	if !ok || quota == CgroupMax {
+		return 0
+	}
+	q, err := strconv.ParseFloat(quota, 64)
+	if err != nil {
+		return 0
+	}
+	p, err := strconv.ParseFloat(period, 64)
+	if err != nil || p <= 0 {
+		return 0
+	}
+	return q / p
+}
+
+// GetCgroupRoot は cgroup v2 のマウント先を返します。
+func (a *ArchonConfig) GetCgroupRoot() string {
+	if a == nil || a.Cgroup == nil || a.Cgroup.Root == "" {
+		return DefaultCgroupRoot
+	}
+	return a.Cgroup.Root
+}
+
+// GetCgroupParent は各ゲームの cgroup を作成する親の cgroup の、マウント先からの相対パスを返します。
+func (a *ArchonConfig) GetCgroupParent() string {
+	if a == nil || a.Cgroup == nil || a.Cgroup.Parent == "" {
+		return DefaultCgroupParent
+	}
+	return a.Cgroup.Parent
+}
+
+// GetCgroupDir はゲームの cgroup のディレクトリを返します。
+func (a *ArchonConfig) GetCgroupDir(game string) string {
+	return filepath.Join(a.GetCgroupRoot(), a.GetCgroupParent(), game)
+}
+
+// MemoryMax は memory.max に書き込む値を返します。
+func (r *ResourcesConfig) MemoryMax() (string, error) {
+	if r == nil || r.Memory == "" {
+		return CgroupMax, nil
+	}
+	size, err := ParseByteSize(r.Memory)
+	if err != nil {
+		return "", fmt.Errorf("memory が不正です: %w", err)
+	}
+	return strconv.FormatInt(size, 10), nil
+}
+
+// CPUMax は cpu.max に書き込む "<quota> <period>" の値を返します。
+func (r *ResourcesConfig) CPUMax() (string, error) {
+	if r == nil || r.CPU == "" {
+		return fmt.Sprintf("%s %d", CgroupMax, CPUPeriod), nil
+	}
+
+	value, percent := strings.CutSuffix(strings.TrimSpace(r.CPU), "%")
+	cores, err := strconv.ParseFloat(value, 64)
+	if err != nil || cores <= 0 || math.IsInf(cores, 0) {
+		return "", fmt.Errorf("cpu は 2, 0.5 などのコア数か 150%% などの割合で指定してください: %s", r.CPU)
+	}
+	if percent {
+		cores /= 100
+	}
+
+	// カーネルが受け付ける最小値は 1000 マイクロ秒
+	quota := max(int64(math.Round(cores*CPUPeriod)), 1000)
+	return fmt.Sprintf("%d %d", quota, CPUPeriod), nil
+}
+
+// PidsMax は pids.max に書き込む値を返します。
+func (r *ResourcesConfig) PidsMax() string {
+	if r == nil || r.Pids <= 0 {
+		return CgroupMax
+	}
+	return strconv.Itoa(r.Pids)
+}
+
+// IOWeightValue は io.weight に書き込む値を返します。
+func (r *ResourcesConfig) IOWeightValue() string {
+	weight := DefaultIOWeight
+	if r != nil && r.IOWeight > 0 {
+		weight = r.IOWeight
+	}
+	return "default " + strconv.Itoa(weight)
+}
+
+// Validate は ResourcesConfig の値を検証します。
+func (r *ResourcesConfig) Validate() error {
+	if r == nil {
+		return nil
+	}
+
+	var errs []error
+	if _, err := r.MemoryMax(); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := r.CPUMax(); err != nil {
+		errs = append(errs, err)
+	}
+	if r.Pids < 0 {
+		errs = append(errs, fmt.Errorf("pids は正の数で指定してください: %d", r.Pids))
+	}
+	if r.IOWeight < 0 || r.IOWeight > 10000 {
+		errs = append(errs, fmt.Errorf("io_weight は 1 から 10000 で指定してください: %d", r.IOWeight))
+	}
+	return errors.Join(errs...)
+}
+
+// ParseByteSize は 512M, 4G などのサイズをバイト数に変換します。単位は1024倍です。(K, M, G, T)
+func ParseByteSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "B"), "I")
+
+	multiplier := int64(1)
+	for i, unit := range []string{"K", "M", "G", "T"} {
+		if strings.HasSuffix(s, unit) {
+			s = strings.TrimSuffix(s, unit)
+			multiplier = int64(1) << (10 * (i + 1))
+			break
+		}
+	}
+
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("サイズは 512M, 4G などの形式で指定してください")
+	}
+	size := value * float64(multiplier)
+	if size > math.MaxInt64 {
+		return 0, fmt.Errorf("サイズが大きすぎます")
+	}
+	return int64(size), nil
+}
+
+// FormatByteSize はバイト数を 1.5G などの読みやすい形式に変換します。
+func FormatByteSize(size int64) string {
+	units := []string{"K", "M", "G", "T"}
+	value := float64(size)
+	unit := ""
+	for _, u := range units {
+		if value < 1024 {
+			break
+		}
+		value /= 1024
+		unit = u
+	}
+	if unit == "" {
+		return strconv.FormatInt(size, 10) + "B"
+	}
+	return strconv.FormatFloat(value, 'f', 1, 64) + unit
+}
+
+// ParseCgroupKeyValues は cpu.stat, memory.events などの "key value" 形式のファイルをパースします。
+func ParseCgroupKeyValues(data []byte) map[string]int64 {
+	values := make(map[string]int64)
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if !ok {
+			continue
+		}
+		if n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64); err == nil {
+			values[key] = n
+		}
+	}
+	return values
+}
+
+// ParseIOStat は io.stat をパースし、全てのデバイスの読み込み・書き込みバイト数の合計を返します。
+func ParseIOStat(data []byte) (int64, int64) {
+	var read, write int64
+	for _, line := range strings.Split(string(data), "\n") {
+		// "8:0 rbytes=1 wbytes=2 rios=3 ..." の形式
+		fields := strings.Fields(line)
+		for _, field := range fields[min(1, len(fields)):] {
+			key, value, _ := strings.Cut(field, "=")
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				continue
+			}
+			switch key {
+			case "rbytes":
+				read += n
+			case "wbytes":
+				write += n
+			}
+		}
+	}
+	return read, write
+}
+
+// ParseCgroupValue は memory.current などの単一の値のファイルをパースします。max の場合は空文字列を返します。
+func ParseCgroupValue(data []byte) string {
+	value := strings.TrimSpace(string(data))
+	if value == CgroupMax {
+		return ""
+	}
+	return value
+}
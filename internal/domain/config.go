@@ -1,5 +1,12 @@
 package domain
 
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+)
+
 // RuntimeEnv ゲームの実行環境
 type RuntimeEnv string
 
@@ -20,22 +27,152 @@ type Config struct {
 
 // ArchonConfig Archonの構成
 type ArchonConfig struct {
-	BackupDir   string `yaml:"backup_dir"`
-	AppdataDir  string `yaml:"appdata_dir,omitempty"`
-	DocumentDir string `yaml:"document_dir,omitempty"`
+	BackupDir   string          `yaml:"backup_dir"`
+	AppdataDir  string          `yaml:"appdata_dir,omitempty"`
+	DocumentDir string          `yaml:"document_dir,omitempty"`
+	LockDir     string          `yaml:"lock_dir,omitempty"`
+	StateDir    string          `yaml:"state_dir,omitempty"`
+	SteamCmd    *SteamCmdConfig `yaml:"steamcmd,omitempty"`
+	Cgroup      *CgroupConfig   `yaml:"cgroup,omitempty"`
+}
+
+// CgroupConfig resources を設定したゲームのサーバを配置する cgroup v2 の構成
+type CgroupConfig struct {
+	// Root は cgroup v2 (cgroup2) のマウント先です。未設定の場合は /sys/fs/cgroup です。
+	Root string `yaml:"root,omitempty"`
+	// Parent は各ゲームの cgroup を作成する親の cgroup の、Root からの相対パスです。未設定の場合は archon.slice です。
+	Parent string `yaml:"parent,omitempty"`
+}
+
+// SteamCmdConfig steamcmdの構成
+type SteamCmdConfig struct {
+	// Path はsteamcmdの実行ファイルのパスです。未設定の場合は archon が管理するsteamcmd、PATH 上の steamcmd の順に探します。
+	Path string `yaml:"path,omitempty"`
+	// DownloadURL は steamcmd install でダウンロードするアーカイブのURLです。
+	DownloadURL string `yaml:"download_url,omitempty"`
+	// InstallDir は steamcmd install でsteamcmdを展開するディレクトリです。
+	InstallDir string `yaml:"install_dir,omitempty"`
+}
+
+const (
+	// DefaultSteamCmdURLLinux はLinux向けsteamcmdの公式のダウンロードURLです。
+	DefaultSteamCmdURLLinux = "https://steamcdn-a.akamaihd.net/client/installer/steamcmd_linux.tar.gz"
+	// DefaultSteamCmdURLWindows はWindows向けsteamcmdの公式のダウンロードURLです。
+	DefaultSteamCmdURLWindows = "https://steamcdn-a.akamaihd.net/client/installer/steamcmd.zip"
+)
+
+// GetSteamCmdPath は steamcmd.path を返します。未設定の場合は空文字列を返します。
+func (a *ArchonConfig) GetSteamCmdPath() string {
+	if a == nil || a.SteamCmd == nil {
+		return ""
+	}
+	return a.SteamCmd.Path
+}
+
+// GetSteamCmdInstallDir は archon が管理するsteamcmdのディレクトリを返します。
+// steamcmd.install_dir が未設定の場合は state_dir 以下の steamcmd ディレクトリを使用します。
+func (a *ArchonConfig) GetSteamCmdInstallDir() string {
+	if a == nil {
+		return ""
+	}
+	if a.SteamCmd != nil && a.SteamCmd.InstallDir != "" {
+		return a.SteamCmd.InstallDir
+	}
+	return filepath.Join(a.GetStateDir(), "steamcmd")
+}
+
+// GetSteamCmdDownloadURL は steamcmd install でダウンロードするURLを返します。
+// steamcmd.download_url が未設定の場合は実行中のOS向けの公式URLを返します。
+func (a *ArchonConfig) GetSteamCmdDownloadURL() string {
+	if a != nil && a.SteamCmd != nil && a.SteamCmd.DownloadURL != "" {
+		return a.SteamCmd.DownloadURL
+	}
+	if runtime.GOOS == "windows" {
+		return DefaultSteamCmdURLWindows
+	}
+	return DefaultSteamCmdURLLinux
+}
+
+// GetStateDir はスケジューラの実行状態やサーバのログを保存するディレクトリを返します。
+// state_dir が未設定の場合は backup_dir 以下の .archon ディレクトリを使用します。
+// backup_dir も未設定の場合は、カレントディレクトリに依存しないよう、ユーザーごとの状態ディレクトリを使用します。
+func (a *ArchonConfig) GetStateDir() string {
+	if a != nil && a.StateDir != "" {
+		return a.StateDir
+	}
+	if a != nil && a.BackupDir != "" {
+		return filepath.Join(a.BackupDir, ".archon")
+	}
+	return defaultStateDir()
+}
+
+// defaultStateDir はユーザーごとの状態ディレクトリを返します。
+// $XDG_STATE_HOME/archon、Windows では %LocalAppData%\archon、それ以外は ~/.local/state/archon です。
+// ホームディレクトリを取得できない場合は /var/lib/archon を使用します。(他のユーザーが先に作成できる一時ディレクトリは使用しない)
+func defaultStateDir() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "archon")
+	}
+	if runtime.GOOS == "windows" {
+		if dir, err := os.UserCacheDir(); err == nil {
+			return filepath.Join(dir, "archon")
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".local", "state", "archon")
+	}
+	return filepath.Join(string(filepath.Separator), "var", "lib", "archon")
+}
+
+// GetLockDir は操作ロックのファイルを作成するディレクトリを返します。
+// lock_dir が未設定の場合は state_dir 以下の locks ディレクトリを使用します。
+func (a *ArchonConfig) GetLockDir() string {
+	if a != nil && a.LockDir != "" {
+		return a.LockDir
+	}
+	return filepath.Join(a.GetStateDir(), "locks")
+}
+
+// PidFilePath は name のゲームの起動したサーバのPIDを記録するファイルのパスを返します。
+func (a *ArchonConfig) PidFilePath(name string) string {
+	return filepath.Join(a.GetStateDir(), "run", name+".pid")
 }
 
 // GameConfig ゲームのコンフィグ
 type GameConfig struct {
 	Run           *RunConfig          `yaml:"run,omitempty"`
 	Steam         *SteamConfig        `yaml:"steam,omitempty"`
+	Install       *InstallConfig      `yaml:"install,omitempty"`
 	BackupTargets *BackupTargetConfig `yaml:"backup_targets,omitempty"`
+	Schedule      *ScheduleConfig     `yaml:"schedule,omitempty"`
+	Update        *UpdateConfig       `yaml:"update,omitempty"`
+	Workshop      *WorkshopConfig     `yaml:"workshop,omitempty"`
+	Prefix        *PrefixConfig       `yaml:"prefix,omitempty"`
+	Runtime       *RuntimeConfig      `yaml:"runtime,omitempty"`
+	Preserve      []string            `yaml:"preserve,omitempty"` // update 後に元の内容に戻すファイル (install_dir からの相対パス)
+	Ports         []PortConfig        `yaml:"ports,omitempty"`
+	Resources     *ResourcesConfig    `yaml:"resources,omitempty"`
+	Isolation     *IsolationConfig    `yaml:"isolation,omitempty"`
 	RuntimeEnv    RuntimeEnv          `yaml:"runtime_env,omitempty"`
 	Name          string              `yaml:"name"`
 	InstallDir    string              `yaml:"install_dir"`
 	ServerConfig  string              `yaml:"server_config,omitempty"`
 }
 
+// GetPreserveFiles は update で元の内容に戻すファイルの一覧を返します。server_config も含みます。
+func (g *GameConfig) GetPreserveFiles() []string {
+	var files []string
+	if g.ServerConfig != "" {
+		files = append(files, g.ServerConfig)
+	}
+	for _, file := range g.Preserve {
+		if file != "" && !slices.Contains(files, file) {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
 // BackupTargetConfig バックアップ対象の構成
 type BackupTargetConfig struct {
 	InstallDir         []string `yaml:"install_dir,omitempty"`
@@ -64,12 +201,174 @@ func (bt *BackupTargetConfig) IsEmpty() bool {
 
 // RunConfig ゲームの実行構成
 type RunConfig struct {
-	Command string   `yaml:"command"`
-	Envs    []string `yaml:"envs,omitempty"`
+	// Nice はサーバのnice値です。(-20 から 19)
+	Nice *int `yaml:"nice,omitempty"`
+	// Command はサーバの起動コマンドです。シェル経由で実行します。
+	Command string `yaml:"command"`
+	// Args は command に追加する引数です。シェルで分割・展開されず、そのまま渡されます。
+	Args []string `yaml:"args,omitempty"`
+	// Envs は追加する環境変数です。(KEY=VALUE) env_file と同じ変数がある場合はこちらが優先されます。
+	Envs []string `yaml:"envs,omitempty"`
+	// EnvFile は環境変数を読み込むdotenv形式のファイルです。相対パスの場合は install_dir からのパスです。
+	EnvFile string `yaml:"env_file,omitempty"`
+	// Workdir はサーバを起動するディレクトリです。相対パスの場合は install_dir からのパスです。未設定の場合は install_dir です。
+	Workdir string `yaml:"workdir,omitempty"`
+	// StopCommand はサーバに終了を要求するコマンドです。(例: rcon でのシャットダウン) 設定した場合はシグナルの代わりに実行します。
+	StopCommand string `yaml:"stop_command,omitempty"`
+	// StopSignal は終了を要求する際に送るシグナルです。(SIGTERM, SIGINT, SIGHUP, SIGQUIT, SIGUSR1, SIGUSR2) 未設定の場合は SIGTERM です。
+	StopSignal string `yaml:"stop_signal,omitempty"`
+	// StopTimeout は終了を要求してから強制終了するまでの待機時間です。(例: 90s, 2m) 未設定の場合は30秒です。
+	StopTimeout string `yaml:"stop_timeout,omitempty"`
+	// User はサーバを実行するユーザーです。archon をrootで実行する必要があります。(Linuxのみ)
+	User string `yaml:"user,omitempty"`
+	// Umask はサーバのumaskです。8進数で指定します。(例: 0027) (Linuxのみ)
+	Umask string `yaml:"umask,omitempty"`
+	// IONice はサーバのI/Oの優先度です。(idle, best-effort[:0-7], realtime[:0-7]) (Linuxのみ)
+	IONice string `yaml:"ionice,omitempty"`
+}
+
+// ScheduleConfig ゲームの定期実行の構成
+// 各ジョブの実行タイミングをcron形式(分 時 日 月 曜日)で指定します。空の場合は実行しません。
+type ScheduleConfig struct {
+	Backup  string `yaml:"backup,omitempty"`
+	Update  string `yaml:"update,omitempty"`
+	Restart string `yaml:"restart,omitempty"`
+	Prune   string `yaml:"prune,omitempty"`
+	// Keep は prune で残すバックアップの数です。
+	Keep int `yaml:"keep,omitempty"`
+}
+
+// UpdateConfig ゲーム更新時の構成
+type UpdateConfig struct {
+	// HealthCheck は更新後に実行する確認コマンドです。インストールディレクトリで実行し、終了コードが0以外の場合は更新失敗として扱います。
+	HealthCheck string `yaml:"health_check,omitempty"`
+	// Rollback は更新に失敗した場合の動作です。(ask, auto, never)
+	Rollback RollbackMode `yaml:"rollback,omitempty"`
+	// Mode は更新の方法です。(in-place, staged)
+	// staged の場合、install_dir の複製を更新し、確認に成功してから install_dir と入れ替えます。更新前の install_dir は update --rollback で戻せます。
+	Mode UpdateMode `yaml:"mode,omitempty"`
+	// Backup が true の場合、更新前にバックアップ対象とインストール済みのバージョンを表すファイルのバックアップを作成します。
+	Backup bool `yaml:"backup,omitempty"`
+	// Hardlink が true の場合、staged で複製する際にリフリンクが使えなければハードリンクを使用します。
+	// 更新で既存のファイルが直接書き換えられると、更新前の install_dir のファイルも変更されるため注意してください。
+	Hardlink bool `yaml:"hardlink,omitempty"`
+}
+
+// InstallConfig ゲームのインストール元の構成
+// 未設定の場合は steam の設定に従ってsteamcmdでインストールします。
+type InstallConfig struct {
+	// Provider はインストール元です。(steam, http, minecraft-vanilla, papermc)
+	Provider InstallProviderKind `yaml:"provider,omitempty"`
+	// URL は http でダウンロードするアーカイブのURLです。
+	URL string `yaml:"url,omitempty"`
+	// SHA256 は http でダウンロードするアーカイブのSHA-256です。
+	SHA256 string `yaml:"sha256,omitempty"`
+	// Format は http でダウンロードするアーカイブの形式です。(zip, tar) 未設定の場合はURLの拡張子から判定します。
+	Format ArchiveFormat `yaml:"format,omitempty"`
+	// ArchiveDir は http でアーカイブ内の一部のディレクトリのみをインストールする場合に、そのディレクトリを指定します。
+	ArchiveDir string `yaml:"archive_dir,omitempty"`
+	// Version はインストールするバージョンです。
+	// minecraft-vanilla, papermc では未設定または latest の場合に最新の安定版、minecraft-vanilla で snapshot の場合に最新のスナップショットを使用します。
+	// http ではバージョンの表示名として使用します。
+	Version string `yaml:"version,omitempty"`
+	// Project は papermc のプロジェクトです。(paper, folia, velocity など) 未設定の場合は paper です。
+	Project string `yaml:"project,omitempty"`
+	// Jar は minecraft-vanilla, papermc でダウンロードしたjarのファイル名です。未設定の場合は server.jar です。
+	Jar string `yaml:"jar,omitempty"`
+	// APIURL は minecraft-vanilla のバージョンマニフェスト、papermc のAPIのURLを変更する場合に指定します。
+	APIURL string `yaml:"api_url,omitempty"`
+}
+
+// WorkshopConfig Steamワークショップのアイテム(Mod)の構成
+type WorkshopConfig struct {
+	// AppID はワークショップのアプリIDです。専用サーバとゲーム本体でIDが異なる場合に指定します。未設定の場合は steam.app_id を使用します。
+	AppID string `yaml:"app_id,omitempty"`
+	// ModsDir はアイテムを配置するディレクトリです。install_dir からの相対パスで指定します。
+	ModsDir string `yaml:"mods_dir"`
+	// Mode はアイテムの配置方法です。(copy, link)
+	Mode WorkshopMode `yaml:"mode,omitempty"`
+	// Items は同期するワークショップのアイテムIDです。
+	Items []string `yaml:"items"`
+	// SyncUpdate が true の場合、update の後にアイテムを同期します。
+	SyncUpdate bool `yaml:"sync_on_update,omitempty"`
+}
+
+// PrefixConfig ゲーム専用のWine/Protonのプレフィックスの構成
+// 設定すると、WINEPREFIX などの環境変数の代わりにこのプレフィックスを使用します。
+type PrefixConfig struct {
+	// Path はプレフィックスのディレクトリです。wine では WINEPREFIX、proton では STEAM_COMPAT_DATA_PATH に相当します。
+	// 未設定の場合は state_dir/prefixes/<name> を使用します。
+	Path string `yaml:"path,omitempty"`
+	// Arch は wine で作成するプレフィックスのアーキテクチャです。(win32, win64)
+	Arch string `yaml:"arch,omitempty"`
+	// Winetricks はプレフィックスの作成後に winetricks でインストールするverbです。(例: vcrun2019, dotnet48)
+	Winetricks []string `yaml:"winetricks,omitempty"`
+}
+
+// PortConfig ゲームサーバが使用するポート
+type PortConfig struct {
+	// Name はポートの用途です。(例: game, query, rcon) ファイアウォールのルールのコメントに使用します。
+	Name string `yaml:"name,omitempty"`
+	// Protocol はプロトコルです。(tcp, udp)
+	Protocol PortProtocol `yaml:"protocol"`
+	// Port はポート番号です。
+	Port int `yaml:"port"`
+}
+
+// IsolationConfig サーバのサンドボックスの構成
+// 有効にした場合、サーバを新しい user, mount, PID, IPC 名前空間で起動し、書き込みを許可したパス以外を読み取り専用にします。(Linuxのみ)
+// /run, /tmp, ホームディレクトリ, archon のディレクトリ, 他のゲームのディレクトリは空の tmpfs で隠します。
+// ネットワークはサーバの公開に必要なため、意図的にホストと共有します。(抽象名前空間の UNIX ソケットにも接続できます)
+type IsolationConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Writable は install_dir, prefix, backup_targets の他に書き込みを許可するパスです。相対パスは install_dir からのパスです。
+	Writable []string `yaml:"writable,omitempty"`
+}
+
+// ResourcesConfig サーバのリソース制限の構成
+// 設定した場合、サーバを専用の cgroup v2 に配置して制限します。(Linuxのみ)
+type ResourcesConfig struct {
+	// Memory はメモリの上限 (memory.max) です。(例: 4G, 512M)
+	Memory string `yaml:"memory,omitempty"`
+	// CPU はCPUの上限 (cpu.max) です。コア数 (例: 2, 0.5) または割合 (例: 150%) で指定します。
+	CPU string `yaml:"cpu,omitempty"`
+	// Pids はプロセス数(スレッドを含む)の上限 (pids.max) です。
+	Pids int `yaml:"pids,omitempty"`
+	// IOWeight はI/Oの重み (io.weight) です。(1 から 10000、デフォルトは 100)
+	IOWeight int `yaml:"io_weight,omitempty"`
+}
+
+// RuntimeConfig wine, proton でゲームを起動する際の構成
+type RuntimeConfig struct {
+	// WineDebug は WINEDEBUG に設定する値です。未設定の場合は -all (ログを出力しない) です。
+	WineDebug string `yaml:"wine_debug,omitempty"`
+	// Proton は proton で使用するProtonです。compatibilitytools.d または steamapps/common のディレクトリ名 (例: GE-Proton9-20)、
+	// またはProtonのディレクトリやスクリプトのパスを指定します。未設定の場合は最後に更新されたProtonを使用します。
+	Proton string `yaml:"proton,omitempty"`
+	// Xvfb が true の場合、xvfb-run で仮想ディスプレイを用意して起動します。ディスプレイのないサーバで使用します。
+	Xvfb bool `yaml:"xvfb,omitempty"`
 }
 
 // SteamConfig ゲームのSteam関連情報
 type SteamConfig struct {
-	Platform string `yaml:"platform,omitempty"`
-	AppID    string `yaml:"app_id"`
+	Login *SteamLoginConfig `yaml:"login,omitempty"`
+	// ExtraArgs は app_update の前に steamcmd に渡す追加の引数です。(例: +@sSteamCmdForcePlatformBitness 64)
+	ExtraArgs      []string `yaml:"extra_args,omitempty"`
+	Platform       string   `yaml:"platform,omitempty"`
+	AppID          string   `yaml:"app_id"`
+	Branch         string   `yaml:"branch,omitempty"`
+	BranchPassword string   `yaml:"branch_password,omitempty"`
+	Validate       bool     `yaml:"validate,omitempty"`
+}
+
+// SteamLoginConfig Steamアカウントでのログインの構成
+// パスワードは password_env, password_file, credential の順に探します。いずれも未設定の場合は steamcmd にキャッシュされたセッションを使用します。
+type SteamLoginConfig struct {
+	Username string `yaml:"username"`
+	// PasswordEnv はパスワードを格納した環境変数の名前です。
+	PasswordEnv string `yaml:"password_env,omitempty"`
+	// PasswordFile はパスワードを格納したファイルのパスです。
+	PasswordFile string `yaml:"password_file,omitempty"`
+	// Credential は archon credentials set で保存した、暗号化済みの認証情報の名前です。
+	Credential string `yaml:"credential,omitempty"`
 }
@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestGetStateDir(t *testing.T) {
+	stateHome := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", stateHome)
+
+	tests := []struct {
+		name string
+		cfg  *ArchonConfig
+		want string
+	}{
+		{name: "state_dir", cfg: &ArchonConfig{StateDir: "/srv/state", BackupDir: "/srv/backup"}, want: "/srv/state"},
+		{name: "backup_dir", cfg: &ArchonConfig{BackupDir: "/srv/backup"}, want: filepath.Join("/srv/backup", ".archon")},
+		{name: "empty", cfg: &ArchonConfig{}, want: filepath.Join(stateHome, "archon")},
+		{name: "nil", cfg: nil, want: filepath.Join(stateHome, "archon")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.GetStateDir(); got != tt.want {
+				t.Errorf("GetStateDir() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
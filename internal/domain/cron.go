@@ -0,0 +1,202 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule はcron形式(分 時 日 月 曜日)のスケジュールです。
+type CronSchedule struct {
+	minute  uint64 // bit 0-59
+	hour    uint64 // bit 0-23
+	dom     uint64 // bit 1-31
+	month   uint64 // bit 1-12
+	dow     uint64 // bit 0-6 (0 = 日曜日)
+	domStar bool   // 日が * で指定されている
+	dowStar bool   // 曜日が * で指定されている
+}
+
+// cronField はcronの各フィールドの範囲と名前です。
+type cronField struct {
+	names map[string]int
+	name  string
+	min   int
+	max   int
+}
+
+var (
+	cronMinute = cronField{name: "分", min: 0, max: 59}
+	cronHour   = cronField{name: "時", min: 0, max: 23}
+	cronDom    = cronField{name: "日", min: 1, max: 31}
+	cronMonth  = cronField{name: "月", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 曜日は 0-7 (0, 7 = 日曜日)
+	cronDow = cronField{name: "曜日", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// cronMacros は @daily 等の省略形です。
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron はcron形式の文字列をパースします。
+// "分 時 日 月 曜日" の5フィールド形式と、@daily 等の省略形に対応しています。
+// 各フィールドでは *, 数値, 範囲(a-b), リスト(a,b), ステップ(*/n, a-b/n) が使用できます。
+func ParseCron(spec string) (*CronSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	if macro, ok := cronMacros[strings.ToLower(spec)]; ok {
+		spec = macro
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron式 '%s' のフィールド数が不正です (分 時 日 月 曜日 の5つを指定してください)", spec)
+	}
+
+	var (
+		s   CronSchedule
+		err error
+	)
+	if s.minute, err = parseCronField(fields[0], cronMinute); err != nil {
+		return nil, err
+	}
+	if s.hour, err = parseCronField(fields[1], cronHour); err != nil {
+		return nil, err
+	}
+	if s.dom, err = parseCronField(fields[2], cronDom); err != nil {
+		return nil, err
+	}
+	if s.month, err = parseCronField(fields[3], cronMonth); err != nil {
+		return nil, err
+	}
+	if s.dow, err = parseCronField(fields[4], cronDow); err != nil {
+		return nil, err
+	}
+	// 7 は日曜日として扱う
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	s.domStar = strings.HasPrefix(fields[2], "*")
+	s.dowStar = strings.HasPrefix(fields[4], "*")
+
+	return &s, nil
+}
+
+// parseCronField はcronの1フィールドをビット集合に変換します。
+func parseCronField(expr string, field cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expr, ",") {
+		rangeExpr, stepExpr, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepExpr)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("cron式の%sフィールド '%s' のステップが不正です", field.name, part)
+			}
+			step = n
+		}
+
+		var low, high int
+		switch {
+		case rangeExpr == "*":
+			low, high = field.min, field.max
+		case strings.Contains(rangeExpr, "-"):
+			lowExpr, highExpr, _ := strings.Cut(rangeExpr, "-")
+			var err error
+			if low, err = parseCronValue(lowExpr, field); err != nil {
+				return 0, err
+			}
+			if high, err = parseCronValue(highExpr, field); err != nil {
+				return 0, err
+			}
+		default:
+			v, err := parseCronValue(rangeExpr, field)
+			if err != nil {
+				return 0, err
+			}
+			low, high = v, v
+			// "5/15" は 5 から最大値までのステップとして扱う
+			if hasStep {
+				high = field.max
+			}
+		}
+
+		if low > high {
+			return 0, fmt.Errorf("cron式の%sフィールド '%s' の範囲が不正です", field.name, part)
+		}
+		for v := low; v <= high; v += step {
+			bits |= 1 << uint(v) // nolint:gosec // G115 v is within 0-59
+		}
+	}
+	return bits, nil
+}
+
+// parseCronValue はcronの値(数値または名前)を変換し、範囲をチェックします。
+func parseCronValue(expr string, field cronField) (int, error) {
+	if v, ok := field.names[strings.ToLower(expr)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(expr)
+	if err != nil {
+		return 0, fmt.Errorf("cron式の%sフィールドの値 '%s' が不正です", field.name, expr)
+	}
+	if v < field.min || v > field.max {
+		return 0, fmt.Errorf("cron式の%sフィールドの値 %d が範囲外です (%d-%d)", field.name, v, field.min, field.max)
+	}
+	return v, nil
+}
+
+// cronSearchLimit は次の実行時刻を探す範囲です。2/30 のような存在しない日付で無限ループしないようにします。
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+// Next は t より後で、スケジュールに一致する最初の時刻を返します(秒以下は切り捨て)。
+// 一致する時刻が見つからない場合はゼロ値を返します。
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(cronSearchLimit)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// matchDay は日と曜日の条件を判定します。
+// cronの仕様に従い、日と曜日の両方が指定されている場合はどちらかに一致すれば実行します。
+func (s *CronSchedule) matchDay(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
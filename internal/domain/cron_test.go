@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		wantErr bool
+	}{
+		{name: "all stars", spec: "* * * * *"},
+		{name: "macro", spec: "@daily"},
+		{name: "macro is case insensitive", spec: "@Hourly"},
+		{name: "ranges lists and steps", spec: "*/15 1-5/2 1,15 jan-jun mon-fri"},
+		{name: "sunday as 7", spec: "0 0 * * 7"},
+		{name: "too few fields", spec: "* * * *", wantErr: true},
+		{name: "too many fields", spec: "* * * * * *", wantErr: true},
+		{name: "minute out of range", spec: "60 * * * *", wantErr: true},
+		{name: "day zero", spec: "* * 0 * *", wantErr: true},
+		{name: "invalid name", spec: "* * * foo *", wantErr: true},
+		{name: "zero step", spec: "*/0 * * * *", wantErr: true},
+		{name: "reversed range", spec: "* 5-1 * * *", wantErr: true},
+		{name: "unknown macro", spec: "@reboot", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseCron(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseCron(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCronScheduleNext(t *testing.T) {
+	// 2026-10-18 は日曜日
+	base := time.Date(2026, 10, 18, 10, 30, 45, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{
+			name: "next minute",
+			spec: "* * * * *",
+			from: base,
+			want: time.Date(2026, 10, 18, 10, 31, 0, 0, time.UTC),
+		},
+		{
+			name: "later today",
+			spec: "0 12 * * *",
+			from: base,
+			want: time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "tomorrow",
+			spec: "@daily",
+			from: base,
+			want: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "exact time is excluded",
+			spec: "30 10 * * *",
+			from: time.Date(2026, 10, 18, 10, 30, 0, 0, time.UTC),
+			want: time.Date(2026, 10, 19, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "step",
+			spec: "*/20 * * * *",
+			from: base,
+			want: time.Date(2026, 10, 18, 10, 40, 0, 0, time.UTC),
+		},
+		{
+			name: "weekday",
+			spec: "0 4 * * mon",
+			from: base,
+			want: time.Date(2026, 10, 19, 4, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "sunday as 7",
+			spec: "0 4 * * 7",
+			from: base,
+			want: time.Date(2026, 10, 25, 4, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "day of month or weekday",
+			spec: "0 0 1 * fri",
+			from: base,
+			want: time.Date(2026, 10, 23, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "day of month and starred weekday",
+			spec: "0 0 1 * *",
+			from: base,
+			want: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "next year",
+			spec: "0 0 1 jan *",
+			from: base,
+			want: time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "leap day",
+			spec: "0 0 29 2 *",
+			from: base,
+			want: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "nonexistent date",
+			spec: "0 0 30 2 *",
+			from: base,
+			want: time.Time{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseCron(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseCron(%q) error = %v", tt.spec, err)
+			}
+			if got := s.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.from, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package domain
+
+import "strings"
+
+// DiffOp は行単位の差分の種類です。
+type DiffOp int
+
+const (
+	// DiffEqual は両方に含まれる行です。
+	DiffEqual DiffOp = iota
+	// DiffDelete は変更前にのみ含まれる行です。
+	DiffDelete
+	// DiffInsert は変更後にのみ含まれる行です。
+	DiffInsert
+)
+
+// DiffLine は行単位の差分の1行です。
+type DiffLine struct {
+	Text string
+	Op   DiffOp
+}
+
+// LineDiff は before から after への行単位の差分を返します。
+// コンフィグファイル程度の大きさを想定した、最長共通部分列による単純な実装です。
+func LineDiff(before, after string) []DiffLine {
+	a := splitLines(before)
+	b := splitLines(after)
+
+	// lcs[i][j] は a[i:] と b[j:] の最長共通部分列の長さ
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []DiffLine
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, DiffLine{Op: DiffEqual, Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, DiffLine{Op: DiffDelete, Text: a[i]})
+			i++
+		default:
+			lines = append(lines, DiffLine{Op: DiffInsert, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, DiffLine{Op: DiffDelete, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, DiffLine{Op: DiffInsert, Text: b[j]})
+	}
+	return lines
+}
+
+// splitLines は改行コードを揃えて行に分割します。
+func splitLines(s string) []string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// UpstreamChanges は配布元の既定値の base から theirs への変更を、ユーザーの設定 ours と比較します。(3方向の比較)
+// missing は配布元で追加・変更された行のうち ours に含まれていない行、
+// obsolete は配布元で削除された行のうち ours に残っている行です。行の前後の空白は無視して比較します。
+func UpstreamChanges(base, ours, theirs string) (missing, obsolete []string) {
+	have := make(map[string]bool)
+	for _, line := range splitLines(ours) {
+		have[strings.TrimSpace(line)] = true
+	}
+
+	for _, line := range LineDiff(base, theirs) {
+		text := strings.TrimSpace(line.Text)
+		if text == "" {
+			continue
+		}
+		switch line.Op {
+		case DiffInsert:
+			if !have[text] {
+				missing = append(missing, line.Text)
+			}
+		case DiffDelete:
+			if have[text] {
+				obsolete = append(obsolete, line.Text)
+			}
+		case DiffEqual:
+		}
+	}
+	return missing, obsolete
+}
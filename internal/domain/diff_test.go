@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestUpstreamChanges(t *testing.T) {
+	base := "port=7777\nmax_players=8\nlegacy=true\n"
+	theirs := "port=7777\nmax_players=16\nnew_option=1\n"
+	ours := "port=8888\nmax_players=8\nlegacy=true\nnew_option=1\n"
+
+	missing, obsolete := UpstreamChanges(base, ours, theirs)
+	if want := []string{"max_players=16"}; !slices.Equal(missing, want) {
+		t.Errorf("missing = %q, want %q", missing, want)
+	}
+	if want := []string{"max_players=8", "legacy=true"}; !slices.Equal(obsolete, want) {
+		t.Errorf("obsolete = %q, want %q", obsolete, want)
+	}
+}
@@ -0,0 +1,198 @@
+package domain
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// InstallProviderKind はゲームのインストール元の種類です。
+type InstallProviderKind string
+
+const (
+	// InstallProviderSteam はsteamcmdでインストールします。
+	InstallProviderSteam InstallProviderKind = "steam"
+	// InstallProviderHTTP は install.url のアーカイブ(zip, tar)をダウンロードして展開します。
+	InstallProviderHTTP InstallProviderKind = "http"
+	// InstallProviderMinecraft はMojangのバージョンマニフェストから、Minecraftのバニラサーバをインストールします。
+	InstallProviderMinecraft InstallProviderKind = "minecraft-vanilla"
+	// InstallProviderPaperMC はPaperMCのビルドAPIから、Paperなどのサーバをインストールします。
+	InstallProviderPaperMC InstallProviderKind = "papermc"
+)
+
+const (
+	// InstalledVersionFile はsteam以外のインストール元で、インストールしたバージョンを記録するファイルです。install_dir に作成します。
+	InstalledVersionFile = ".archon-version.yaml"
+	// DefaultServerJar は minecraft-vanilla, papermc でダウンロードしたjarの既定のファイル名です。
+	DefaultServerJar = "server.jar"
+	// DefaultPaperMCProject は papermc の既定のプロジェクトです。
+	DefaultPaperMCProject = "paper"
+	// LatestVersion は最新のバージョンを表します。
+	LatestVersion = "latest"
+	// MinecraftSnapshot は minecraft-vanilla で最新のスナップショットを表します。
+	MinecraftSnapshot = "snapshot"
+)
+
+// ArchiveFormat は http でダウンロードするアーカイブの形式です。
+type ArchiveFormat string
+
+const (
+	// ArchiveZip はzip形式です。
+	ArchiveZip ArchiveFormat = "zip"
+	// ArchiveTar はtar形式です。gzipで圧縮されていても構いません。
+	ArchiveTar ArchiveFormat = "tar"
+)
+
+// InstallRecord はsteam以外のインストール元でインストールしたバージョンの記録です。
+type InstallRecord struct {
+	InstalledAt time.Time           `yaml:"installed_at"`
+	Provider    InstallProviderKind `yaml:"provider"`
+	Version     string              `yaml:"version"`
+	// Source はダウンロード元のURLです。
+	Source string `yaml:"source,omitempty"`
+	// Files はインストールしたファイル/ディレクトリの、install_dir からの相対パスです。
+	Files []string `yaml:"files,omitempty"`
+}
+
+// GetProvider はインストール元の種類を返します。未設定の場合は steam です。
+func (i *InstallConfig) GetProvider() InstallProviderKind {
+	if i == nil || i.Provider == "" {
+		return InstallProviderSteam
+	}
+	return i.Provider
+}
+
+// GetJar は minecraft-vanilla, papermc でダウンロードしたjarのファイル名を返します。
+func (i *InstallConfig) GetJar() string {
+	if i == nil || i.Jar == "" {
+		return DefaultServerJar
+	}
+	return i.Jar
+}
+
+// GetVersion はインストールするバージョンを返します。未設定の場合は latest です。
+func (i *InstallConfig) GetVersion() string {
+	if i == nil || i.Version == "" {
+		return LatestVersion
+	}
+	return i.Version
+}
+
+// GetProject は papermc のプロジェクトを返します。
+func (i *InstallConfig) GetProject() string {
+	if i == nil || i.Project == "" {
+		return DefaultPaperMCProject
+	}
+	return i.Project
+}
+
+// GetFormat は http でダウンロードするアーカイブの形式を返します。
+// install.format が未設定の場合は install.url の拡張子から判定し、判定できない場合は空文字列を返します。
+func (i *InstallConfig) GetFormat() ArchiveFormat {
+	if i == nil {
+		return ""
+	}
+	if i.Format != "" {
+		return i.Format
+	}
+
+	name := strings.ToLower(i.ArchiveName())
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return ArchiveZip
+	case strings.HasSuffix(name, ".tar"), strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return ArchiveTar
+	default:
+		return ""
+	}
+}
+
+// ArchiveName は install.url のファイル名を返します。
+func (i *InstallConfig) ArchiveName() string {
+	if i == nil {
+		return ""
+	}
+	u, err := url.Parse(i.URL)
+	if err != nil {
+		return ""
+	}
+	name := path.Base(u.Path)
+	if name == "/" || name == "." {
+		return ""
+	}
+	return name
+}
+
+// HTTPVersion は http でインストールするバージョンを返します。
+// install.version が未設定の場合は install.sha256 の先頭12文字を使用します。
+func (i *InstallConfig) HTTPVersion() string {
+	if i == nil {
+		return ""
+	}
+	if i.Version != "" {
+		return i.Version
+	}
+	if len(i.SHA256) > 12 {
+		return i.SHA256[:12]
+	}
+	return i.SHA256
+}
+
+// Validate は InstallConfig の値を、インストール元に必要な設定が揃っているかも含めて検証します。
+func (i *InstallConfig) Validate(steam *SteamConfig) error {
+	switch i.GetProvider() {
+	case InstallProviderSteam:
+		if steam == nil {
+			return fmt.Errorf("steam設定が見つかりません")
+		}
+		if steam.AppID == "" {
+			return fmt.Errorf("steamのappIDが設定されていません")
+		}
+	case InstallProviderHTTP:
+		return i.validateHTTP()
+	case InstallProviderMinecraft, InstallProviderPaperMC:
+		if !filepath.IsLocal(i.GetJar()) {
+			return fmt.Errorf("install.jar は install_dir からの相対パスで指定してください: %s", i.Jar)
+		}
+	default:
+		return fmt.Errorf("install.provider に指定できるのは steam, http, minecraft-vanilla, papermc のいずれかです: %s", i.Provider)
+	}
+	return nil
+}
+
+// validateHTTP は http の設定を検証します。
+func (i *InstallConfig) validateHTTP() error {
+	if i.URL == "" {
+		return fmt.Errorf("install.url を指定してください")
+	}
+	if i.ArchiveName() == "" {
+		return fmt.Errorf("install.url からファイル名を取得できません: %s", i.URL)
+	}
+	if len(i.SHA256) != 64 || !isHex(i.SHA256) {
+		return fmt.Errorf("install.sha256 にアーカイブのSHA-256を16進数で指定してください")
+	}
+	switch i.GetFormat() {
+	case ArchiveZip, ArchiveTar:
+	case "":
+		return fmt.Errorf("install.url からアーカイブの形式を判定できません。install.format に zip, tar のいずれかを指定してください")
+	default:
+		return fmt.Errorf("install.format に指定できるのは zip, tar のいずれかです: %s", i.Format)
+	}
+	if i.ArchiveDir != "" && !filepath.IsLocal(i.ArchiveDir) {
+		return fmt.Errorf("install.archive_dir はアーカイブ内の相対パスで指定してください: %s", i.ArchiveDir)
+	}
+	return nil
+}
+
+// isHex は s が16進数のみで構成されている場合に true を返します。
+func isHex(s string) bool {
+	for _, r := range strings.ToLower(s) {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+)
+
+// SandboxHiddenDirs はサンドボックス内で常に空の tmpfs に置き換えるディレクトリです。
+// ホストのサービスの UNIX ソケット (docker.sock, systemd, D-Bus など) や一時ファイルに触れられないようにします。
+var SandboxHiddenDirs = []string{"/run", "/var/run", "/tmp", "/dev/shm"}
+
+// SandboxPaths はサンドボックスで書き込みを許可するパスと、隠すパスです。
+type SandboxPaths struct {
+	// Writable は書き込みを許可するパスです。それ以外は読み取り専用になります。
+	Writable []string
+	// Hidden は空の tmpfs に置き換えて、中身を見えなくするパスです。Writable のパスはその上に重ねて見えるようにします。
+	Hidden []string
+}
+
+// SandboxSpec はサンドボックス内で起動するサーバの情報です。
+// archon 自身をサンドボックスの初期化プロセスとして起動する際に、環境変数で JSON として渡します。
+type SandboxSpec struct {
+	// Path, Args はサーバのコマンドです。(exec.Cmd の Path, Args)
+	Path string   `json:"path"`
+	Args []string `json:"args"`
+	// Dir はサーバのカレントディレクトリです。
+	Dir string `json:"dir"`
+	// Writable は書き込みを許可するパスです。それ以外は読み取り専用になります。
+	Writable []string `json:"writable"`
+	// ReadOnly は Hidden で隠れる場所にあっても、読み取り専用で見えるようにするパスです。(Protonなど)
+	ReadOnly []string `json:"read_only"`
+	// Hidden は空の tmpfs に置き換えるパスです。
+	Hidden []string `json:"hidden"`
+}
+
+// IsEnabled はサンドボックスが有効かどうかを返します。
+func (i *IsolationConfig) IsEnabled() bool {
+	return i != nil && i.Enabled
+}
+
+// Validate は IsolationConfig の値を検証します。
+// wine, proton はプレフィックスへの書き込みが必要なため、prefix の設定を必須とします。
+func (i *IsolationConfig) Validate(env RuntimeEnv, prefix *PrefixConfig) error {
+	if !i.IsEnabled() {
+		return nil
+	}
+
+	var errs []error
+	if (env == RuntimeEnvWine || env == RuntimeEnvProton) && prefix == nil {
+		errs = append(errs, fmt.Errorf("%s で isolation を使用するには prefix を設定してください", env))
+	}
+	for _, path := range i.Writable {
+		if path == "" {
+			errs = append(errs, fmt.Errorf("writable に空のパスが指定されています"))
+		} else if filepath.Clean(path) == "/" {
+			errs = append(errs, fmt.Errorf("writable にルートディレクトリは指定できません"))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SandboxWritablePaths はサンドボックス内で書き込みを許可するパスを返します。
+// install_dir, ゲーム専用のプレフィックス, savePaths (backup_targets), isolation.writable の順に追加し、重複を除いてソートします。
+func (g *GameConfig) SandboxWritablePaths(archonCfg *ArchonConfig, savePaths []string) []string {
+	paths := []string{g.InstallDir}
+	if prefixDir := g.GetPrefixDir(archonCfg); prefixDir != "" {
+		paths = append(paths, prefixDir)
+	}
+	paths = append(paths, savePaths...)
+	if g.Isolation != nil {
+		for _, path := range g.Isolation.Writable {
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(g.InstallDir, path)
+			}
+			paths = append(paths, path)
+		}
+	}
+
+	var writable []string
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			writable = append(writable, abs)
+		}
+	}
+	slices.Sort(writable)
+	return slices.Compact(writable)
+}
+
+// SandboxHiddenPaths はサンドボックス内で隠すパスを返します。
+// archon のバックアップ・状態・ロックのディレクトリと、name 以外のゲームの install_dir, prefix が対象です。
+func SandboxHiddenPaths(archonCfg *ArchonConfig, games map[string]*GameConfig, name string) []string {
+	var paths []string
+	if archonCfg != nil {
+		paths = append(paths, archonCfg.BackupDir, archonCfg.GetStateDir(), archonCfg.GetLockDir())
+	}
+	for gameName, game := range games {
+		if gameName == name || game == nil {
+			continue
+		}
+		paths = append(paths, game.InstallDir, game.GetPrefixDir(archonCfg))
+	}
+
+	var hidden []string
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			hidden = append(hidden, abs)
+		}
+	}
+	slices.Sort(hidden)
+	return slices.Compact(hidden)
+}
@@ -0,0 +1,11 @@
+package domain
+
+import "time"
+
+// LockInfo はゲームごとの操作ロックの保持者情報です。ロックファイルに書き出されます。
+type LockInfo struct {
+	StartedAt time.Time `yaml:"started_at"`
+	Operation string    `yaml:"operation"`
+	Host      string    `yaml:"host"`
+	PID       int       `yaml:"pid"`
+}
@@ -0,0 +1,145 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PortProtocol はポートのプロトコルです。
+type PortProtocol string
+
+const (
+	// ProtocolTCP はTCPを示します。
+	ProtocolTCP PortProtocol = "tcp"
+	// ProtocolUDP はUDPを示します。
+	ProtocolUDP PortProtocol = "udp"
+)
+
+// FirewallFormat は firewall export で出力するルールの形式です。
+type FirewallFormat string
+
+const (
+	// FirewallNftables は nft -f で読み込める形式です。
+	FirewallNftables FirewallFormat = "nftables"
+	// FirewallUfw は ufw コマンドの形式です。
+	FirewallUfw FirewallFormat = "ufw"
+	// FirewallIptables は iptables コマンドの形式です。
+	FirewallIptables FirewallFormat = "iptables"
+)
+
+// String は "27015/udp" の形式で返します。
+func (p PortConfig) String() string {
+	return strconv.Itoa(p.Port) + "/" + string(p.Protocol)
+}
+
+// Label はファイアウォールのルールのコメントに使用する "archon: <ゲーム> <用途>" の形式の文字列を返します。
+func (p PortConfig) Label(game string) string {
+	if p.Name == "" {
+		return "archon: " + game
+	}
+	return "archon: " + game + " " + p.Name
+}
+
+// PortConflict は複数のゲームで同じポートが設定されていることを示します。
+type PortConflict struct {
+	Port  PortConfig
+	Games []string
+}
+
+// ValidatePorts は ports の値を検証します。同じゲーム内でのポートの重複もエラーにします。
+func ValidatePorts(ports []PortConfig) error {
+	var errs []error
+	seen := make(map[string]bool)
+	for _, port := range ports {
+		if port.Port < 1 || port.Port > 65535 {
+			errs = append(errs, fmt.Errorf("port は 1 から 65535 で指定してください: %d", port.Port))
+		}
+		if port.Protocol != ProtocolTCP && port.Protocol != ProtocolUDP {
+			errs = append(errs, fmt.Errorf("ポート %d の protocol は tcp, udp のどちらかを指定してください: %q", port.Port, port.Protocol))
+			continue
+		}
+		if seen[port.String()] {
+			errs = append(errs, fmt.Errorf("ポート %s が重複しています", port))
+		}
+		seen[port.String()] = true
+	}
+	return errors.Join(errs...)
+}
+
+// FindPortConflicts は複数のゲームで設定されているポートを、ポート番号順に返します。
+func FindPortConflicts(games map[string]*GameConfig) []PortConflict {
+	users := make(map[string][]string)
+	ports := make(map[string]PortConfig)
+	for _, name := range sortedGameNames(games) {
+		for _, port := range games[name].Ports {
+			key := port.String()
+			// 同じゲーム内の重複は ValidatePorts で検出する
+			if len(users[key]) > 0 && users[key][len(users[key])-1] == name {
+				continue
+			}
+			users[key] = append(users[key], name)
+			ports[key] = port
+		}
+	}
+
+	var conflicts []PortConflict
+	for key, names := range users {
+		if len(names) > 1 {
+			conflicts = append(conflicts, PortConflict{Port: ports[key], Games: names})
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		if conflicts[i].Port.Port != conflicts[j].Port.Port {
+			return conflicts[i].Port.Port < conflicts[j].Port.Port
+		}
+		return conflicts[i].Port.Protocol < conflicts[j].Port.Protocol
+	})
+	return conflicts
+}
+
+// FirewallRules は全てのゲームのポートを許可するファイアウォールのルールを format の形式で返します。
+// 既存のルールを置き換えないよう、ルールの追加のみを出力します。
+func FirewallRules(format FirewallFormat, games map[string]*GameConfig) (string, error) {
+	var sb strings.Builder
+	switch format {
+	case FirewallNftables:
+		sb.WriteString("#!/usr/sbin/nft -f\n# archon: inet filter テーブルの input チェインに追加します。\n")
+	case FirewallUfw, FirewallIptables:
+		sb.WriteString("#!/bin/sh\n")
+	default:
+		return "", fmt.Errorf("不明な形式です: %s (nftables, ufw, iptables のいずれかを指定してください)", format)
+	}
+
+	for _, name := range sortedGameNames(games) {
+		game := games[name]
+		if len(game.Ports) == 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, "\n# %s (%s)\n", name, game.Name)
+		for _, port := range game.Ports {
+			label := port.Label(name)
+			switch format {
+			case FirewallNftables:
+				fmt.Fprintf(&sb, "add rule inet filter input %s dport %d accept comment %q\n", port.Protocol, port.Port, label)
+			case FirewallUfw:
+				fmt.Fprintf(&sb, "ufw allow %s comment %s\n", port, ShellQuote(label))
+			case FirewallIptables:
+				fmt.Fprintf(&sb, "iptables -A INPUT -p %s --dport %d -m comment --comment %s -j ACCEPT\n", port.Protocol, port.Port, ShellQuote(label))
+			}
+		}
+	}
+	return sb.String(), nil
+}
+
+// sortedGameNames はゲーム名を名前順に返します。
+func sortedGameNames(games map[string]*GameConfig) []string {
+	names := make([]string, 0, len(games))
+	for name := range games {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,123 @@
+package domain
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	// PrefixArchWin32 は32bitのプレフィックスを示します。
+	PrefixArchWin32 = "win32"
+	// PrefixArchWin64 は64bitのプレフィックスを示します。
+	PrefixArchWin64 = "win64"
+	// WinetricksLog はwinetricksがインストール済みのverbを記録するファイルです。WINEPREFIX 直下に作成されます。
+	WinetricksLog = "winetricks.log"
+)
+
+// PrefixStatus はプレフィックスの状態です。
+type PrefixStatus struct {
+	// Dir はプレフィックスのディレクトリです。
+	Dir string
+	// WinePrefix はWineのプレフィックス(drive_c を含むディレクトリ)です。proton では Dir/pfx です。
+	WinePrefix string
+	// Verbs はwinetricksでインストール済みのverbです。
+	Verbs []string
+	// Initialized はプレフィックスが作成済みの場合に true です。
+	Initialized bool
+}
+
+// GetPrefixDir はゲーム専用のプレフィックスのディレクトリを返します。prefix が未設定の場合は空文字列を返します。
+func (g *GameConfig) GetPrefixDir(archonCfg *ArchonConfig) string {
+	if g.Prefix == nil {
+		return ""
+	}
+	if g.Prefix.Path != "" {
+		return g.Prefix.Path
+	}
+	return filepath.Join(archonCfg.GetStateDir(), "prefixes", g.Name)
+}
+
+// ResolveProtonCompatData は proton で使用するプレフィックス(STEAM_COMPAT_DATA_PATH)のディレクトリを返します。
+// prefix、環境変数の STEAM_COMPAT_DATA_PATH (envCompatData)、Steamライブラリの compatdata/<steam.app_id> の順に使用します。
+// サーバの起動とバックアップで同じプレフィックスを使用するため、プレフィックスの解決は必ずこのメソッドを使用し、
+// home には RunUser が返すサーバを実行するユーザーのホームディレクトリを渡します。
+func (g *GameConfig) ResolveProtonCompatData(archonCfg *ArchonConfig, fs SteamLibraryFS, home, steamRoot, envCompatData string) (string, error) {
+	if prefixDir := g.GetPrefixDir(archonCfg); prefixDir != "" {
+		return prefixDir, nil
+	}
+	if envCompatData != "" {
+		return envCompatData, nil
+	}
+	if g.Steam == nil || g.Steam.AppID == "" {
+		return "", fmt.Errorf("proton では prefix (archon prefix init で作成できます) または steam.app_id を設定してください")
+	}
+	return FindCompatData(fs, home, steamRoot, g.Steam.AppID), nil
+}
+
+// WinePrefixDir はプレフィックスのディレクトリから、Wineのプレフィックス(drive_c を含むディレクトリ)を返します。
+// proton では compatdata と同じく、ディレクトリ内の pfx がWineのプレフィックスになります。
+func WinePrefixDir(env RuntimeEnv, prefixDir string) string {
+	if env == RuntimeEnvProton {
+		return filepath.Join(prefixDir, "pfx")
+	}
+	return prefixDir
+}
+
+// PrefixEnv はプレフィックスを使用するための環境変数を "KEY=VALUE" の形式で返します。
+func (g *GameConfig) PrefixEnv(prefixDir string) []string {
+	switch g.RuntimeEnv {
+	case RuntimeEnvWine:
+		envs := []string{"WINEPREFIX=" + prefixDir}
+		if g.Prefix != nil && g.Prefix.Arch != "" {
+			envs = append(envs, "WINEARCH="+g.Prefix.Arch)
+		}
+		return envs
+	case RuntimeEnvProton:
+		return []string{
+			"STEAM_COMPAT_DATA_PATH=" + prefixDir,
+			"WINEPREFIX=" + WinePrefixDir(RuntimeEnvProton, prefixDir),
+		}
+	default:
+		return nil
+	}
+}
+
+// ParseWinetricksLog は winetricks.log からインストール済みのverbを返します。
+func ParseWinetricksLog(data []byte) []string {
+	var verbs []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		// winetricks は一時的なverbも記録するため、オプション指定のものは除く
+		if line == "" || strings.HasPrefix(line, "-") || strings.Contains(line, "=") || slices.Contains(verbs, line) {
+			continue
+		}
+		verbs = append(verbs, line)
+	}
+	return verbs
+}
+
+// Validate は PrefixConfig の値を検証します。
+func (p *PrefixConfig) Validate(env RuntimeEnv) error {
+	if p == nil {
+		return nil
+	}
+
+	switch env {
+	case RuntimeEnvWine:
+	case RuntimeEnvProton:
+		if p.Arch != "" {
+			return fmt.Errorf("arch は runtime_env が wine の場合のみ指定できます")
+		}
+	default:
+		return fmt.Errorf("prefix は runtime_env が wine または proton の場合のみ指定できます")
+	}
+
+	switch p.Arch {
+	case "", PrefixArchWin32, PrefixArchWin64:
+	default:
+		return fmt.Errorf("不明な arch です: %s (win32, win64 のいずれかを指定してください)", p.Arch)
+	}
+	return nil
+}
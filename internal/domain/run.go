@@ -0,0 +1,215 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultStopSignal は run.stop_signal が未設定の場合に送るシグナルです。
+	DefaultStopSignal = "SIGTERM"
+	// DefaultStopTimeout は run.stop_timeout が未設定の場合の、強制終了までの待機時間です。
+	DefaultStopTimeout = 30 * time.Second
+)
+
+// stopSignals は run.stop_signal に指定できるシグナルです。
+var stopSignals = []string{"SIGTERM", "SIGINT", "SIGHUP", "SIGQUIT", "SIGUSR1", "SIGUSR2"}
+
+// ioniceClasses は run.ionice のクラス名と ionice -c の値の対応です。
+var ioniceClasses = map[string]string{
+	"realtime":    "1",
+	"best-effort": "2",
+	"idle":        "3",
+}
+
+// CommandLine は command に args をクォートして連結した、シェルで実行するコマンドを返します。
+func (r *RunConfig) CommandLine() string {
+	if r == nil {
+		return ""
+	}
+	parts := []string{r.Command}
+	for _, arg := range r.Args {
+		parts = append(parts, ShellQuote(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+// GetWorkdir はサーバを起動するディレクトリを返します。
+func (r *RunConfig) GetWorkdir(installDir string) string {
+	if r == nil || r.Workdir == "" {
+		return installDir
+	}
+	if filepath.IsAbs(r.Workdir) {
+		return r.Workdir
+	}
+	return filepath.Join(installDir, r.Workdir)
+}
+
+// GetEnvFile は env_file のパスを返します。未設定の場合は空文字列を返します。
+func (r *RunConfig) GetEnvFile(installDir string) string {
+	if r == nil || r.EnvFile == "" {
+		return ""
+	}
+	if filepath.IsAbs(r.EnvFile) {
+		return r.EnvFile
+	}
+	return filepath.Join(installDir, r.EnvFile)
+}
+
+// GetUser はサーバを実行するユーザーの名前を返します。未設定の場合は空文字列を返します。
+func (r *RunConfig) GetUser() string {
+	if r == nil {
+		return ""
+	}
+	return r.User
+}
+
+// GetStopSignal は終了を要求する際に送るシグナルの名前を SIGTERM の形式で返します。
+func (r *RunConfig) GetStopSignal() string {
+	if r == nil || r.StopSignal == "" {
+		return DefaultStopSignal
+	}
+	signal := strings.ToUpper(r.StopSignal)
+	if !strings.HasPrefix(signal, "SIG") {
+		signal = "SIG" + signal
+	}
+	return signal
+}
+
+// GetStopTimeout は終了を要求してから強制終了するまでの待機時間を返します。不正な値の場合はデフォルト値を返します。
+func (r *RunConfig) GetStopTimeout() time.Duration {
+	if r == nil || r.StopTimeout == "" {
+		return DefaultStopTimeout
+	}
+	timeout, err := time.ParseDuration(r.StopTimeout)
+	if err != nil || timeout <= 0 {
+		return DefaultStopTimeout
+	}
+	return timeout
+}
+
+// IONiceArgs は run.ionice を ionice コマンドの引数に変換します。未設定の場合は nil を返します。
+func (r *RunConfig) IONiceArgs() ([]string, error) {
+	if r == nil || r.IONice == "" {
+		return nil, nil
+	}
+	name, level, hasLevel := strings.Cut(r.IONice, ":")
+	class, ok := ioniceClasses[name]
+	if !ok {
+		return nil, fmt.Errorf("ionice のクラス %s は不明です (idle, best-effort, realtime のいずれかを指定してください)", name)
+	}
+
+	args := []string{"-c", class}
+	if !hasLevel {
+		return args, nil
+	}
+	if name == "idle" {
+		return nil, fmt.Errorf("ionice の idle には優先度を指定できません")
+	}
+	if n, err := strconv.Atoi(level); err != nil || n < 0 || n > 7 {
+		return nil, fmt.Errorf("ionice の優先度は 0 から 7 で指定してください: %s", level)
+	}
+	return append(args, "-n", level), nil
+}
+
+// UsesPlatformSettings は Linux のみ対応の設定 (user, umask, nice, ionice, stop_signal) を使用している場合に true を返します。
+func (r *RunConfig) UsesPlatformSettings() bool {
+	return r != nil && (r.User != "" || r.Umask != "" || r.Nice != nil || r.IONice != "" || r.StopSignal != "")
+}
+
+// Validate は RunConfig の値を検証します。
+// check-config で全ての問題を表示できるよう、見つかった全てのエラーを errors.Join でまとめて返します。
+func (r *RunConfig) Validate() error {
+	if r == nil {
+		return nil
+	}
+
+	var errs []error
+	if r.Command == "" {
+		errs = append(errs, fmt.Errorf("command が設定されていません"))
+	}
+	for _, env := range r.Envs {
+		if key, _, ok := strings.Cut(env, "="); !ok || key == "" {
+			errs = append(errs, fmt.Errorf("envs は KEY=VALUE の形式で指定してください: %s", env))
+		}
+	}
+	if r.StopSignal != "" && !slices.Contains(stopSignals, r.GetStopSignal()) {
+		errs = append(errs, fmt.Errorf("stop_signal %s には対応していません (%s のいずれかを指定してください)", r.StopSignal, strings.Join(stopSignals, ", ")))
+	}
+	if r.StopTimeout != "" {
+		if timeout, err := time.ParseDuration(r.StopTimeout); err != nil || timeout <= 0 {
+			errs = append(errs, fmt.Errorf("stop_timeout は 30s, 2m などの正の時間で指定してください: %s", r.StopTimeout))
+		}
+	}
+	if r.User != "" && strings.ContainsAny(r.User, " \t:/") {
+		errs = append(errs, fmt.Errorf("user が不正です: %s", r.User))
+	}
+	if r.Umask != "" {
+		if mask, err := strconv.ParseUint(r.Umask, 8, 32); err != nil || mask > 0o777 {
+			errs = append(errs, fmt.Errorf("umask は 0027 のような8進数で指定してください: %s", r.Umask))
+		}
+	}
+	if r.Nice != nil && (*r.Nice < -20 || *r.Nice > 19) {
+		errs = append(errs, fmt.Errorf("nice は -20 から 19 で指定してください: %d", *r.Nice))
+	}
+	if _, err := r.IONiceArgs(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// ParseDotEnv はdotenv形式のデータをパースし、環境変数を "KEY=VALUE" の形式で返します。
+// 空行と # から始まる行は無視し、先頭の export は取り除きます。
+// ダブルクォートで囲んだ値は \n, \", \\ のエスケープを展開し、シングルクォートで囲んだ値はそのまま使用します。
+func ParseDotEnv(data []byte) ([]string, error) {
+	var envs []string
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("%d行目: KEY=VALUE の形式ではありません", i+1)
+		}
+
+		value, err := unquoteDotEnv(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%d行目: %w", i+1, err)
+		}
+		envs = append(envs, key+"="+value)
+	}
+	return envs, nil
+}
+
+// unquoteDotEnv はdotenvの値のクォートを外します。クォートされていない値は # 以降をコメントとして取り除きます。
+func unquoteDotEnv(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		end := strings.LastIndex(value, `"`)
+		if end == 0 {
+			return "", fmt.Errorf("ダブルクォートが閉じられていません")
+		}
+		replacer := strings.NewReplacer(`\n`, "\n", `\"`, `"`, `\\`, `\`)
+		return replacer.Replace(value[1:end]), nil
+	case strings.HasPrefix(value, "'"):
+		end := strings.LastIndex(value, "'")
+		if end == 0 {
+			return "", fmt.Errorf("シングルクォートが閉じられていません")
+		}
+		return value[1:end], nil
+	default:
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = value[:i]
+		}
+		return strings.TrimSpace(value), nil
+	}
+}
@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+)
+
+// RunUser はサーバを実行するユーザーのホームディレクトリとユーザー名を返します。
+// run.user が設定されている場合はそのユーザー、そうでない場合は archon を実行しているユーザーです。
+// wine は環境変数 USER のユーザー名でプロファイルを作成するため、run.user が未設定の場合は USER を優先します。
+// サーバの起動、プレフィックスの作成、バックアップで同じプレフィックスやSteamライブラリを使用するため、ユーザーの解決は必ずこのメソッドを使用します。
+func (g *GameConfig) RunUser() (home, name string, err error) {
+	if g.Run != nil && g.Run.User != "" {
+		u, err := user.Lookup(g.Run.User)
+		if err != nil {
+			return "", "", fmt.Errorf("run.user のユーザー %s が見つかりません: %w", g.Run.User, err)
+		}
+		return u.HomeDir, u.Username, nil
+	}
+
+	home, err = os.UserHomeDir()
+	if err != nil {
+		return "", "", fmt.Errorf("ホームディレクトリが取得できません: %w", err)
+	}
+	name = os.Getenv("USER")
+	if name == "" {
+		if u, err := user.Current(); err == nil {
+			name = u.Username
+		} else {
+			name = filepath.Base(home)
+		}
+	}
+	return home, name, nil
+}
@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultWineDebug は runtime.wine_debug が未設定の場合の WINEDEBUG の値です。
+	DefaultWineDebug = "-all"
+	// ProtonScript はProtonのディレクトリ内の、起動に使用するスクリプトのファイル名です。
+	ProtonScript = "proton"
+	// SystemCompatToolsDir はディストリビューションのパッケージがProtonをインストールするディレクトリです。
+	SystemCompatToolsDir = "/usr/share/steam/compatibilitytools.d"
+)
+
+// GetWineDebug は WINEDEBUG に設定する値を返します。
+func (r *RuntimeConfig) GetWineDebug() string {
+	if r == nil || r.WineDebug == "" {
+		return DefaultWineDebug
+	}
+	return r.WineDebug
+}
+
+// GetProton は使用するProtonの名前またはパスを返します。未設定の場合は空文字列を返します。
+func (r *RuntimeConfig) GetProton() string {
+	if r == nil {
+		return ""
+	}
+	return r.Proton
+}
+
+// UseXvfb は xvfb-run で起動する場合に true を返します。
+func (r *RuntimeConfig) UseXvfb() bool {
+	return r != nil && r.Xvfb
+}
+
+// Validate は RuntimeConfig の値を検証します。
+func (r *RuntimeConfig) Validate(env RuntimeEnv) error {
+	if r == nil {
+		return nil
+	}
+	if env != RuntimeEnvWine && env != RuntimeEnvProton {
+		return fmt.Errorf("runtime は runtime_env が wine または proton の場合のみ指定できます")
+	}
+	if r.Proton != "" && env != RuntimeEnvProton {
+		return fmt.Errorf("proton は runtime_env が proton の場合のみ指定できます")
+	}
+	return nil
+}
+
+// ProtonSearchDirs はProtonを探すディレクトリを優先順に返します。
+// 各Steamのインストール先の compatibilitytools.d (GE-Protonなど) と、
+// 各Steamライブラリ (SteamLibraries を参照) の steamapps/common (Valve公式のProton) が対象です。
+func ProtonSearchDirs(steamRoots, libraries []string) []string {
+	var dirs []string
+	for _, root := range steamRoots {
+		dirs = append(dirs, filepath.Join(root, "compatibilitytools.d"))
+	}
+	for _, library := range libraries {
+		dirs = append(dirs, filepath.Join(library, "steamapps", "common"))
+	}
+	return append(dirs, SystemCompatToolsDir)
+}
+
+// protonVersionPattern はProtonのディレクトリ名に含まれる数値です。
+var protonVersionPattern = regexp.MustCompile(`\d+`)
+
+// CompareProtonVersions はProtonのディレクトリ名を、含まれる数値を前から順に比較します。
+// "GE-Proton9-20" は 9.20、"Proton 8.0" は 8.0 として比較します。
+// 数値を含まない名前 ("Proton - Experimental" など) は最も古いものとして扱い、数値が同じ場合は名前で比較します。
+func CompareProtonVersions(a, b string) int {
+	if c := slices.Compare(protonVersion(a), protonVersion(b)); c != 0 {
+		return c
+	}
+	return cmp.Compare(a, b)
+}
+
+// protonVersion は name に含まれる数値を順に返します。
+func protonVersion(name string) []int {
+	var version []int
+	for _, s := range protonVersionPattern.FindAllString(name, -1) {
+		if n, err := strconv.Atoi(s); err == nil {
+			version = append(version, n)
+		}
+	}
+	return version
+}
+
+// ShellQuote は s をシェルのコマンドラインで1つの引数として扱われるようにクォートします。
+func ShellQuote(s string) string {
+	if s != "" && !strings.ContainsFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:@%+,", r))
+	}) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
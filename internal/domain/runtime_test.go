@@ -0,0 +1,25 @@
+package domain
+
+import "testing"
+
+func TestCompareProtonVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "GE-Proton9-20", b: "GE-Proton9-3", want: 1},
+		{a: "GE-Proton10-1", b: "GE-Proton9-20", want: 1},
+		{a: "Proton 8.0", b: "Proton 9.0 (Beta)", want: -1},
+		{a: "Proton - Experimental", b: "Proton 7.0", want: -1},
+		{a: "Proton 9.0", b: "Proton 9.0", want: 0},
+		{a: "Proton 9.0 (Beta)", b: "Proton 9.0", want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" vs "+tt.b, func(t *testing.T) {
+			if got := CompareProtonVersions(tt.a, tt.b); got != tt.want {
+				t.Errorf("CompareProtonVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
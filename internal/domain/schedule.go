@@ -0,0 +1,86 @@
+package domain
+
+import "time"
+
+// JobKind は定期実行するジョブの種類です。
+type JobKind string
+
+const (
+	// JobBackup はバックアップを作成するジョブです。
+	JobBackup JobKind = "backup"
+	// JobUpdate はゲームを更新するジョブです。
+	JobUpdate JobKind = "update"
+	// JobRestart はサーバを再起動するジョブです。
+	JobRestart JobKind = "restart"
+	// JobPrune は古いバックアップを削除するジョブです。
+	JobPrune JobKind = "prune"
+)
+
+// TriggerSchedule はスケジューラが自動で作成したバックアップです。
+const TriggerSchedule Trigger = "schedule"
+
+// DefaultPruneKeep は prune で残すバックアップ数のデフォルト値です。
+const DefaultPruneKeep = 10
+
+// ScheduledJob はゲームごとの定期実行ジョブです。
+type ScheduledJob struct {
+	Schedule *CronSchedule
+	Game     string // games のキー
+	Kind     JobKind
+	Spec     string
+}
+
+// Key はジョブを一意に識別する文字列を返します。
+func (j ScheduledJob) Key() string {
+	return j.Game + "/" + string(j.Kind)
+}
+
+// ScheduleState はスケジューラの実行状態です。ダウンタイム中に実行されなかったジョブの検出に使います。
+type ScheduleState struct {
+	Jobs map[string]JobState `yaml:"jobs"`
+}
+
+// JobState はジョブごとの最終実行の記録です。
+type JobState struct {
+	LastRun time.Time `yaml:"last_run"`
+	Result  string    `yaml:"result,omitempty"`
+}
+
+// GetJobs は ScheduleConfig から定期実行ジョブの一覧を返します。
+// cron式のパースに失敗した場合はエラーを返します。
+func (s *ScheduleConfig) GetJobs(game string) ([]ScheduledJob, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	specs := []struct {
+		kind JobKind
+		spec string
+	}{
+		{JobBackup, s.Backup},
+		{JobUpdate, s.Update},
+		{JobRestart, s.Restart},
+		{JobPrune, s.Prune},
+	}
+
+	var jobs []ScheduledJob
+	for _, spec := range specs {
+		if spec.spec == "" {
+			continue
+		}
+		schedule, err := ParseCron(spec.spec)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, ScheduledJob{Game: game, Kind: spec.kind, Spec: spec.spec, Schedule: schedule})
+	}
+	return jobs, nil
+}
+
+// GetKeep は prune で残すバックアップ数を返します。
+func (s *ScheduleConfig) GetKeep() int {
+	if s == nil || s.Keep <= 0 {
+		return DefaultPruneKeep
+	}
+	return s.Keep
+}
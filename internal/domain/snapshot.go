@@ -7,7 +7,7 @@ import (
 
 // MetaVersion はメタデータスキーマのバージョンです。
 // フィールドの追加・変更が生じた際にインクリメントします。
-const MetaVersion = "1"
+const MetaVersion = "3"
 
 // BaseType はファイルのリストア起点となるディレクトリの種別です。
 type BaseType string
@@ -53,9 +53,54 @@ type Metadata struct {
 	CreatedAt   time.Time   `yaml:"created_at"`
 	ToolVersion string      `yaml:"tool_version"`
 	Os          string      `yaml:"os"`
+	RuntimeEnv  RuntimeEnv  `yaml:"runtime_env,omitempty"`
+	WinProfile  string      `yaml:"win_profile,omitempty"` // バックアップ元の Windows ユーザープロファイルディレクトリ
+	Trigger     Trigger     `yaml:"trigger,omitempty"`
+	Note        string      `yaml:"note,omitempty"`
+	Tags        []string    `yaml:"tags"`
 	Files       []FileEntry `yaml:"files"`
 }
 
+// HasTags はメタデータが tags を全て持っている場合に true を返します。
+func (m *Metadata) HasTags(tags []string) bool {
+	for _, tag := range tags {
+		found := false
+		for _, t := range m.Tags {
+			if t == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Trigger はバックアップが作成されたきっかけを表します。
+type Trigger string
+
+const (
+	// TriggerManual はユーザーが backup コマンドで作成したバックアップです。
+	TriggerManual Trigger = "manual"
+	// TriggerPreClean は clean コマンドの実行前に自動で作成したバックアップです。
+	TriggerPreClean Trigger = "pre-clean"
+)
+
+// BackupOptions はバックアップ時に付与する情報を指定するオプションです。
+type BackupOptions struct {
+	Trigger Trigger
+	Note    string
+	Tags    []string
+}
+
+// SnapshotArchive はバックアップのzipファイルと、そのメタデータの組です。
+type SnapshotArchive struct {
+	Meta *Metadata
+	Path string
+}
+
 // FileEntry はzipに含まれる1ファイル分のメタデータです。
 type FileEntry struct {
 	ModifiedAt   time.Time `yaml:"modified_at"`
@@ -87,3 +132,13 @@ func (d WinDirectoryType) GetWinDirType() error {
 		return fmt.Errorf("サポートされていないディレクトリタイプ %s が指定されました", d)
 	}
 }
+
+// RestoreOptions はリストア時の動作を指定するオプションです。
+type RestoreOptions struct {
+	// Mirror が true の場合、リストア先のディレクトリをアーカイブの内容と完全に一致させます。
+	// アーカイブに含まれないファイルは削除されます。
+	Mirror bool
+	// AllowExternalSymlinks が true の場合、アーカイブの外を指すシンボリックリンク(絶対パス等)も復元します。
+	// false の場合、そのようなリンクを含むアーカイブはリストアしません。
+	AllowExternalSymlinks bool
+}
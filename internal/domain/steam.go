@@ -0,0 +1,64 @@
+package domain
+
+import "fmt"
+
+// DefaultSteamBranch は公開ブランチの名前です。
+const DefaultSteamBranch = "public"
+
+// GetBranch は更新するブランチを返します。未設定の場合は公開ブランチです。
+func (s *SteamConfig) GetBranch() string {
+	if s == nil || s.Branch == "" {
+		return DefaultSteamBranch
+	}
+	return s.Branch
+}
+
+// AppManifest はインストール済みアプリのマニフェスト(appmanifest_<appid>.acf)の情報です。
+type AppManifest struct {
+	AppID   string
+	BuildID string
+	// Branch はインストール済みのブランチです。
+	Branch string
+}
+
+// ParseAppManifest はマニフェストの内容をパースします。
+func ParseAppManifest(data []byte) (*AppManifest, error) {
+	vdf, err := ParseVDF(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	state := vdf.Child("AppState")
+	if state == nil {
+		return nil, fmt.Errorf("マニフェストに AppState が見つかりません")
+	}
+	buildID, ok := state.Get("buildid")
+	if !ok {
+		return nil, fmt.Errorf("マニフェストに buildid が見つかりません")
+	}
+	appID, _ := state.Get("appid")
+	// 公開ブランチの場合 BetaKey は空になる
+	branch, _ := state.Get("UserConfig", "BetaKey")
+	if branch == "" {
+		branch = DefaultSteamBranch
+	}
+
+	return &AppManifest{AppID: appID, BuildID: buildID, Branch: branch}, nil
+}
+
+// UpdateStatus はインストール済みのバージョンと、配信中の最新バージョンの比較結果です。
+// steam の場合、バージョンはビルドIDです。
+type UpdateStatus struct {
+	// InstalledVersion はインストール済みのバージョンです。未インストールの場合は空です。
+	InstalledVersion string
+	// InstalledBranch はインストール済みのブランチです。未インストールの場合や、ブランチのないインストール元の場合は空です。
+	InstalledBranch string
+	LatestVersion   string
+	// Branch はコンフィグで指定された更新対象のブランチです。ブランチのないインストール元の場合は空です。
+	Branch string
+}
+
+// Available は更新がある場合に true を返します。ブランチが切り替わる場合も更新として扱います。
+func (s *UpdateStatus) Available() bool {
+	return s.InstalledVersion != s.LatestVersion || s.InstalledBranch != s.Branch
+}
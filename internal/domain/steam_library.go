@@ -0,0 +1,149 @@
+package domain
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// FlatpakSteamDir はFlatpak版Steamのデータディレクトリの、ホームディレクトリからの相対パスです。
+const FlatpakSteamDir = ".var/app/com.valvesoftware.Steam/.local/share/Steam"
+
+// SteamRootCandidates はSteamのインストール先の候補を優先順に返します。
+// steamRoot には環境変数 STEAM_ROOT の値を渡します。空の場合は候補に含めません。
+func SteamRootCandidates(home, steamRoot string) []string {
+	var roots []string
+	if steamRoot != "" {
+		roots = append(roots, steamRoot)
+	}
+	return append(roots,
+		filepath.Join(home, ".steam", "steam"),
+		filepath.Join(home, ".steam", "root"),
+		filepath.Join(home, ".local", "share", "Steam"),
+		filepath.Join(home, FlatpakSteamDir),
+		filepath.Join(home, "snap", "steam", "common", ".local", "share", "Steam"),
+	)
+}
+
+// LibraryFoldersPaths はSteamのインストール先からの、libraryfolders.vdf の相対パスを優先順に返します。
+func LibraryFoldersPaths() []string {
+	return []string{
+		filepath.Join("steamapps", "libraryfolders.vdf"),
+		filepath.Join("config", "libraryfolders.vdf"),
+	}
+}
+
+// ParseLibraryFolders は libraryfolders.vdf をパースし、Steamライブラリのパスを記載順に返します。
+// "0" { "path" "..." } の形式と、古い "1" "..." の形式の両方に対応します。
+func ParseLibraryFolders(data []byte) ([]string, error) {
+	vdf, err := ParseVDF(string(data))
+	if err != nil {
+		return nil, err
+	}
+	root := vdf.Child("libraryfolders")
+	if root == nil {
+		return nil, fmt.Errorf("libraryfolders が見つかりません")
+	}
+
+	// キーは "0", "1", ... の連番になっている
+	type library struct {
+		path  string
+		index int
+	}
+	var libraries []library
+	for key, child := range root.Children {
+		index, err := strconv.Atoi(key)
+		if err != nil {
+			continue
+		}
+		if path, ok := child.Get("path"); ok && path != "" {
+			libraries = append(libraries, library{index: index, path: path})
+		}
+	}
+	for key, path := range root.Values {
+		if index, err := strconv.Atoi(key); err == nil && path != "" {
+			libraries = append(libraries, library{index: index, path: path})
+		}
+	}
+	sort.Slice(libraries, func(i, j int) bool { return libraries[i].index < libraries[j].index })
+
+	paths := make([]string, 0, len(libraries))
+	for _, lib := range libraries {
+		paths = append(paths, lib.path)
+	}
+	return paths, nil
+}
+
+// CompatDataPath はSteamライブラリからの、Protonのプレフィックス(compatdata)の相対パスを返します。
+func CompatDataPath(appID string) string {
+	return filepath.Join("steamapps", "compatdata", appID)
+}
+
+// SteamLibraryFS はSteamライブラリの探索に使用するファイル操作です。
+type SteamLibraryFS interface {
+	Stat(path string) (os.FileInfo, error)
+	ReadFile(path string) ([]byte, error)
+}
+
+// FindCompatData は appID のProtonのプレフィックス(compatdata)を、全てのSteamライブラリから探します。
+// 別のドライブのライブラリにある場合も、実際に compatdata が存在するライブラリを選びます。
+// どのライブラリにも存在しない場合は、最初に見つかったSteamのインストール先の compatdata を返します。
+// steamRoot には環境変数 STEAM_ROOT の値を渡します。
+func FindCompatData(fs SteamLibraryFS, home, steamRoot, appID string) string {
+	libraries := SteamLibraries(fs, home, steamRoot)
+	for _, library := range libraries {
+		path := filepath.Join(library, CompatDataPath(appID))
+		if info, err := fs.Stat(path); err == nil && info.IsDir() {
+			return path
+		}
+	}
+
+	if len(libraries) > 0 {
+		return filepath.Join(libraries[0], CompatDataPath(appID))
+	}
+	return filepath.Join(SteamRootCandidates(home, steamRoot)[0], CompatDataPath(appID))
+}
+
+// SteamLibraries はSteamのインストール先と、その libraryfolders.vdf に記載された全てのSteamライブラリを返します。
+// ~/.steam/steam は ~/.local/share/Steam へのシンボリックリンクであることが多いため、実体が同じものは除きます。
+// 読み込めない・パースできない libraryfolders.vdf は、次の候補の libraryfolders.vdf を読み込みます。
+func SteamLibraries(fs SteamLibraryFS, home, steamRoot string) []string {
+	var libraries []string
+	var seen []os.FileInfo
+	add := func(path string) {
+		if info, err := fs.Stat(path); err == nil {
+			for _, s := range seen {
+				if os.SameFile(s, info) {
+					return
+				}
+			}
+			seen = append(seen, info)
+		}
+		libraries = append(libraries, path)
+	}
+
+	for _, root := range SteamRootCandidates(home, steamRoot) {
+		if _, err := fs.Stat(filepath.Join(root, "steamapps")); err != nil {
+			continue
+		}
+		add(root)
+
+		for _, rel := range LibraryFoldersPaths() {
+			data, err := fs.ReadFile(filepath.Join(root, rel))
+			if err != nil {
+				continue
+			}
+			paths, err := ParseLibraryFolders(data)
+			if err != nil {
+				continue
+			}
+			for _, path := range paths {
+				add(path)
+			}
+			break
+		}
+	}
+	return libraries
+}
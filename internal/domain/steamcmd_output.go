@@ -0,0 +1,166 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SteamCmdFailure はsteamcmdの出力から判別した失敗の種類です。
+type SteamCmdFailure string
+
+const (
+	// SteamCmdDiskSpace はディスクの空き容量不足または書き込みの失敗です。(state 0x202 など)
+	SteamCmdDiskSpace SteamCmdFailure = "disk_space"
+	// SteamCmdConnection はコンテンツサーバとの通信の失敗です。(state 0x402, 0x602 など)
+	SteamCmdConnection SteamCmdFailure = "connection"
+	// SteamCmdNoSubscription はログイン中のアカウントがアプリを所有していないことを示します。
+	SteamCmdNoSubscription SteamCmdFailure = "no_subscription"
+	// SteamCmdRateLimited はログインの試行回数の制限に達したことを示します。
+	SteamCmdRateLimited SteamCmdFailure = "rate_limited"
+	// SteamCmdLoginFailed はパスワードやSteam Guardのコードの誤りなどによるログインの失敗です。
+	SteamCmdLoginFailed SteamCmdFailure = "login_failed"
+	// SteamCmdTimeout はダウンロードのタイムアウトです。
+	SteamCmdTimeout SteamCmdFailure = "timeout"
+	// SteamCmdUnknown はその他のエラーです。
+	SteamCmdUnknown SteamCmdFailure = "unknown"
+)
+
+// SteamCmdError はsteamcmdの出力から判別したエラーです。
+type SteamCmdError struct {
+	Failure SteamCmdFailure
+	// Line はエラーを判別したsteamcmdの出力の行です。
+	Line string
+}
+
+// Error はエラーの説明とsteamcmdの出力を返します。
+func (e *SteamCmdError) Error() string {
+	return fmt.Sprintf("%s (steamcmd: %s)", e.Message(), e.Line)
+}
+
+// Message は失敗の種類ごとの説明を返します。
+func (e *SteamCmdError) Message() string {
+	switch e.Failure {
+	case SteamCmdDiskSpace:
+		return "ディスクの空き容量が不足しているか、書き込みに失敗しました。インストール先の空き容量と権限を確認してください"
+	case SteamCmdConnection:
+		return "Steamのコンテンツサーバとの通信に失敗しました"
+	case SteamCmdNoSubscription:
+		return "アプリを所有していないため、ダウンロードできません。steam.login で所有しているアカウントを指定してください"
+	case SteamCmdRateLimited:
+		return "Steamへのログインの試行回数が制限を超えました。制限が解除されるまで (通常30分から1時間程度) 待ってから再実行してください"
+	case SteamCmdLoginFailed:
+		return "Steamへのログインに失敗しました。パスワードやSteam Guardのコードを確認してください"
+	case SteamCmdTimeout:
+		return "ダウンロードがタイムアウトしました"
+	default:
+		return "steamcmdがエラーを報告しました"
+	}
+}
+
+// Transient は時間をおいて再実行すると成功する可能性があるエラーの場合に true を返します。
+// ログインの試行回数の制限は、再実行するとさらに解除が遅れるため含みません。
+func (e *SteamCmdError) Transient() bool {
+	switch e.Failure {
+	case SteamCmdConnection, SteamCmdTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// SteamCmdProgress はsteamcmdのダウンロードの進捗です。
+type SteamCmdProgress struct {
+	// State は "downloading" や "verifying install" などの状態です。
+	State string
+	// Code は状態のコードです。(0x61 など)
+	Code    string
+	Percent float64
+}
+
+// SteamCmdOutput はsteamcmdの出力を1行ずつ解析した結果です。
+type SteamCmdOutput struct {
+	// Failure は最初に判別したエラーです。エラーがない場合は nil です。
+	Failure *SteamCmdError
+	// Progress は最後に出力された進捗です。
+	Progress *SteamCmdProgress
+}
+
+var (
+	steamProgressPattern = regexp.MustCompile(`Update state \((0x[0-9a-fA-F]+)\) ([^,]+), progress: ([0-9.]+)`)
+	steamAppStatePattern = regexp.MustCompile(`Error! App '\d+' state is (0x[0-9a-fA-F]+)`)
+)
+
+// steamStateFailures は app_update の終了時の状態コードと失敗の種類の対応です。
+var steamStateFailures = map[string]SteamCmdFailure{
+	"0x202": SteamCmdDiskSpace,
+	"0x402": SteamCmdConnection,
+	"0x602": SteamCmdConnection,
+}
+
+// steamMessageFailures はエラーの行に含まれるメッセージと失敗の種類の対応です。上から順に判定します。
+var steamMessageFailures = []struct {
+	message string
+	failure SteamCmdFailure
+}{
+	{"No subscription", SteamCmdNoSubscription},
+	{"Rate Limit Exceeded", SteamCmdRateLimited},
+	{"Invalid Password", SteamCmdLoginFailed},
+	{"Two-factor code mismatch", SteamCmdLoginFailed},
+	{"Account Logon Denied", SteamCmdLoginFailed},
+	{"Disk write failure", SteamCmdDiskSpace},
+	{"Not enough disk space", SteamCmdDiskSpace},
+	{"No Connection", SteamCmdConnection},
+	{"Timeout", SteamCmdTimeout},
+	{"Timed out", SteamCmdTimeout},
+}
+
+// Parse はsteamcmdの出力の1行を解析し、結果に反映します。
+func (o *SteamCmdOutput) Parse(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return
+	}
+
+	if m := steamProgressPattern.FindStringSubmatch(line); m != nil {
+		percent, err := strconv.ParseFloat(m[3], 64)
+		if err == nil {
+			o.Progress = &SteamCmdProgress{Code: m[1], State: strings.TrimSpace(m[2]), Percent: percent}
+		}
+		return
+	}
+	if failure, ok := parseSteamFailure(line); ok && o.Failure == nil {
+		o.Failure = &SteamCmdError{Failure: failure, Line: line}
+	}
+}
+
+// Err は判別したエラーを返します。エラーがない場合は nil を返します。
+func (o *SteamCmdOutput) Err() error {
+	if o.Failure == nil {
+		return nil
+	}
+	return o.Failure
+}
+
+// parseSteamFailure はエラーの行から失敗の種類を判別します。エラーの行でない場合は ok が false になります。
+func parseSteamFailure(line string) (SteamCmdFailure, bool) {
+	if m := steamAppStatePattern.FindStringSubmatch(line); m != nil {
+		if failure, ok := steamStateFailures[strings.ToLower(m[1])]; ok {
+			return failure, true
+		}
+		return SteamCmdUnknown, true
+	}
+
+	// ログインの失敗は "Logging in user 'x' to Steam Public...FAILED (Invalid Password)" の形式で出力される
+	// "SteamAPI_Init() failed" などの警告はエラーとして扱わないよう、大文字小文字を区別する
+	if !strings.HasPrefix(line, "ERROR!") && !strings.HasPrefix(line, "Error!") && !strings.Contains(line, "FAILED") {
+		return "", false
+	}
+	for _, f := range steamMessageFailures {
+		if strings.Contains(line, f.message) {
+			return f.failure, true
+		}
+	}
+	return SteamCmdUnknown, true
+}
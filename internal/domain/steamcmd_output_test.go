@@ -0,0 +1,116 @@
+package domain
+
+import "testing"
+
+func TestSteamCmdOutputParse(t *testing.T) {
+	tests := []struct {
+		name          string
+		lines         []string
+		wantFailure   SteamCmdFailure
+		wantTransient bool
+		wantProgress  *SteamCmdProgress
+	}{
+		{
+			name:  "success",
+			lines: []string{"Loading Steam API...OK", "Success! App '2278520' fully installed."},
+		},
+		{
+			name:         "progress",
+			lines:        []string{" Update state (0x61) downloading, progress: 42.50 (1024 / 2048)"},
+			wantProgress: &SteamCmdProgress{Code: "0x61", State: "downloading", Percent: 42.5},
+		},
+		{
+			name:          "disk space state",
+			lines:         []string{"Error! App '2278520' state is 0x202 after update job."},
+			wantFailure:   SteamCmdDiskSpace,
+			wantTransient: false,
+		},
+		{
+			name:          "connection state",
+			lines:         []string{"Error! App '2278520' state is 0x602 after update job."},
+			wantFailure:   SteamCmdConnection,
+			wantTransient: true,
+		},
+		{
+			name:        "unknown state",
+			lines:       []string{"Error! App '2278520' state is 0x6 after update job."},
+			wantFailure: SteamCmdUnknown,
+		},
+		{
+			name:        "no subscription",
+			lines:       []string{"ERROR! Failed to install app '2278520' (No subscription)"},
+			wantFailure: SteamCmdNoSubscription,
+		},
+		{
+			name:          "rate limited is not transient",
+			lines:         []string{"Logging in user 'bob' to Steam Public...FAILED (Rate Limit Exceeded)"},
+			wantFailure:   SteamCmdRateLimited,
+			wantTransient: false,
+		},
+		{
+			name:        "invalid password",
+			lines:       []string{"Logging in user 'bob' to Steam Public...FAILED (Invalid Password)"},
+			wantFailure: SteamCmdLoginFailed,
+		},
+		{
+			name:          "timeout",
+			lines:         []string{"ERROR! Download item 123 failed (Timeout)."},
+			wantFailure:   SteamCmdTimeout,
+			wantTransient: true,
+		},
+		{
+			name:  "lowercase failed is a warning",
+			lines: []string{"[S_API FAIL] SteamAPI_Init() failed; SteamAPI_IsSteamRunning() failed."},
+		},
+		{
+			name: "first failure wins",
+			lines: []string{
+				"ERROR! Failed to install app '2278520' (No subscription)",
+				"Error! App '2278520' state is 0x602 after update job.",
+			},
+			wantFailure: SteamCmdNoSubscription,
+		},
+		{
+			name: "progress is kept after failure",
+			lines: []string{
+				" Update state (0x61) downloading, progress: 10.00 (1 / 10)",
+				"Error! App '2278520' state is 0x402 after update job.",
+			},
+			wantFailure:   SteamCmdConnection,
+			wantTransient: true,
+			wantProgress:  &SteamCmdProgress{Code: "0x61", State: "downloading", Percent: 10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var o SteamCmdOutput
+			for _, line := range tt.lines {
+				o.Parse(line)
+			}
+
+			if tt.wantFailure == "" {
+				if err := o.Err(); err != nil {
+					t.Fatalf("Err() = %v, want nil", err)
+				}
+			} else {
+				if o.Failure == nil {
+					t.Fatalf("Failure = nil, want %s", tt.wantFailure)
+				}
+				if o.Failure.Failure != tt.wantFailure {
+					t.Errorf("Failure = %s, want %s", o.Failure.Failure, tt.wantFailure)
+				}
+				if got := o.Failure.Transient(); got != tt.wantTransient {
+					t.Errorf("Transient() = %v, want %v", got, tt.wantTransient)
+				}
+			}
+
+			switch {
+			case tt.wantProgress == nil && o.Progress != nil:
+				t.Errorf("Progress = %+v, want nil", o.Progress)
+			case tt.wantProgress != nil && (o.Progress == nil || *o.Progress != *tt.wantProgress):
+				t.Errorf("Progress = %+v, want %+v", o.Progress, tt.wantProgress)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+)
+
+// RollbackMode は更新に失敗した場合のロールバックの動作です。
+type RollbackMode string
+
+const (
+	// RollbackAsk はロールバックするかをユーザーに確認します。
+	RollbackAsk RollbackMode = "ask"
+	// RollbackAuto は確認せずにロールバックします。
+	RollbackAuto RollbackMode = "auto"
+	// RollbackNever はロールバックしません。
+	RollbackNever RollbackMode = "never"
+)
+
+// UpdateMode は更新の方法です。
+type UpdateMode string
+
+const (
+	// UpdateInPlace は install_dir をそのまま更新します。
+	UpdateInPlace UpdateMode = "in-place"
+	// UpdateStaged は install_dir の複製を更新し、確認に成功した場合に install_dir と入れ替えます。
+	UpdateStaged UpdateMode = "staged"
+)
+
+const (
+	// stagingSuffix は staged で更新する複製のディレクトリ名に付与する接尾辞です。
+	stagingSuffix = ".archon-staging"
+	// previousSuffix は staged で入れ替えた、更新前のディレクトリ名に付与する接尾辞です。
+	previousSuffix = ".archon-previous"
+)
+
+// TriggerPreUpdate は update の実行前に自動で作成したバックアップです。
+const TriggerPreUpdate Trigger = "pre-update"
+
+// GetRollback はロールバックの動作を返します。未設定の場合は ask です。
+func (u *UpdateConfig) GetRollback() RollbackMode {
+	if u == nil || u.Rollback == "" {
+		return RollbackAsk
+	}
+	return u.Rollback
+}
+
+// GetMode は更新の方法を返します。未設定の場合は in-place です。
+func (u *UpdateConfig) GetMode() UpdateMode {
+	if u == nil || u.Mode == "" {
+		return UpdateInPlace
+	}
+	return u.Mode
+}
+
+// Validate は UpdateConfig の値を検証します。
+func (u *UpdateConfig) Validate() error {
+	switch u.GetRollback() {
+	case RollbackAsk, RollbackAuto, RollbackNever:
+	default:
+		return fmt.Errorf("rollback に指定できるのは ask, auto, never のいずれかです: %s", u.Rollback)
+	}
+	switch u.GetMode() {
+	case UpdateInPlace, UpdateStaged:
+	default:
+		return fmt.Errorf("mode に指定できるのは in-place, staged のいずれかです: %s", u.Mode)
+	}
+	return nil
+}
+
+// StagingDir は staged で更新する、install_dir の複製のディレクトリを返します。install_dir と同じ階層に作成します。
+func StagingDir(installDir string) string {
+	return filepath.Clean(installDir) + stagingSuffix
+}
+
+// PreviousDir は staged で入れ替えた、更新前の install_dir を保存するディレクトリを返します。
+func PreviousDir(installDir string) string {
+	return filepath.Clean(installDir) + previousSuffix
+}
+
+// WithInstallDir は install_dir を dir に変更したコンフィグのコピーを返します。
+func (g *GameConfig) WithInstallDir(dir string) *GameConfig {
+	cp := *g
+	cp.InstallDir = dir
+	return &cp
+}
+
+// SteamManifestPath はインストールディレクトリからの、Steamのアプリマニフェストの相対パスを返します。
+func SteamManifestPath(appID string) string {
+	return filepath.Join("steamapps", fmt.Sprintf("appmanifest_%s.acf", appID))
+}
+
+// WithInstallDirTargets は install_dir のバックアップ対象に paths を加えたコンフィグのコピーを返します。
+// 更新前のバックアップなど、コンフィグ以外のファイルも一緒に保存する場合に使用します。
+func (g *GameConfig) WithInstallDirTargets(paths ...string) *GameConfig {
+	cp := *g
+	targets := BackupTargetConfig{}
+	if g.BackupTargets != nil {
+		targets = *g.BackupTargets
+	}
+
+	installDir := slices.Clone(targets.InstallDir)
+	for _, path := range paths {
+		if !slices.Contains(installDir, path) {
+			installDir = append(installDir, path)
+		}
+	}
+	targets.InstallDir = installDir
+	cp.BackupTargets = &targets
+
+	return &cp
+}
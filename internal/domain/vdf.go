@@ -0,0 +1,199 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// VDF はSteamのKeyValues形式(VDF/ACF)のノードです。
+// Steamのキーは大文字・小文字を区別しないため、キーは小文字に変換して保持します。
+type VDF struct {
+	Values   map[string]string
+	Children map[string]*VDF
+}
+
+// newVDF 空のノードを生成する
+func newVDF() *VDF {
+	return &VDF{Values: make(map[string]string), Children: make(map[string]*VDF)}
+}
+
+// Child は path で指定した子ノードを返します。見つからない場合は nil を返します。
+func (v *VDF) Child(path ...string) *VDF {
+	node := v
+	for _, key := range path {
+		if node == nil {
+			return nil
+		}
+		node = node.Children[strings.ToLower(key)]
+	}
+	return node
+}
+
+// Get は path で指定した値を返します。最後の要素が値のキーです。
+func (v *VDF) Get(path ...string) (string, bool) {
+	if len(path) == 0 {
+		return "", false
+	}
+	node := v.Child(path[:len(path)-1]...)
+	if node == nil {
+		return "", false
+	}
+	value, ok := node.Values[strings.ToLower(path[len(path)-1])]
+	return value, ok
+}
+
+// ParseVDF はVDF/ACF形式のテキストをパースします。
+// トップレベルのキーは返却するノードの子または値になります。
+func ParseVDF(text string) (*VDF, error) {
+	p := &vdfParser{tokens: tokenizeVDF(text)}
+	root, err := p.parseBody(false)
+	if err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// vdfToken はVDFの字句です。
+type vdfToken struct {
+	text   string
+	quoted bool
+}
+
+// vdfParser はVDFのパーサーです。
+type vdfParser struct {
+	tokens []vdfToken
+	pos    int
+}
+
+// parseBody は "}" またはテキストの終わりまでのキーと値をパースします。
+func (p *vdfParser) parseBody(nested bool) (*VDF, error) {
+	node := newVDF()
+	for p.pos < len(p.tokens) {
+		key := p.tokens[p.pos]
+		p.pos++
+
+		if !key.quoted && key.text == "}" {
+			if !nested {
+				return nil, fmt.Errorf("VDFのパースに失敗しました: 対応する '{' のない '}' があります")
+			}
+			return node, nil
+		}
+		if !key.quoted && key.text == "{" {
+			return nil, fmt.Errorf("VDFのパースに失敗しました: キーのない '{' があります")
+		}
+		if p.pos >= len(p.tokens) {
+			return nil, fmt.Errorf("VDFのパースに失敗しました: キー '%s' の値がありません", key.text)
+		}
+
+		value := p.tokens[p.pos]
+		p.pos++
+		name := strings.ToLower(key.text)
+
+		if !value.quoted && value.text == "{" {
+			child, err := p.parseBody(true)
+			if err != nil {
+				return nil, err
+			}
+			node.Children[name] = child
+			continue
+		}
+		if !value.quoted && value.text == "}" {
+			return nil, fmt.Errorf("VDFのパースに失敗しました: キー '%s' の値がありません", key.text)
+		}
+		node.Values[name] = value.text
+	}
+
+	if nested {
+		return nil, fmt.Errorf("VDFのパースに失敗しました: '}' が不足しています")
+	}
+	return node, nil
+}
+
+// tokenizeVDF はVDFのテキストを字句に分割します。
+// "//" から行末まではコメントとして無視し、[$WIN32] のような条件式は読み飛ばします。
+func tokenizeVDF(text string) []vdfToken {
+	var tokens []vdfToken
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			continue
+		case c == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case c == '{' || c == '}':
+			tokens = append(tokens, vdfToken{text: string(c)})
+		case c == '[':
+			for i < len(runes) && runes[i] != ']' {
+				i++
+			}
+		case c == '"':
+			var sb strings.Builder
+			for i++; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+					switch runes[i] {
+					case 'n':
+						sb.WriteRune('\n')
+					case 't':
+						sb.WriteRune('\t')
+					default:
+						sb.WriteRune(runes[i])
+					}
+					continue
+				}
+				sb.WriteRune(runes[i])
+			}
+			tokens = append(tokens, vdfToken{text: sb.String(), quoted: true})
+		default:
+			start := i
+			for i < len(runes) && !strings.ContainsRune(" \t\r\n{}\"", runes[i]) {
+				i++
+			}
+			tokens = append(tokens, vdfToken{text: string(runes[start:i])})
+			i--
+		}
+	}
+	return tokens
+}
+
+// ExtractVDFBlock は text の中から "key" { ... } のブロックを取り出します。
+// steamcmd の出力のように、VDF以外のテキストが前後に含まれている場合に使用します。
+func ExtractVDFBlock(text, key string) (string, error) {
+	quotedKey := `"` + key + `"`
+	for offset := 0; ; {
+		idx := strings.Index(text[offset:], quotedKey)
+		if idx < 0 {
+			return "", fmt.Errorf("出力に \"%s\" のブロックが見つかりません", key)
+		}
+		start := offset + idx
+		rest := strings.TrimLeft(text[start+len(quotedKey):], " \t\r\n")
+		if !strings.HasPrefix(rest, "{") {
+			offset = start + len(quotedKey)
+			continue
+		}
+
+		// 対応する "}" までを取り出す
+		open := len(text) - len(rest)
+		depth := 0
+		inQuote := false
+		for i := open; i < len(text); i++ {
+			switch c := text[i]; {
+			case c == '\\' && inQuote:
+				i++
+			case c == '"':
+				inQuote = !inQuote
+			case c == '{' && !inQuote:
+				depth++
+			case c == '}' && !inQuote:
+				depth--
+				if depth == 0 {
+					return text[start : i+1], nil
+				}
+			}
+		}
+		return "", fmt.Errorf("\"%s\" のブロックが閉じられていません", key)
+	}
+}
@@ -0,0 +1,154 @@
+package domain
+
+import "testing"
+
+func TestParseVDF(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		path    []string
+		want    string
+		wantOK  bool
+		wantErr bool
+	}{
+		{
+			name:   "nested value",
+			text:   `"AppState" { "appid" "900" "UserConfig" { "BetaKey" "experimental" } }`,
+			path:   []string{"AppState", "UserConfig", "BetaKey"},
+			want:   "experimental",
+			wantOK: true,
+		},
+		{
+			name:   "keys are case insensitive",
+			text:   `"AppState" { "BuildID" "15000001" }`,
+			path:   []string{"appstate", "buildid"},
+			want:   "15000001",
+			wantOK: true,
+		},
+		{
+			name:   "comments and conditionals are skipped",
+			text:   "// comment\n\"root\"\n{\n\t\"key\" \"value\" [$WIN32]\n}\n",
+			path:   []string{"root", "key"},
+			want:   "value",
+			wantOK: true,
+		},
+		{
+			name:   "escaped characters",
+			text:   `"root" { "key" "a\"b\\c\td" }`,
+			path:   []string{"root", "key"},
+			want:   "a\"b\\c\td",
+			wantOK: true,
+		},
+		{
+			name:   "unquoted tokens",
+			text:   "root { key value }",
+			path:   []string{"root", "key"},
+			want:   "value",
+			wantOK: true,
+		},
+		{
+			name: "missing key",
+			text: `"root" { "key" "value" }`,
+			path: []string{"root", "other"},
+		},
+		{
+			name:    "missing closing brace",
+			text:    `"root" { "key" "value"`,
+			wantErr: true,
+		},
+		{
+			name:    "unexpected closing brace",
+			text:    `"key" "value" }`,
+			wantErr: true,
+		},
+		{
+			name:    "missing value",
+			text:    `"root" { "key" }`,
+			wantErr: true,
+		},
+		{
+			name:    "brace without key",
+			text:    `{ "key" "value" }`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vdf, err := ParseVDF(tt.text)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("ParseVDF() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseVDF() error = %v", err)
+			}
+
+			got, ok := vdf.Get(tt.path...)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("Get(%v) = %q, %v, want %q, %v", tt.path, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestExtractVDFBlock(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "surrounded by other output",
+			text: "AppID : 900, change number : 1/0\n\"900\"\n{\n\t\"common\" { \"name\" \"x\" }\n}\nUnloading Steam API...OK\n",
+			key:  "900",
+			want: "\"900\"\n{\n\t\"common\" { \"name\" \"x\" }\n}",
+		},
+		{
+			name: "skips key used as a value",
+			text: `"appid" "900" "900" { "a" "b" }`,
+			key:  "900",
+			want: `"900" { "a" "b" }`,
+		},
+		{
+			name: "braces inside quotes",
+			text: `"900" { "a" "}{" }`,
+			key:  "900",
+			want: `"900" { "a" "}{" }`,
+		},
+		{
+			name:    "not found",
+			text:    `"901" { }`,
+			key:     "900",
+			wantErr: true,
+		},
+		{
+			name:    "not closed",
+			text:    `"900" { "a" { }`,
+			key:     "900",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractVDFBlock(tt.text, tt.key)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ExtractVDFBlock() = %q, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExtractVDFBlock() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ExtractVDFBlock() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
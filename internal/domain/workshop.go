@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+)
+
+// WorkshopMode はダウンロードしたワークショップアイテムを mods_dir に配置する方法です。
+type WorkshopMode string
+
+const (
+	// WorkshopModeCopy はアイテムを mods_dir にコピーします。
+	WorkshopModeCopy WorkshopMode = "copy"
+	// WorkshopModeLink はアイテムへのシンボリックリンクを mods_dir に作成します。
+	WorkshopModeLink WorkshopMode = "link"
+)
+
+// WorkshopLock は同期済みのワークショップアイテムの記録です。変更のないアイテムの同期をスキップするために使います。
+type WorkshopLock struct {
+	Items map[string]WorkshopLockItem `yaml:"items"`
+}
+
+// WorkshopLockItem はアイテムごとの同期済みのバージョンです。
+type WorkshopLockItem struct {
+	// TimeUpdated はワークショップでアイテムが更新された日時(Unix時間)です。取得できなかった場合は0です。
+	TimeUpdated int64 `yaml:"time_updated"`
+}
+
+// GetAppID はワークショップのアプリIDを返します。未設定の場合は steam.app_id です。
+func (w *WorkshopConfig) GetAppID(steam *SteamConfig) string {
+	if w != nil && w.AppID != "" {
+		return w.AppID
+	}
+	if steam == nil {
+		return ""
+	}
+	return steam.AppID
+}
+
+// GetMode はアイテムの配置方法を返します。未設定の場合は copy です。
+func (w *WorkshopConfig) GetMode() WorkshopMode {
+	if w == nil || w.Mode == "" {
+		return WorkshopModeCopy
+	}
+	return w.Mode
+}
+
+// SyncOnUpdate は update の後にアイテムを同期する場合に true を返します。
+func (w *WorkshopConfig) SyncOnUpdate() bool {
+	return w != nil && w.SyncUpdate && len(w.Items) > 0
+}
+
+// Validate は WorkshopConfig の値を検証します。
+func (w *WorkshopConfig) Validate(steam *SteamConfig) error {
+	if w == nil {
+		return nil
+	}
+	if len(w.Items) == 0 {
+		return fmt.Errorf("items にワークショップのアイテムIDを指定してください")
+	}
+	if w.ModsDir == "" {
+		return fmt.Errorf("mods_dir を指定してください")
+	}
+	if !filepath.IsLocal(w.ModsDir) {
+		return fmt.Errorf("mods_dir は install_dir からの相対パスで指定してください: %s", w.ModsDir)
+	}
+	if w.GetAppID(steam) == "" {
+		return fmt.Errorf("app_id または steam.app_id を指定してください")
+	}
+	for i, item := range w.Items {
+		if item == "" || !isDigits(item) {
+			return fmt.Errorf("アイテムIDは数字で指定してください: %q", item)
+		}
+		if slices.Contains(w.Items[:i], item) {
+			return fmt.Errorf("アイテムID %s が重複しています", item)
+		}
+	}
+	switch w.GetMode() {
+	case WorkshopModeCopy, WorkshopModeLink:
+		return nil
+	default:
+		return fmt.Errorf("mode に指定できるのは copy, link のいずれかです: %s", w.Mode)
+	}
+}
+
+// WorkshopContentPath は steamcmd の force_install_dir からの、ダウンロードしたアイテムの相対パスを返します。
+func WorkshopContentPath(appID, item string) string {
+	return filepath.Join("steamapps", "workshop", "content", appID, item)
+}
+
+// isDigits は s が数字のみで構成されている場合に true を返します。
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,16 @@
+package cgroup
+
+import (
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+// Cgroup resources を設定したゲームのサーバの cgroup v2 の管理
+type Cgroup struct {
+	archonCfg *domain.ArchonConfig
+}
+
+// NewCgroup Cgroupのインスタンスを生成する
+// archonCfg の cgroup.root, cgroup.parent の下にゲームごとの cgroup を作成します。
+func NewCgroup(archonCfg *domain.ArchonConfig) *Cgroup {
+	return &Cgroup{archonCfg: archonCfg}
+}
@@ -0,0 +1,222 @@
+//go:build linux
+
+package cgroup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+// Check は resources の制限に必要な cgroup v2 のコントローラが使用できるかを確認します。
+// resources が設定されていない場合は何もしません。
+func (c *Cgroup) Check(gameCfg *domain.GameConfig) error {
+	if gameCfg.Resources == nil {
+		return nil
+	}
+
+	// cgroup v1 のみの環境には cgroup.controllers が存在しない
+	root := c.archonCfg.GetCgroupRoot()
+	available, err := readControllers(filepath.Join(root, "cgroup.controllers"))
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("cgroup.root (%s) は cgroup v2 (cgroup2) のマウント先ではありません", root)
+	} else if err != nil {
+		return err
+	}
+	var missing []string
+	for _, controller := range requiredControllers(gameCfg.Resources) {
+		if !slices.Contains(available, controller) {
+			missing = append(missing, controller)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("cgroup のコントローラ %s が使用できません", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// Prepare はゲームの cgroup を作成して resources の制限を書き込み、cgroup のディレクトリを開いて返します。
+// 返されたディレクトリは SysProcAttr.CgroupFD に指定し、起動後に閉じてください。
+// 制限が設定されていない項目は上限なし(デフォルト)に戻すため、resources から削除した制限は次の起動で解除されます。
+func (c *Cgroup) Prepare(gameCfg *domain.GameConfig) (*os.File, error) {
+	if err := c.Check(gameCfg); err != nil {
+		return nil, err
+	}
+	limits, err := limitFiles(gameCfg.Resources)
+	if err != nil {
+		return nil, err
+	}
+
+	// cgroup v2 では親の cgroup.subtree_control で有効にしたコントローラのみ子で使用できるため、
+	// root から parent までの各階層で有効にする
+	controllers := requiredControllers(gameCfg.Resources)
+	dir := c.archonCfg.GetCgroupRoot()
+	for _, name := range strings.Split(filepath.Clean(c.archonCfg.GetCgroupParent()), string(filepath.Separator)) {
+		if err := enableControllers(dir, controllers); err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(dir, name)
+		if err := mkdir(dir); err != nil {
+			return nil, err
+		}
+	}
+	if err := enableControllers(dir, controllers); err != nil {
+		return nil, err
+	}
+
+	dir = c.archonCfg.GetCgroupDir(gameCfg.Name)
+	if err := mkdir(dir); err != nil {
+		return nil, err
+	}
+	for _, limit := range limits {
+		path := filepath.Join(dir, limit.file)
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) && !limit.required {
+			continue
+		}
+		if err := os.WriteFile(path, []byte(limit.value), 0o644); err != nil {
+			return nil, fmt.Errorf("%s に %s を書き込めませんでした: %w", path, limit.value, err)
+		}
+	}
+
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, fmt.Errorf("cgroup %s を開けませんでした: %w", dir, err)
+	}
+	return f, nil
+}
+
+// Usage はゲームの cgroup から現在のリソースの使用状況を取得します。
+// resources が設定されていない場合や、cgroup がまだ作成されていない場合は nil を返します。
+// memory.peak, io.stat などカーネルや有効なコントローラによって存在しないファイルは0として扱います。
+func (c *Cgroup) Usage(gameCfg *domain.GameConfig) (*domain.CgroupUsage, error) {
+	if gameCfg.Resources == nil {
+		return nil, nil
+	}
+	dir := c.archonCfg.GetCgroupDir(gameCfg.Name)
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("cgroup %s を確認できません: %w", dir, err)
+	}
+
+	read := func(name string) []byte {
+		data, _ := os.ReadFile(filepath.Join(dir, name))
+		return data
+	}
+	value := func(name string) int64 {
+		n, _ := strconv.ParseInt(strings.TrimSpace(string(read(name))), 10, 64)
+		return n
+	}
+
+	usage := &domain.CgroupUsage{
+		MemoryMax:     domain.ParseCgroupValue(read("memory.max")),
+		PidsMax:       domain.ParseCgroupValue(read("pids.max")),
+		CPUMax:        strings.TrimSpace(string(read("cpu.max"))),
+		MemoryCurrent: value("memory.current"),
+		MemoryPeak:    value("memory.peak"),
+		OOMKills:      domain.ParseCgroupKeyValues(read("memory.events"))["oom_kill"],
+		PidsCurrent:   value("pids.current"),
+	}
+	cpuStat := domain.ParseCgroupKeyValues(read("cpu.stat"))
+	usage.CPUUsageUsec = cpuStat["usage_usec"]
+	usage.CPUThrottledUsec = cpuStat["throttled_usec"]
+	usage.IOReadBytes, usage.IOWriteBytes = domain.ParseIOStat(read("io.stat"))
+	return usage, nil
+}
+
+// limit cgroup に書き込む制限
+type limit struct {
+	file  string
+	value string
+	// required は、ファイルが存在しない場合にエラーとするかどうかです。
+	required bool
+}
+
+// limitFiles resources から cgroup に書き込む制限を生成する
+// 設定されていない制限は、コントローラが無効でファイルが存在しない場合にスキップする
+func limitFiles(resources *domain.ResourcesConfig) ([]limit, error) {
+	memory, err := resources.MemoryMax()
+	if err != nil {
+		return nil, err
+	}
+	cpu, err := resources.CPUMax()
+	if err != nil {
+		return nil, err
+	}
+	return []limit{
+		{file: "memory.max", value: memory, required: resources.Memory != ""},
+		{file: "cpu.max", value: cpu, required: resources.CPU != ""},
+		{file: "pids.max", value: resources.PidsMax(), required: resources.Pids > 0},
+		{file: "io.weight", value: resources.IOWeightValue(), required: resources.IOWeight > 0},
+	}, nil
+}
+
+// requiredControllers resources の制限に必要なコントローラを返す
+// 使用状況の表示のため、memory は常に必要とする
+func requiredControllers(resources *domain.ResourcesConfig) []string {
+	controllers := []string{"memory"}
+	if resources.CPU != "" {
+		controllers = append(controllers, "cpu")
+	}
+	if resources.Pids > 0 {
+		controllers = append(controllers, "pids")
+	}
+	if resources.IOWeight > 0 {
+		controllers = append(controllers, "io")
+	}
+	return controllers
+}
+
+// enableControllers dir の cgroup.subtree_control で、使用可能なコントローラを子の cgroup で有効にする
+// 必要なコントローラは有効にできない場合にエラーとし、それ以外は使用状況の表示のために可能であれば有効にする
+func enableControllers(dir string, required []string) error {
+	available, err := readControllers(filepath.Join(dir, "cgroup.controllers"))
+	if err != nil {
+		return err
+	}
+	enabled, err := readControllers(filepath.Join(dir, "cgroup.subtree_control"))
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, "cgroup.subtree_control")
+	for _, controller := range domain.CgroupControllers {
+		if slices.Contains(enabled, controller) {
+			continue
+		}
+		isRequired := slices.Contains(required, controller)
+		if !slices.Contains(available, controller) {
+			if isRequired {
+				return fmt.Errorf("cgroup %s でコントローラ %s が使用できません", dir, controller)
+			}
+			continue
+		}
+		if err := os.WriteFile(path, []byte("+"+controller), 0o644); err != nil && isRequired {
+			return fmt.Errorf("cgroup %s でコントローラ %s を有効にできませんでした (rootで実行するか、cgroup の委譲を確認してください): %w", dir, controller, err)
+		}
+	}
+	return nil
+}
+
+// readControllers cgroup.controllers, cgroup.subtree_control を読み込む
+func readControllers(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s を読み込めませんでした: %w", path, err)
+	}
+	return strings.Fields(string(data)), nil
+}
+
+// mkdir cgroup を作成する。既に存在する場合は何もしない
+func mkdir(dir string) error {
+	if err := os.Mkdir(dir, 0o755); err != nil && !errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("cgroup %s を作成できませんでした (rootで実行するか、cgroup の委譲を確認してください): %w", dir, err)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+//go:build linux
+
+package cgroup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+// writeFiles は dir にファイル名と内容の files を作成する
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// assertFile は path の内容が want であることを確認する
+func assertFile(t *testing.T, path, want string) {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%s が読み込めません: %v", path, err)
+	}
+	if string(data) != want {
+		t.Errorf("%s の内容 = %q, want %q", path, data, want)
+	}
+}
+
+// newTestCgroup は一時ディレクトリを cgroup.root とし、parent の下にゲームの cgroup を作成する Cgroup を返す
+// 実際の cgroupfs とは異なり、cgroup.subtree_control への書き込みは追記されず、最後に有効にしたコントローラのみが残る。
+func newTestCgroup(t *testing.T, parent string) (*Cgroup, string) {
+	t.Helper()
+
+	root := t.TempDir()
+	return NewCgroup(&domain.ArchonConfig{Cgroup: &domain.CgroupConfig{Root: root, Parent: parent}}), root
+}
+
+func TestPrepare(t *testing.T) {
+	c, root := newTestCgroup(t, "archon.slice/games")
+	writeFiles(t, root, map[string]string{
+		"cgroup.controllers":     "cpuset cpu io memory pids\n",
+		"cgroup.subtree_control": "cpuset cpu io memory\n",
+	})
+	writeFiles(t, filepath.Join(root, "archon.slice"), map[string]string{
+		"cgroup.controllers":     "cpu io memory pids\n",
+		"cgroup.subtree_control": "memory pids io\n",
+	})
+	writeFiles(t, filepath.Join(root, "archon.slice", "games"), map[string]string{
+		"cgroup.controllers":     "memory pids\n",
+		"cgroup.subtree_control": "pids\n",
+	})
+	// 前回の起動で設定した制限
+	gameDir := filepath.Join(root, "archon.slice", "games", "test")
+	writeFiles(t, gameDir, map[string]string{
+		"cpu.max":   "50000 100000\n",
+		"io.weight": "default 500\n",
+	})
+
+	gameCfg := &domain.GameConfig{Name: "test", Resources: &domain.ResourcesConfig{Memory: "1G", Pids: 64}}
+	f, err := c.Prepare(gameCfg)
+	if err != nil {
+		t.Fatalf("Prepare() error = %v", err)
+	}
+	if f.Name() != gameDir {
+		t.Errorf("Prepare() = %s, want %s", f.Name(), gameDir)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 各階層で、有効になっていないコントローラを子の cgroup で有効にする
+	assertFile(t, filepath.Join(root, "cgroup.subtree_control"), "+pids")
+	assertFile(t, filepath.Join(root, "archon.slice", "cgroup.subtree_control"), "+cpu")
+	assertFile(t, filepath.Join(root, "archon.slice", "games", "cgroup.subtree_control"), "+memory")
+
+	assertFile(t, filepath.Join(gameDir, "memory.max"), "1073741824")
+	assertFile(t, filepath.Join(gameDir, "pids.max"), "64")
+	// resources から削除した制限は上限なし(デフォルト)に戻す
+	assertFile(t, filepath.Join(gameDir, "cpu.max"), "max 100000")
+	assertFile(t, filepath.Join(gameDir, "io.weight"), "default 100")
+}
+
+func TestPrepareUnavailableController(t *testing.T) {
+	c, root := newTestCgroup(t, "archon.slice")
+	writeFiles(t, root, map[string]string{
+		"cgroup.controllers":     "cpu memory pids\n",
+		"cgroup.subtree_control": "cpu memory pids\n",
+	})
+	writeFiles(t, filepath.Join(root, "archon.slice"), map[string]string{
+		"cgroup.controllers":     "memory pids\n",
+		"cgroup.subtree_control": "",
+	})
+
+	gameCfg := &domain.GameConfig{Name: "test", Resources: &domain.ResourcesConfig{CPU: "2"}}
+	if f, err := c.Prepare(gameCfg); err == nil {
+		_ = f.Close()
+		t.Fatal("Prepare() error = nil, want error")
+	}
+	if _, err := os.Stat(filepath.Join(root, "archon.slice", "test")); !os.IsNotExist(err) {
+		t.Errorf("コントローラが使用できない場合にゲームの cgroup が作成されています: %v", err)
+	}
+}
+
+func TestPrepareNotCgroup2(t *testing.T) {
+	c, _ := newTestCgroup(t, "archon.slice")
+
+	gameCfg := &domain.GameConfig{Name: "test", Resources: &domain.ResourcesConfig{Memory: "1G"}}
+	if f, err := c.Prepare(gameCfg); err == nil {
+		_ = f.Close()
+		t.Fatal("Prepare() error = nil, want error")
+	}
+}
+
+func TestUsage(t *testing.T) {
+	c, root := newTestCgroup(t, "archon.slice")
+	writeFiles(t, filepath.Join(root, "archon.slice", "test"), map[string]string{
+		"memory.max":     "1073741824\n",
+		"pids.max":       "max\n",
+		"cpu.max":        "200000 100000\n",
+		"memory.current": "52428800\n",
+		"memory.events":  "low 0\nhigh 0\nmax 3\noom 1\noom_kill 2\n",
+		"pids.current":   "12\n",
+		"cpu.stat":       "usage_usec 123456\nuser_usec 100000\nsystem_usec 23456\nthrottled_usec 789\n",
+		"io.stat":        "8:0 rbytes=1000 wbytes=2000 rios=1 wios=2\n8:16 rbytes=10 wbytes=20 rios=1 wios=2\n",
+	})
+
+	gameCfg := &domain.GameConfig{Name: "test", Resources: &domain.ResourcesConfig{Memory: "1G"}}
+	got, err := c.Usage(gameCfg)
+	if err != nil {
+		t.Fatalf("Usage() error = %v", err)
+	}
+	want := domain.CgroupUsage{
+		MemoryMax:        "1073741824",
+		PidsMax:          "",
+		CPUMax:           "200000 100000",
+		MemoryCurrent:    52428800,
+		MemoryPeak:       0, // memory.peak がないカーネル
+		OOMKills:         2,
+		CPUUsageUsec:     123456,
+		CPUThrottledUsec: 789,
+		PidsCurrent:      12,
+		IOReadBytes:      1010,
+		IOWriteBytes:     2020,
+	}
+	if got == nil || *got != want {
+		t.Errorf("Usage() = %+v, want %+v", got, want)
+	}
+}
+
+func TestUsageNotPrepared(t *testing.T) {
+	c, _ := newTestCgroup(t, "archon.slice")
+
+	tests := []struct {
+		name      string
+		resources *domain.ResourcesConfig
+	}{
+		{name: "no resources", resources: nil},
+		{name: "no cgroup", resources: &domain.ResourcesConfig{Memory: "1G"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.Usage(&domain.GameConfig{Name: "test", Resources: tt.resources})
+			if err != nil || got != nil {
+				t.Errorf("Usage() = %+v, %v, want nil, nil", got, err)
+			}
+		})
+	}
+}
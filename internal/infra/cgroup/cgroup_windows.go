@@ -0,0 +1,28 @@
+//go:build windows
+
+package cgroup
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+// Check は Windows では cgroup が使用できないため、resources が設定されている場合はエラーを返します。
+func (c *Cgroup) Check(gameCfg *domain.GameConfig) error {
+	if gameCfg.Resources != nil {
+		return fmt.Errorf("resources はLinuxでのみ使用できます")
+	}
+	return nil
+}
+
+// Prepare は Windows では cgroup が使用できないため、エラーを返します。
+func (c *Cgroup) Prepare(_ *domain.GameConfig) (*os.File, error) {
+	return nil, fmt.Errorf("resources はLinuxでのみ使用できます")
+}
+
+// Usage は Windows では cgroup が使用できないため、nil を返します。
+func (c *Cgroup) Usage(_ *domain.GameConfig) (*domain.CgroupUsage, error) {
+	return nil, nil
+}
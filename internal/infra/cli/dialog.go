@@ -4,19 +4,41 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"os"
 	"strings"
 )
 
 // Util CLI操作のユーティリティ
-type Util struct{}
+type Util struct {
+	// stdin は AskSecret で使用する標準入力のリーダー
+	// パイプで複数行を渡された場合に読み込み済みの行を失わないよう、使い回す
+	stdin *bufio.Reader
+	// nonInteractive が true の場合、入力を待たずにデフォルトの回答を返す
+	nonInteractive bool
+}
 
 // NewCliUtil CliUtilのインスタンスを生成する
 func NewCliUtil() *Util {
 	return &Util{}
 }
 
+// NewNonInteractiveCliUtil 入力を待たずに常にデフォルトの回答を返すCliUtilのインスタンスを生成する
+// スケジューラなど、ユーザーが応答できない状況で使用します。
+func NewNonInteractiveCliUtil() *Util {
+	return &Util{nonInteractive: true}
+}
+
 // AskYesNo ユーザーにYes/Noの選択を促す
 func (c *Util) AskYesNo(r io.Reader, question string, defaultYes bool) (bool, error) {
+	if c.nonInteractive {
+		answer := "n"
+		if defaultYes {
+			answer = "y"
+		}
+		fmt.Printf("%s [自動応答: %s]\n", question, answer)
+		return defaultYes, nil
+	}
+
 	reader := bufio.NewReader(r)
 	if defaultYes {
 		fmt.Printf("%s [Y/n]: ", question)
@@ -36,6 +58,29 @@ func (c *Util) AskYesNo(r io.Reader, question string, defaultYes bool) (bool, er
 	return input != "" && input != `n` && input != "no", nil
 }
 
+// AskSecret 入力内容を画面に表示せずに、パスワード等の入力を促す
+func (c *Util) AskSecret(prompt string) (string, error) {
+	if c.nonInteractive {
+		return "", fmt.Errorf("%s: 自動応答中のため入力できません", prompt)
+	}
+
+	fmt.Printf("%s: ", prompt)
+	restore, err := disableEcho(int(os.Stdin.Fd())) // nolint:gosec // G115 fd fits in int
+	if err != nil {
+		return "", fmt.Errorf("端末の設定に失敗しました: %w", err)
+	}
+	if c.stdin == nil {
+		c.stdin = bufio.NewReader(os.Stdin)
+	}
+	input, err := c.stdin.ReadString('\n')
+	restore()
+	fmt.Println()
+	if err != nil {
+		return "", fmt.Errorf("入力の読み込みに失敗しました: %w", err)
+	}
+	return strings.TrimRight(input, "\r\n"), nil
+}
+
 // Writeln 文字列を結合して最後に改行する
 func (c *Util) Writeln(builder *strings.Builder, strs ...string) {
 	for _, str := range strs {
@@ -0,0 +1,28 @@
+//go:build linux
+
+package cli
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// disableEcho は端末のエコーを無効にし、元に戻す関数を返します。
+// 標準入力が端末でない場合は何もしません。
+func disableEcho(fd int) (func(), error) {
+	termios, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		// 端末ではない (パイプ等)
+		return func() {}, nil //nolint:nilerr // 端末でなければエコーの制御は不要
+	}
+
+	state := *termios
+	state.Lflag &^= unix.ECHO
+	if err := unix.IoctlSetTermios(fd, unix.TCSETS, &state); err != nil {
+		return nil, fmt.Errorf("エコーの無効化に失敗しました: %w", err)
+	}
+	return func() {
+		_ = unix.IoctlSetTermios(fd, unix.TCSETS, termios)
+	}, nil
+}
@@ -0,0 +1,27 @@
+//go:build windows
+
+package cli
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows"
+)
+
+// disableEcho はコンソールのエコーを無効にし、元に戻す関数を返します。
+// 標準入力がコンソールでない場合は何もしません。
+func disableEcho(fd int) (func(), error) {
+	handle := windows.Handle(fd)
+	var mode uint32
+	if err := windows.GetConsoleMode(handle, &mode); err != nil {
+		// コンソールではない (パイプ等)
+		return func() {}, nil //nolint:nilerr // コンソールでなければエコーの制御は不要
+	}
+
+	if err := windows.SetConsoleMode(handle, mode&^windows.ENABLE_ECHO_INPUT); err != nil {
+		return nil, fmt.Errorf("エコーの無効化に失敗しました: %w", err)
+	}
+	return func() {
+		_ = windows.SetConsoleMode(handle, mode)
+	}, nil
+}
@@ -0,0 +1,222 @@
+package credential
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+// KeyEnv は認証情報の暗号化に使うパスフレーズを格納する環境変数の名前です。
+const KeyEnv = "ARCHON_CREDENTIAL_KEY"
+
+const (
+	// storeFile は暗号化済みの認証情報を保存するファイル名です。
+	storeFile = "credentials.yaml"
+	// pbkdf2Iterations はパスフレーズから鍵を導出する際の反復回数です。
+	pbkdf2Iterations = 600_000
+	// keyLength は AES-256 の鍵の長さです。
+	keyLength = 32
+	// saltLength はエントリごとのソルトの長さです。
+	saltLength = 16
+)
+
+// Store 暗号化済みの認証情報のストア
+// 認証情報はエントリごとにソルトを変えた AES-256-GCM で暗号化して保存します。
+type Store struct {
+	path string
+}
+
+// entry はストアに保存する1件分の認証情報です。
+// バイト列は base64 で保存します。
+type entry struct {
+	Salt       string `yaml:"salt"`
+	Nonce      string `yaml:"nonce"`
+	Ciphertext string `yaml:"ciphertext"`
+}
+
+// NewStore Storeのインスタンスを生成する
+// dir 以下の credentials.yaml に保存します。
+func NewStore(dir string) *Store {
+	return &Store{path: filepath.Join(dir, storeFile)}
+}
+
+// Set は name に secret を暗号化して保存します。既に存在する場合は上書きします。
+func (s *Store) Set(name, secret, passphrase string) error {
+	if passphrase == "" {
+		return fmt.Errorf("パスフレーズが空です")
+	}
+
+	entries, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("ソルトの生成に失敗しました: %w", err)
+	}
+	gcm, err := newGCM(passphrase, salt)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("nonceの生成に失敗しました: %w", err)
+	}
+
+	entries[name] = entry{
+		Salt:       base64.StdEncoding.EncodeToString(salt),
+		Nonce:      base64.StdEncoding.EncodeToString(nonce),
+		Ciphertext: base64.StdEncoding.EncodeToString(gcm.Seal(nil, nonce, []byte(secret), []byte(name))),
+	}
+	return s.save(entries)
+}
+
+// Get は name の認証情報を復号して返します。
+func (s *Store) Get(name, passphrase string) (string, error) {
+	if passphrase == "" {
+		return "", fmt.Errorf("認証情報 %s を復号するには、環境変数 %s にパスフレーズを設定してください", name, KeyEnv)
+	}
+
+	entries, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	e, ok := entries[name]
+	if !ok {
+		return "", fmt.Errorf("認証情報 %s が見つかりません。archon credentials set で保存してください", name)
+	}
+
+	salt, saltErr := base64.StdEncoding.DecodeString(e.Salt)
+	nonce, nonceErr := base64.StdEncoding.DecodeString(e.Nonce)
+	ciphertext, ctErr := base64.StdEncoding.DecodeString(e.Ciphertext)
+	if err := errors.Join(saltErr, nonceErr, ctErr); err != nil {
+		return "", fmt.Errorf("認証情報 %s が破損しています: %w", name, err)
+	}
+
+	gcm, err := newGCM(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+	plain, err := gcm.Open(nil, nonce, ciphertext, []byte(name))
+	if err != nil {
+		return "", fmt.Errorf("認証情報 %s の復号に失敗しました。パスフレーズを確認してください", name)
+	}
+	return string(plain), nil
+}
+
+// Delete は name の認証情報を削除します。
+func (s *Store) Delete(name string) error {
+	entries, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := entries[name]; !ok {
+		return fmt.Errorf("認証情報 %s が見つかりません", name)
+	}
+	delete(entries, name)
+	return s.save(entries)
+}
+
+// List は保存されている認証情報の名前を返します。
+func (s *Store) List() ([]string, error) {
+	entries, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Password は login の設定に従ってパスワードを取得します。
+// password_env, password_file, credential の順に探し、いずれも未設定の場合は空文字列を返します。
+func (s *Store) Password(login *domain.SteamLoginConfig) (string, error) {
+	switch {
+	case login.PasswordEnv != "":
+		password, ok := os.LookupEnv(login.PasswordEnv)
+		if !ok || password == "" {
+			return "", fmt.Errorf("環境変数 %s が設定されていません", login.PasswordEnv)
+		}
+		return password, nil
+	case login.PasswordFile != "":
+		data, err := os.ReadFile(login.PasswordFile)
+		if err != nil {
+			return "", fmt.Errorf("パスワードファイル %s の読み込みに失敗しました: %w", login.PasswordFile, err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	case login.Credential != "":
+		return s.Get(login.Credential, os.Getenv(KeyEnv))
+	default:
+		return "", nil
+	}
+}
+
+// newGCM パスフレーズとソルトから AES-256-GCM を生成する
+func newGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, pbkdf2Iterations, keyLength)
+	if err != nil {
+		return nil, fmt.Errorf("鍵の導出に失敗しました: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("暗号化の初期化に失敗しました: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("暗号化の初期化に失敗しました: %w", err)
+	}
+	return gcm, nil
+}
+
+// load ストアを読み込む ファイルが存在しない場合は空のストアを返す
+func (s *Store) load() (map[string]entry, error) {
+	entries := make(map[string]entry)
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("認証情報ストアの読み込みに失敗しました: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("認証情報ストア %s のパースに失敗しました: %w", s.path, err)
+	}
+	return entries, nil
+}
+
+// save ストアを保存する 所有者のみ読み書きできる権限で書き込む
+func (s *Store) save(entries map[string]entry) error {
+	data, err := yaml.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("認証情報ストアのシリアライズに失敗しました: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("認証情報ストアのディレクトリの作成に失敗しました: %w", err)
+	}
+
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return fmt.Errorf("認証情報ストアの書き込みに失敗しました: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("認証情報ストアの書き込みに失敗しました: %w", err)
+	}
+	return nil
+}
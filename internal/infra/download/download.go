@@ -0,0 +1,109 @@
+package download
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// partialSuffix はダウンロード途中のファイルに付与する拡張子です。
+const partialSuffix = ".partial"
+
+// userAgent はAPIへのリクエストに付与する User-Agent です。PaperMCのAPIなど、識別可能な User-Agent を求めるAPIがあります。
+const userAgent = "grimoire-archon (https://github.com/nonuplet/grimoire-archon)"
+
+// Downloader HTTP(S)によるファイルのダウンロード
+type Downloader struct {
+	client *http.Client
+}
+
+// NewDownloader Downloaderのインスタンスを生成する
+func NewDownloader() *Downloader {
+	return &Downloader{client: http.DefaultClient}
+}
+
+// Download は url の内容を dst に保存します。
+// ダウンロード途中のファイルが残らないよう、完了してから dst にリネームします。
+func (d *Downloader) Download(ctx context.Context, url, dst string) (err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return fmt.Errorf("リクエストの作成に失敗しました: %w", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s のダウンロードに失敗しました: %w", url, err)
+	}
+	defer func(body io.ReadCloser) {
+		if err := body.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "レスポンスのクローズに失敗しました: %v\n", err)
+		}
+	}(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s のダウンロードに失敗しました: %s", url, resp.Status)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return fmt.Errorf("保存先ディレクトリの作成に失敗しました: %w", err)
+	}
+
+	tmpPath := dst + partialSuffix
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("保存先ファイルの作成に失敗しました: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rmErr := os.Remove(tmpPath); rmErr != nil && !os.IsNotExist(rmErr) {
+				fmt.Fprintf(os.Stderr, "ダウンロード途中のファイルの削除に失敗しました: %v\n", rmErr)
+			}
+		}
+	}()
+
+	_, copyErr := io.Copy(out, resp.Body)
+	if closeErr := out.Close(); closeErr != nil && copyErr == nil {
+		copyErr = closeErr
+	}
+	if copyErr != nil {
+		return fmt.Errorf("%s のダウンロードに失敗しました: %w", url, copyErr)
+	}
+
+	if err := os.Rename(tmpPath, dst); err != nil {
+		return fmt.Errorf("ダウンロードしたファイルの保存に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// FetchJSON は url からJSONを取得し、v にデコードします。
+func (d *Downloader) FetchJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return fmt.Errorf("リクエストの作成に失敗しました: %w", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s の取得に失敗しました: %w", url, err)
+	}
+	defer func(body io.ReadCloser) {
+		if err := body.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "レスポンスのクローズに失敗しました: %v\n", err)
+		}
+	}(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s の取得に失敗しました: %s", url, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("%s のパースに失敗しました: %w", url, err)
+	}
+	return nil
+}
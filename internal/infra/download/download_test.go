@@ -0,0 +1,102 @@
+package download_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nonuplet/grimoire-archon/internal/infra/download"
+	"github.com/nonuplet/grimoire-archon/internal/infra/filesystem"
+)
+
+// steamCmdArchive は steamcmd_linux.tar.gz と同じ構成の tar.gz を生成する
+func steamCmdArchive(t *testing.T) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	entries := []struct {
+		header *tar.Header
+		body   string
+	}{
+		{header: &tar.Header{Name: "linux32/", Typeflag: tar.TypeDir, Mode: 0o755}},
+		{header: &tar.Header{Name: "linux32/steamcmd", Typeflag: tar.TypeReg, Mode: 0o755}, body: "\x7fELF"},
+		{header: &tar.Header{Name: "steamcmd.sh", Typeflag: tar.TypeReg, Mode: 0o755}, body: "#!/bin/sh\n"},
+		{header: &tar.Header{Name: "linux32/steamcmd.sh", Typeflag: tar.TypeSymlink, Linkname: "../steamcmd.sh"}},
+	}
+	for _, e := range entries {
+		e.header.Size = int64(len(e.body))
+		if err := tw.WriteHeader(e.header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDownloadAndUntar(t *testing.T) {
+	archive := steamCmdArchive(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/steamcmd_linux.tar.gz" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(archive)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	archivePath := filepath.Join(dir, "steamcmd_linux.tar.gz")
+	if err := download.NewDownloader().Download(context.Background(), server.URL+"/steamcmd_linux.tar.gz", archivePath); err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	if _, err := os.Stat(archivePath + ".partial"); !os.IsNotExist(err) {
+		t.Errorf("ダウンロード途中のファイルが残っています: %v", err)
+	}
+
+	installDir := filepath.Join(dir, "steamcmd")
+	if err := filesystem.NewFileSystem().Untar(context.Background(), archivePath, installDir); err != nil {
+		t.Fatalf("Untar() error = %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(installDir, "steamcmd.sh"))
+	if err != nil {
+		t.Fatalf("steamcmd.sh が展開されていません: %v", err)
+	}
+	if info.Mode().Perm()&0o100 == 0 {
+		t.Errorf("steamcmd.sh の実行権限が維持されていません: %s", info.Mode())
+	}
+	if link, err := os.Readlink(filepath.Join(installDir, "linux32", "steamcmd.sh")); err != nil || link != "../steamcmd.sh" {
+		t.Errorf("Readlink() = %q, %v, want ../steamcmd.sh", link, err)
+	}
+}
+
+func TestDownloadNotFound(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	dst := filepath.Join(t.TempDir(), "steamcmd_linux.tar.gz")
+	if err := download.NewDownloader().Download(context.Background(), server.URL+"/missing.tar.gz", dst); err == nil {
+		t.Fatal("Download() error = nil, want error")
+	}
+	for _, path := range []string{dst, dst + ".partial"} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s が作成されています: %v", path, err)
+		}
+	}
+}
@@ -1,5 +1,11 @@
 package filesystem
 
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
 // FileSystem ファイルシステム操作 (コピー/削除/圧縮/etc...)
 type FileSystem struct{}
 
@@ -7,3 +13,19 @@ type FileSystem struct{}
 func NewFileSystem() *FileSystem {
 	return &FileSystem{}
 }
+
+// ctxReader は ctx がキャンセルされると読み込みを中断する io.Reader です。
+// 大きなファイルのコピー中でも中断できるようにするために使用します。
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+// Read は ctx がキャンセルされていればエラーを、そうでなければ r から読み込んだ結果を返します。
+func (c ctxReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, fmt.Errorf("読み込みを中断しました: %w", err)
+	}
+	n, err := c.r.Read(p)
+	return n, err //nolint:wrapcheck // io.EOF をそのまま返す必要がある
+}
@@ -0,0 +1,186 @@
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// cloneStats は CloneDir で各方法で複製したファイルの数です。
+type cloneStats struct {
+	reflinked  int
+	hardlinked int
+	copied     int
+}
+
+// CloneDir はディレクトリ src を dst に複製します。dst が既に存在する場合はエラーを返します。
+// ファイルは可能であればリフリンク(Copy on Write)で複製し、できない場合は hardlink が true ならハードリンク、false ならコピーします。
+// ハードリンクした場合、dst 側でファイルを直接書き換えると src 側のファイルも変更されます。
+func (f *FileSystem) CloneDir(ctx context.Context, src, dst string, hardlink bool) error {
+	src, err := f.getAbsolutePath(src)
+	if err != nil {
+		return fmt.Errorf("複製元パスの取得: %w", err)
+	}
+	dst, err = f.getAbsolutePath(dst)
+	if err != nil {
+		return fmt.Errorf("複製先パスの取得: %w", err)
+	}
+	if _, err := os.Lstat(dst); err == nil {
+		return fmt.Errorf("複製先がすでに存在します (%s)", dst)
+	}
+
+	var stats cloneStats
+	reflink := true
+	walkErr := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("複製を中断しました: %w", err)
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return fmt.Errorf("相対パスの取得に失敗しました (%s): %w", path, err)
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return fmt.Errorf("ファイル情報の取得に失敗しました (%s): %w", path, err)
+		}
+
+		switch {
+		case d.Type()&os.ModeSymlink != 0:
+			return f.copySymlink(path, target, info, false)
+		case d.IsDir():
+			// 中身を書き込めるように、一旦書き込み可能な権限で作成する。権限は最後に設定する
+			if err := os.MkdirAll(target, info.Mode().Perm()|0o700); err != nil {
+				return fmt.Errorf("ディレクトリの作成に失敗しました (%s): %w", target, err)
+			}
+			return nil
+		}
+
+		// 一度リフリンクに失敗したファイルシステムでは、以降は試さない
+		if reflink {
+			if err := reflinkFile(path, target); err == nil {
+				stats.reflinked++
+				return applyAttributes(target, info)
+			}
+			reflink = false
+		}
+		if hardlink {
+			if err := os.Link(path, target); err == nil {
+				stats.hardlinked++
+				return nil
+			}
+		}
+		stats.copied++
+		return f.copyFile(ctx, path, target, info, false)
+	})
+	if walkErr != nil {
+		return fmt.Errorf("%s の複製に失敗しました: %w", src, walkErr)
+	}
+
+	// ディレクトリの権限と更新日時は、中身の書き込みが終わってから設定する
+	if err := applyDirAttributes(src, dst); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s を複製しました。(リフリンク: %d, ハードリンク: %d, コピー: %d)\n", src, stats.reflinked, stats.hardlinked, stats.copied)
+	return nil
+}
+
+// applyDirAttributes は src 以下のディレクトリの権限と更新日時を、dst 以下の対応するディレクトリに設定します。
+// 子の設定で親の更新日時が変わらないよう、深い階層から設定します。
+func applyDirAttributes(src, dst string) error {
+	var dirs []string
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("ディレクトリの走査に失敗しました (%s): %w", src, err)
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		info, err := os.Stat(dirs[i])
+		if err != nil {
+			return fmt.Errorf("ファイル情報の取得に失敗しました (%s): %w", dirs[i], err)
+		}
+		rel, err := filepath.Rel(src, dirs[i])
+		if err != nil {
+			return fmt.Errorf("相対パスの取得に失敗しました (%s): %w", dirs[i], err)
+		}
+		if err := applyAttributes(filepath.Join(dst, rel), info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rename は src を dst にリネームします。
+func (f *FileSystem) Rename(src, dst string) error {
+	src, err := f.getAbsolutePath(src)
+	if err != nil {
+		return fmt.Errorf("リネーム元パスの取得: %w", err)
+	}
+	dst, err = f.getAbsolutePath(dst)
+	if err != nil {
+		return fmt.Errorf("リネーム先パスの取得: %w", err)
+	}
+
+	if err := os.Rename(src, dst); err != nil {
+		return fmt.Errorf("%s を %s にリネームできませんでした: %w", src, dst, err)
+	}
+	if err := syncDir(filepath.Dir(dst)); err != nil {
+		return fmt.Errorf("リネームの永続化に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// SwapDirs はディレクトリ a と b を入れ替えます。
+// 対応している環境では1回の操作で入れ替え、途中でどちらかのパスが存在しない状態になりません。
+func (f *FileSystem) SwapDirs(a, b string) error {
+	a, err := f.getAbsolutePath(a)
+	if err != nil {
+		return fmt.Errorf("入れ替え元パスの取得: %w", err)
+	}
+	b, err = f.getAbsolutePath(b)
+	if err != nil {
+		return fmt.Errorf("入れ替え先パスの取得: %w", err)
+	}
+
+	if err := exchange(a, b); err != nil {
+		return fmt.Errorf("%s と %s の入れ替えに失敗しました: %w", a, b, err)
+	}
+	if err := syncDir(filepath.Dir(a)); err != nil {
+		return fmt.Errorf("入れ替えの永続化に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// exchangeByRename は一時的な名前を経由した3回のリネームで a と b を入れ替えます。
+// 原子的な入れ替えに対応していない環境で使用します。
+func exchangeByRename(a, b string) error {
+	tmp := a + ".swap"
+	if err := os.Rename(a, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(b, a); err != nil {
+		if rbErr := os.Rename(tmp, a); rbErr != nil {
+			return fmt.Errorf("%w (元に戻せませんでした。%s を %s に戻してください: %w)", err, tmp, a, rbErr)
+		}
+		return err
+	}
+	if err := os.Rename(tmp, b); err != nil {
+		return fmt.Errorf("%w (%s を %s にリネームしてください)", err, tmp, b)
+	}
+	return nil
+}
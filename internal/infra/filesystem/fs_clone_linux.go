@@ -0,0 +1,51 @@
+//go:build linux
+
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// reflinkFile は src を dst にリフリンク(FICLONE)で複製します。
+// Btrfs, XFS など対応しているファイルシステムでのみ成功します。失敗した場合は dst を残しません。
+func reflinkFile(src, dst string) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func(in *os.File) {
+		if closeErr := in.Close(); closeErr != nil {
+			fmt.Fprintf(os.Stderr, "複製元のクローズに失敗しました %v", closeErr)
+		}
+	}(in)
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	cloneErr := unix.IoctlFileClone(int(out.Fd()), int(in.Fd()))
+	if closeErr := out.Close(); closeErr != nil && cloneErr == nil {
+		cloneErr = closeErr
+	}
+	if cloneErr != nil {
+		if rmErr := os.Remove(dst); rmErr != nil {
+			return errors.Join(cloneErr, rmErr)
+		}
+		return cloneErr
+	}
+	return nil
+}
+
+// exchange は renameat2(RENAME_EXCHANGE) で a と b を原子的に入れ替えます。
+// ファイルシステムが対応していない場合は、リネームを組み合わせて入れ替えます。
+func exchange(a, b string) error {
+	err := unix.Renameat2(unix.AT_FDCWD, a, unix.AT_FDCWD, b, unix.RENAME_EXCHANGE)
+	if errors.Is(err, unix.ENOSYS) || errors.Is(err, unix.EINVAL) {
+		return exchangeByRename(a, b)
+	}
+	return err
+}
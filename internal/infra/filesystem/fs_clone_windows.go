@@ -0,0 +1,15 @@
+//go:build windows
+
+package filesystem
+
+import "errors"
+
+// reflinkFile は Windows ではリフリンクに対応していないため、常に失敗します。
+func reflinkFile(_, _ string) error {
+	return errors.ErrUnsupported
+}
+
+// exchange は Windows では原子的な入れ替えができないため、リネームを組み合わせて入れ替えます。
+func exchange(a, b string) error {
+	return exchangeByRename(a, b)
+}
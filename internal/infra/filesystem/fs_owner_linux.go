@@ -0,0 +1,27 @@
+//go:build linux
+
+package filesystem
+
+import (
+	"os"
+	"syscall"
+)
+
+// canChown は所有者情報を保存・復元できるか(root権限で実行しているか)を返します。
+func canChown() bool {
+	return os.Geteuid() == 0
+}
+
+// getOwner は FileInfo から uid, gid を取得します。取得できない場合は ok が false になります。
+func getOwner(info os.FileInfo) (uid, gid int, ok bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+	return int(stat.Uid), int(stat.Gid), true
+}
+
+// setOwner はパスの所有者を設定します。シンボリックリンクはリンク自体の所有者を変更します。
+func setOwner(path string, uid, gid int) error {
+	return os.Lchown(path, uid, gid)
+}
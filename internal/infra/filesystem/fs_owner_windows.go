@@ -0,0 +1,22 @@
+//go:build windows
+
+package filesystem
+
+import (
+	"os"
+)
+
+// canChown は所有者情報を保存・復元できるかを返します。Windowsでは常に false です。
+func canChown() bool {
+	return false
+}
+
+// getOwner は Windows では所有者情報を取得できないため、常に ok が false になります。
+func getOwner(_ os.FileInfo) (uid, gid int, ok bool) {
+	return 0, 0, false
+}
+
+// setOwner は Windows では何もしません。
+func setOwner(_ string, _, _ int) error {
+	return nil
+}
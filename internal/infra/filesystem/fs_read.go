@@ -1,8 +1,12 @@
 package filesystem
 
 import (
+	"encoding/hex"
 	"fmt"
+	"hash"
+	"io"
 	"os"
+	"path/filepath"
 	"time"
 )
 
@@ -14,7 +18,7 @@ func (f *FileSystem) Stat(path string) (os.FileInfo, error) {
 	}
 	info, err := os.Stat(path)
 	if err != nil {
-		return nil, fmt.Errorf("%s が見つかりません", path)
+		return nil, fmt.Errorf("%s が見つかりません: %w", path, err)
 	}
 
 	return info, nil
@@ -43,13 +47,102 @@ func (f *FileSystem) ReadFile(path string) ([]byte, error) {
 
 	file, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("ファイル %s の読み込みに失敗しました: %w", file, err)
+		return nil, fmt.Errorf("ファイル %s の読み込みに失敗しました: %w", path, err)
 	}
 
 	return file, nil
 }
 
+// Checksum は h でファイルのハッシュ値を計算し、16進数の文字列で返します。
+func (f *FileSystem) Checksum(path string, h hash.Hash) (string, error) {
+	path, err := f.getAbsolutePath(path)
+	if err != nil {
+		return "", fmt.Errorf("絶対パスの取得に失敗しました: %w", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("ファイル %s を開けませんでした: %w", path, err)
+	}
+	defer func(file *os.File) {
+		if err := file.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "ファイル %s のクローズに失敗しました: %v\n", path, err)
+		}
+	}(file)
+
+	if _, err := io.Copy(h, file); err != nil {
+		return "", fmt.Errorf("ファイル %s の読み込みに失敗しました: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
 // GetTimestamp タイムスタンプの取得
 func (f *FileSystem) GetTimestamp() string {
 	return time.Now().Format("20060102_150405")
 }
+
+// ListExtraFiles は dst 以下に存在し、src 以下に存在しないファイル/ディレクトリのパスを返します。
+// ディレクトリごと存在しない場合は、そのディレクトリのみを返します(中身は列挙しません)。
+// src ではファイルとディレクトリが入れ替わっているパスは上書きできないため、replaced として別に返します。
+// src または dst がディレクトリでない場合は空のリストを返します。
+func (f *FileSystem) ListExtraFiles(src, dst string) (replaced, extras []string, err error) {
+	src, err = f.getAbsolutePath(src)
+	if err != nil {
+		return nil, nil, fmt.Errorf("比較元パスの取得: %w", err)
+	}
+	dst, err = f.getAbsolutePath(dst)
+	if err != nil {
+		return nil, nil, fmt.Errorf("比較先パスの取得: %w", err)
+	}
+
+	srcInfo, err := os.Stat(src)
+	if err != nil || !srcInfo.IsDir() {
+		return nil, nil, nil
+	}
+	dstInfo, err := os.Stat(dst)
+	if err != nil || !dstInfo.IsDir() {
+		return nil, nil, nil
+	}
+
+	return listExtraFiles(src, dst)
+}
+
+// listExtraFiles は ListExtraFiles の再帰処理です。
+func listExtraFiles(src, dst string) (replaced, extras []string, err error) {
+	entries, err := os.ReadDir(dst)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ディレクトリ '%s' の読み取りに失敗しました: %w", dst, err)
+	}
+
+	for _, entry := range entries {
+		srcPath := filepath.Join(src, entry.Name())
+		dstPath := filepath.Join(dst, entry.Name())
+
+		srcInfo, err := os.Lstat(srcPath)
+		if os.IsNotExist(err) {
+			extras = append(extras, dstPath)
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s の情報取得に失敗しました: %w", srcPath, err)
+		}
+
+		// ファイルとディレクトリが入れ替わっている場合は、上書きできないので削除対象とする
+		if entry.IsDir() != srcInfo.IsDir() {
+			replaced = append(replaced, dstPath)
+			continue
+		}
+		if !entry.IsDir() {
+			continue
+		}
+
+		subReplaced, subExtras, err := listExtraFiles(srcPath, dstPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		replaced = append(replaced, subReplaced...)
+		extras = append(extras, subExtras...)
+	}
+
+	return replaced, extras, nil
+}
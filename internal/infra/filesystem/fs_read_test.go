@@ -0,0 +1,51 @@
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// makeTree は root の下に paths のファイルを作成する。/ で終わるパスはディレクトリとして作成する
+func makeTree(t *testing.T, root string, paths ...string) {
+	t.Helper()
+
+	for _, path := range paths {
+		full := filepath.Join(root, filepath.FromSlash(path))
+		if path[len(path)-1] == '/' {
+			if err := os.MkdirAll(full, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(path), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestListExtraFiles(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	makeTree(t, src, "keep.txt", "sub/keep.txt", "swapped", "dir/")
+	makeTree(t, dst, "keep.txt", "sub/keep.txt", "sub/extra.txt", "extra/a.txt", "swapped/a.txt", "dir")
+
+	replaced, extras, err := NewFileSystem().ListExtraFiles(src, dst)
+	if err != nil {
+		t.Fatalf("ListExtraFiles() error = %v", err)
+	}
+
+	wantReplaced := []string{filepath.Join(dst, "dir"), filepath.Join(dst, "swapped")}
+	wantExtras := []string{filepath.Join(dst, "extra"), filepath.Join(dst, "sub", "extra.txt")}
+	slices.Sort(replaced)
+	slices.Sort(extras)
+	if !slices.Equal(replaced, wantReplaced) {
+		t.Errorf("ListExtraFiles() replaced = %v, want %v", replaced, wantReplaced)
+	}
+	if !slices.Equal(extras, wantExtras) {
+		t.Errorf("ListExtraFiles() extras = %v, want %v", extras, wantExtras)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // ClearDirectoryContents ディレクトリ内のすべてのファイルを削除
@@ -33,7 +34,50 @@ func (f *FileSystem) RemoveAll(path string) error {
 	}
 
 	if err := os.RemoveAll(path); err != nil {
-		return fmt.Errorf("%s の削除に失敗しました: %w", path, err)
+		// 読み取り専用のディレクトリが含まれていると削除できないため、書き込み権限を付けて再試行する
+		makeDirsWritable(path)
+		if err := os.RemoveAll(path); err != nil {
+			return fmt.Errorf("%s の削除に失敗しました: %w", path, err)
+		}
 	}
 	return nil
 }
+
+// makeDirsWritable は path 以下のディレクトリに所有者の書き込み権限を付与します。
+// 削除の前処理として使うため、エラーは無視します。
+func makeDirsWritable(path string) {
+	_ = filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			if info, infoErr := d.Info(); infoErr == nil {
+				_ = os.Chmod(p, info.Mode().Perm()|0o700)
+			}
+		}
+		return nil
+	})
+}
+
+// RemovePartialFiles は dir 直下の書き込み途中のファイル(PartialSuffix 付きのファイル)を削除し、削除したパスを返します。
+func (f *FileSystem) RemovePartialFiles(dir string) ([]string, error) {
+	dir, err := f.getAbsolutePath(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%s のパス取得に失敗しました: %w", dir, err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("ディレクトリの読み取りに失敗しました: %w", err)
+	}
+
+	var removed []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), PartialSuffix) {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if err := os.Remove(path); err != nil {
+			return removed, fmt.Errorf("%s の削除に失敗しました: %w", path, err)
+		}
+		removed = append(removed, path)
+	}
+	return removed, nil
+}
@@ -0,0 +1,26 @@
+//go:build linux
+
+package filesystem
+
+import (
+	"fmt"
+	"os"
+)
+
+// syncDir はディレクトリを fsync し、ディレクトリ内のリネーム・作成を永続化します。
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("ディレクトリ %s を開けませんでした: %w", path, err)
+	}
+	defer func(dir *os.File) {
+		if closeErr := dir.Close(); closeErr != nil {
+			fmt.Fprintf(os.Stderr, "ディレクトリ %s のクローズに失敗しました: %v\n", path, closeErr)
+		}
+	}(dir)
+
+	if err := dir.Sync(); err != nil {
+		return fmt.Errorf("fsync: %w", err)
+	}
+	return nil
+}
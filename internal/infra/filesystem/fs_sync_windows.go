@@ -0,0 +1,9 @@
+//go:build windows
+
+package filesystem
+
+// syncDir は Windows ではディレクトリの fsync ができないため、何もしません。
+// NTFS ではリネームはメタデータのジャーナルで保護されます。
+func syncDir(_ string) error {
+	return nil
+}
@@ -0,0 +1,150 @@
+package filesystem
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Untar は tar または tar.gz ファイルを dstDir に展開します。
+// 通常のファイル, ディレクトリ, シンボリックリンクに対応し、パーミッションを維持します。
+func (f *FileSystem) Untar(ctx context.Context, tarFilePath, dstDir string) error {
+	// 絶対パスへ変換
+	tarFilePath, err := f.getAbsolutePath(tarFilePath)
+	if err != nil {
+		return fmt.Errorf("tarファイルパスの取得エラー: %w", err)
+	}
+	dstDir, err = f.getAbsolutePath(dstDir)
+	if err != nil {
+		return fmt.Errorf("展開先ディレクトリパスの取得エラー: %w", err)
+	}
+
+	file, err := os.Open(tarFilePath)
+	if err != nil {
+		return fmt.Errorf("tarファイルを開けませんでした: %w", err)
+	}
+	defer func(file *os.File) {
+		if err := file.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "tarファイル %s のクローズに失敗しました: %v\n", tarFilePath, err)
+		}
+	}(file)
+
+	// gzip のマジックナンバーで圧縮の有無を判定する
+	br := bufio.NewReader(ctxReader{ctx: ctx, r: file})
+	var r io.Reader = br
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return fmt.Errorf("gzipの読み込みに失敗しました: %w", err)
+		}
+		defer func(gz *gzip.Reader) {
+			if err := gz.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "gzipのクローズに失敗しました: %v\n", err)
+			}
+		}(gz)
+		r = gz
+	}
+
+	if err := os.MkdirAll(dstDir, 0o755); err != nil {
+		return fmt.Errorf("展開先ディレクトリの作成に失敗しました: %w", err)
+	}
+
+	tr := tar.NewReader(r)
+	var written uint64
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("tarファイルの読み込みに失敗しました: %w", err)
+		}
+
+		n, err := extractTarEntry(dstDir, header, tr)
+		if err != nil {
+			return err
+		}
+
+		// Zip Bomb と同様に、展開サイズの上限を設定
+		written += uint64(n) // nolint:gosec // G115 n is non-negative
+		if written > maxDecompressLimit {
+			return fmt.Errorf("展開サイズが上限を超えました: %s", tarFilePath)
+		}
+	}
+}
+
+// extractTarEntry は tar のエントリを1つ展開し、書き込んだバイト数を返します。
+func extractTarEntry(dstDir string, header *tar.Header, r io.Reader) (int64, error) {
+	fpath := filepath.Join(dstDir, filepath.Clean(header.Name))
+
+	// Tar Slip 対策：展開先パスが指定ディレクトリ内にあるか確認
+	if !isWithinDir(dstDir, fpath) {
+		return 0, fmt.Errorf("不正なファイルパスを検出しました (Tar Slip対策): %s", fpath)
+	}
+	if err := checkNoSymlinkParent(dstDir, fpath); err != nil {
+		return 0, err
+	}
+
+	mode := os.FileMode(header.Mode).Perm() // nolint:gosec // G115 permission bits only
+
+	switch header.Typeflag {
+	case tar.TypeDir:
+		if err := os.MkdirAll(fpath, 0o755); err != nil {
+			return 0, fmt.Errorf("展開先ディレクトリの作成に失敗しました: %w", err)
+		}
+		return 0, nil
+
+	case tar.TypeSymlink:
+		// リンク先が展開先の外を指す場合は展開しない
+		target := header.Linkname
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(fpath), target)
+		}
+		if !isWithinDir(dstDir, target) {
+			return 0, fmt.Errorf("%w: %s -> %s", ErrExternalSymlink, header.Name, header.Linkname)
+		}
+		if err := os.MkdirAll(filepath.Dir(fpath), 0o755); err != nil {
+			return 0, fmt.Errorf("展開先ディレクトリの作成に失敗しました: %w", err)
+		}
+		if err := os.RemoveAll(fpath); err != nil {
+			return 0, fmt.Errorf("既存ファイルの削除に失敗しました (%s): %w", fpath, err)
+		}
+		if err := os.Symlink(header.Linkname, fpath); err != nil {
+			return 0, fmt.Errorf("シンボリックリンクの作成に失敗しました: %w", err)
+		}
+		return 0, nil
+
+	case tar.TypeReg:
+		if err := os.MkdirAll(filepath.Dir(fpath), 0o755); err != nil {
+			return 0, fmt.Errorf("展開先ディレクトリの作成に失敗しました: %w", err)
+		}
+		// 既存のファイル, シンボリックリンクは先に削除する (リンク経由の書き込みを防ぐ)
+		if err := os.RemoveAll(fpath); err != nil {
+			return 0, fmt.Errorf("既存ファイルの削除に失敗しました (%s): %w", fpath, err)
+		}
+
+		out, err := os.OpenFile(fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+		if err != nil {
+			return 0, fmt.Errorf("展開先ファイルの作成に失敗しました: %w", err)
+		}
+		n, copyErr := io.Copy(out, r)
+		if err := out.Close(); err != nil && copyErr == nil {
+			copyErr = err
+		}
+		if copyErr != nil {
+			return n, fmt.Errorf("%s の展開に失敗しました: %w", header.Name, copyErr)
+		}
+		return n, nil
+
+	default:
+		// デバイスファイル等は展開しない
+		fmt.Fprintf(os.Stderr, "未対応のエントリをスキップしました: %s\n", header.Name)
+		return 0, nil
+	}
+}
@@ -0,0 +1,76 @@
+package filesystem
+
+import (
+	"archive/tar"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTar は entries の tar ファイルを作成する
+func writeTar(t *testing.T, path string, entries []*tar.Header) {
+	t.Helper()
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	tw := tar.NewWriter(file)
+	for _, header := range entries {
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if header.Size > 0 {
+			if _, err := tw.Write([]byte(strings.Repeat("x", int(header.Size)))); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUntarRejectsEscapes(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []*tar.Header
+	}{
+		{
+			name:    "parent path",
+			entries: []*tar.Header{{Name: "../evil", Typeflag: tar.TypeReg, Mode: 0o644, Size: 1}},
+		},
+		{
+			name:    "absolute symlink",
+			entries: []*tar.Header{{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "/etc"}},
+		},
+		{
+			name:    "relative symlink",
+			entries: []*tar.Header{{Name: "dir/link", Typeflag: tar.TypeSymlink, Linkname: "../../outside"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			archive := filepath.Join(dir, "archive.tar")
+			writeTar(t, archive, tt.entries)
+
+			dst := filepath.Join(dir, "dst")
+			if err := NewFileSystem().Untar(context.Background(), archive, dst); err == nil {
+				t.Fatal("Untar() error = nil, want error")
+			}
+			if _, err := os.Lstat(filepath.Join(dir, "evil")); !os.IsNotExist(err) {
+				t.Errorf("展開先の外にファイルが作成されています: %v", err)
+			}
+		})
+	}
+}
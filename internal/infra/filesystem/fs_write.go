@@ -1,6 +1,7 @@
 package filesystem
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -35,10 +36,30 @@ func (f *FileSystem) WriteFile(path string, data []byte, perm os.FileMode) error
 	return nil
 }
 
+// Symlink は link に target へのシンボリックリンクを作成します。
+// Windowsでは管理者権限または開発者モードが必要です。
+func (f *FileSystem) Symlink(target, link string) error {
+	target, err := f.getAbsolutePath(target)
+	if err != nil {
+		return fmt.Errorf("リンク先 (%s) のパス取得に失敗しました: %w", target, err)
+	}
+	link, err = f.getAbsolutePath(link)
+	if err != nil {
+		return fmt.Errorf("リンク (%s) のパス取得に失敗しました: %w", link, err)
+	}
+
+	if err := os.Symlink(target, link); err != nil {
+		return fmt.Errorf("シンボリックリンク (%s -> %s) の作成に失敗しました: %w", link, target, err)
+	}
+	return nil
+}
+
 // CopyFileOrDir ファイルまたはディレクトリをコピー。
 // overwrite が true の場合、コピー先が既に存在しても上書きする。
 // overwrite が false の場合、コピー先が既に存在するとエラーを返す。
-func (f *FileSystem) CopyFileOrDir(src, dst string, overwrite bool) error {
+// パーミッション, 更新日時, シンボリックリンク(ディレクトリ内のもの)を保持します。root権限で実行している場合は所有者も保持します。
+// ctx がキャンセルされた場合、ファイル単位でコピーを中断します。
+func (f *FileSystem) CopyFileOrDir(ctx context.Context, src, dst string, overwrite bool) error {
 	// 絶対パスに変換
 	src, err := f.getAbsolutePath(src)
 	if err != nil {
@@ -57,13 +78,13 @@ func (f *FileSystem) CopyFileOrDir(src, dst string, overwrite bool) error {
 	if info.IsDir() {
 		// Directory
 		fmt.Printf("ディレクトリをコピー中: %s -> %s\n", src, dst)
-		if err := f.copyDir(src, dst, overwrite); err != nil {
+		if err := f.copyDir(ctx, src, dst, info, overwrite); err != nil {
 			return fmt.Errorf("ディレクトリのコピーに失敗しました: %w", err)
 		}
 	} else {
 		// File
 		fmt.Printf("ファイルをコピー中: %s -> %s\n", src, dst)
-		if err := f.copyFile(src, dst, overwrite); err != nil {
+		if err := f.copyFile(ctx, src, dst, info, overwrite); err != nil {
 			return fmt.Errorf("ファイルのコピーに失敗しました: %w", err)
 		}
 	}
@@ -72,40 +93,64 @@ func (f *FileSystem) CopyFileOrDir(src, dst string, overwrite bool) error {
 
 // copyDir はディレクトリ src を dst へ再帰的にコピーします。
 // overwrite が false のとき、既存ファイルはスキップせずエラーを返します。
-func (f *FileSystem) copyDir(src, dst string, overwrite bool) error {
+func (f *FileSystem) copyDir(ctx context.Context, src, dst string, srcInfo os.FileInfo, overwrite bool) error {
 	entries, err := os.ReadDir(src)
 	if err != nil {
 		return fmt.Errorf("ディレクトリの読み込みに失敗しました (%s): %w", src, err)
 	}
 
-	if err := os.MkdirAll(dst, 0o755); err != nil {
+	// 中身を書き込めるように、一旦書き込み可能な権限で作成する。権限は最後に設定する
+	if err := os.MkdirAll(dst, 0o700); err != nil {
 		return fmt.Errorf("ディレクトリの作成に失敗しました (%s): %w", dst, err)
 	}
+	if err := os.Chmod(dst, srcInfo.Mode().Perm()|0o700); err != nil {
+		return fmt.Errorf("ディレクトリの権限設定に失敗しました (%s): %w", dst, err)
+	}
 
 	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("コピーを中断しました: %w", err)
+		}
+
 		srcPath := filepath.Join(src, entry.Name())
 		dstPath := filepath.Join(dst, entry.Name())
 
-		if entry.IsDir() {
-			if err := f.copyDir(srcPath, dstPath, overwrite); err != nil {
+		info, err := entry.Info()
+		if err != nil {
+			return fmt.Errorf("ファイル情報の取得に失敗しました (%s): %w", srcPath, err)
+		}
+
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			if err := f.copySymlink(srcPath, dstPath, info, overwrite); err != nil {
+				return err
+			}
+		case info.IsDir():
+			if err := f.copyDir(ctx, srcPath, dstPath, info, overwrite); err != nil {
 				return err
 			}
-		} else {
-			if err := f.copyFile(srcPath, dstPath, overwrite); err != nil {
+		default:
+			if err := f.copyFile(ctx, srcPath, dstPath, info, overwrite); err != nil {
 				return err
 			}
 		}
 	}
-	return nil
+
+	// 中身の書き込みが終わってから権限と更新日時を設定する
+	return applyAttributes(dst, srcInfo)
 }
 
 // copyFile はファイル src を dst へコピーします。
 // overwrite が false のとき、dst が既に存在する場合はエラーを返します。
-func (f *FileSystem) copyFile(src, dst string, overwrite bool) error {
-	if !overwrite {
-		if _, err := os.Stat(dst); err == nil {
+func (f *FileSystem) copyFile(ctx context.Context, src, dst string, srcInfo os.FileInfo, overwrite bool) error {
+	if _, err := os.Lstat(dst); err == nil {
+		if !overwrite {
 			return fmt.Errorf("コピー先がすでに存在します (%s)", dst)
 		}
+		// 読み取り専用ファイルやシンボリックリンクを経由した書き込みを避けるため、先に削除する
+		if err := os.Remove(dst); err != nil {
+			return fmt.Errorf("コピー先の削除に失敗しました (%s): %w", dst, err)
+		}
 	}
 
 	in, err := os.Open(src)
@@ -123,7 +168,7 @@ func (f *FileSystem) copyFile(src, dst string, overwrite bool) error {
 		return fmt.Errorf("親ディレクトリの作成に失敗しました (%s): %w", filepath.Dir(dst), mkdirErr)
 	}
 
-	out, err := os.Create(dst)
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, srcInfo.Mode().Perm()|0o200)
 	if err != nil {
 		return fmt.Errorf("ファイルの作成に失敗しました (%s): %w", dst, err)
 	}
@@ -134,8 +179,62 @@ func (f *FileSystem) copyFile(src, dst string, overwrite bool) error {
 		}
 	}(out)
 
-	if _, err := io.Copy(out, in); err != nil {
+	if _, err := io.Copy(out, ctxReader{ctx: ctx, r: in}); err != nil {
 		return fmt.Errorf("ファイルのコピーに失敗しました (%s -> %s): %w", src, dst, err)
 	}
+
+	return applyAttributes(dst, srcInfo)
+}
+
+// copySymlink はシンボリックリンク src を、リンクのまま dst へコピーします。
+// overwrite が false のとき、dst が既に存在する場合はエラーを返します。
+func (f *FileSystem) copySymlink(src, dst string, srcInfo os.FileInfo, overwrite bool) error {
+	target, err := os.Readlink(src)
+	if err != nil {
+		return fmt.Errorf("シンボリックリンクの読み取りに失敗しました (%s): %w", src, err)
+	}
+
+	if dstInfo, err := os.Lstat(dst); err == nil {
+		if !overwrite {
+			return fmt.Errorf("コピー先がすでに存在します (%s)", dst)
+		}
+		if dstInfo.IsDir() {
+			return fmt.Errorf("コピー先がディレクトリのため、シンボリックリンクで置き換えられません (%s)", dst)
+		}
+		if err := os.Remove(dst); err != nil {
+			return fmt.Errorf("コピー先の削除に失敗しました (%s): %w", dst, err)
+		}
+	}
+
+	if mkdirErr := os.MkdirAll(filepath.Dir(dst), 0o755); mkdirErr != nil {
+		return fmt.Errorf("親ディレクトリの作成に失敗しました (%s): %w", filepath.Dir(dst), mkdirErr)
+	}
+
+	if err := os.Symlink(target, dst); err != nil {
+		return fmt.Errorf("シンボリックリンクの作成に失敗しました (%s -> %s): %w", dst, target, err)
+	}
+
+	// シンボリックリンクは権限・更新日時を持たないので、所有者のみ設定する
+	if uid, gid, ok := getOwner(srcInfo); ok && canChown() {
+		if err := setOwner(dst, uid, gid); err != nil {
+			return fmt.Errorf("所有者の設定に失敗しました (%s): %w", dst, err)
+		}
+	}
+	return nil
+}
+
+// applyAttributes は path に srcInfo の権限, 更新日時, (root権限の場合は)所有者を設定します。
+func applyAttributes(path string, srcInfo os.FileInfo) error {
+	if uid, gid, ok := getOwner(srcInfo); ok && canChown() {
+		if err := setOwner(path, uid, gid); err != nil {
+			return fmt.Errorf("所有者の設定に失敗しました (%s): %w", path, err)
+		}
+	}
+	if err := os.Chmod(path, srcInfo.Mode().Perm()); err != nil {
+		return fmt.Errorf("権限の設定に失敗しました (%s): %w", path, err)
+	}
+	if err := os.Chtimes(path, srcInfo.ModTime(), srcInfo.ModTime()); err != nil {
+		return fmt.Errorf("更新日時の設定に失敗しました (%s): %w", path, err)
+	}
 	return nil
 }
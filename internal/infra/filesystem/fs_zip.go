@@ -2,13 +2,24 @@ package filesystem
 
 import (
 	"archive/zip"
+	"context"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
+	iofs "io/fs"
 	"os"
 	"path/filepath"
 	"strings"
 )
 
+// PartialSuffix は書き込み途中のzipファイルに付与する拡張子です。
+// 書き込みが完了した時点で最終的なファイル名にリネームします。
+const PartialSuffix = ".partial"
+
+// ErrExternalSymlink はアーカイブに展開先の外を指すシンボリックリンクが含まれていることを示します。
+var ErrExternalSymlink = errors.New("展開先の外を指すシンボリックリンクを検出しました")
+
 const (
 	// ZipBomb検出用: zipファイルのバッファサイズ 10MB
 	bufSize int64 = 10 * 1024 * 1024
@@ -53,7 +64,11 @@ func (f *FileSystem) IsZipFile(path string) bool {
 }
 
 // Zip はdirの内容をzipFilePathに圧縮します。
-func (f *FileSystem) Zip(dir, zipFilePath string) error {
+// 権限, 更新日時を保持し、シンボリックリンクはリンクのまま格納します。
+// root権限で実行している場合は所有者(uid/gid)も格納します。
+// 書き込みは zipFilePath + PartialSuffix に対して行い、fsync の後にリネームします。
+// 途中で失敗・中断した場合に、壊れたzipファイルが正常なバックアップとして残ることはありません。
+func (f *FileSystem) Zip(ctx context.Context, dir, zipFilePath string) error {
 	dir, err := f.getAbsolutePath(dir)
 	if err != nil {
 		return fmt.Errorf("ディレクトリパスの取得: %w", err)
@@ -63,33 +78,153 @@ func (f *FileSystem) Zip(dir, zipFilePath string) error {
 		return fmt.Errorf("zipファイルパスの取得: %w", err)
 	}
 
+	partialPath := zipFilePath + PartialSuffix
+	if err := writeZip(ctx, dir, partialPath); err != nil {
+		if rmErr := os.Remove(partialPath); rmErr != nil && !os.IsNotExist(rmErr) {
+			fmt.Fprintf(os.Stderr, "書き込み途中のzipファイル %s の削除に失敗しました: %v\n", partialPath, rmErr)
+		}
+		return err
+	}
+
+	return commitFile(partialPath, zipFilePath)
+}
+
+// writeZip はdirの内容をzipFilePathに圧縮し、fsyncします。
+func writeZip(ctx context.Context, dir, zipFilePath string) (err error) {
 	file, err := os.Create(zipFilePath)
 	if err != nil {
 		return fmt.Errorf("zipファイル %s の作成に失敗しました: %w", zipFilePath, err)
 	}
 	defer func(f *os.File) {
-		err := f.Close()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "zipファイル %s のクローズに失敗しました: %v\n", zipFilePath, err)
+		if closeErr := f.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("zipファイル %s のクローズに失敗しました: %w", zipFilePath, closeErr)
 		}
 	}(file)
 
 	zw := zip.NewWriter(file)
-	defer func(zw *zip.Writer) {
-		err := zw.Close()
+	walkErr := filepath.WalkDir(dir, func(path string, d iofs.DirEntry, err error) error {
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "zipファイル %s のクローズに失敗しました: %v\n", zipFilePath, err)
+			return err
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return fmt.Errorf("圧縮を中断しました: %w", ctxErr)
+		}
+		if path == dir {
+			return nil
 		}
-	}(zw)
+		return addZipEntry(ctx, zw, dir, path, d)
+	})
+	if walkErr != nil {
+		return fmt.Errorf("add dir %s: %w", dir, walkErr)
+	}
 
-	if err := zw.AddFS(os.DirFS(dir)); err != nil {
-		return fmt.Errorf("add fs %s: %w", dir, err)
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("zipファイル %s の書き込みに失敗しました: %w", zipFilePath, err)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("zipファイル %s の同期に失敗しました: %w", zipFilePath, err)
+	}
+	return nil
+}
+
+// commitFile は書き込みが完了した一時ファイル tmpPath を path にアトミックにリネームします。
+// リネーム後、親ディレクトリを fsync してリネームを永続化します。
+func commitFile(tmpPath, path string) error {
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("ファイル %s のリネームに失敗しました: %w", path, err)
+	}
+	if err := syncDir(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("ディレクトリ %s の同期に失敗しました: %w", filepath.Dir(path), err)
+	}
+	return nil
+}
+
+// addZipEntry は path を zip に1エントリとして追加します。
+func addZipEntry(ctx context.Context, zw *zip.Writer, baseDir, path string, d iofs.DirEntry) error {
+	info, err := d.Info()
+	if err != nil {
+		return fmt.Errorf("ファイル情報の取得に失敗しました (%s): %w", path, err)
+	}
+
+	rel, err := filepath.Rel(baseDir, path)
+	if err != nil {
+		return fmt.Errorf("相対パスの取得に失敗しました (%s): %w", path, err)
+	}
+
+	// FileInfoHeader で権限と更新日時がヘッダに格納される
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return fmt.Errorf("zipヘッダの作成に失敗しました (%s): %w", path, err)
+	}
+	header.Name = filepath.ToSlash(rel)
+	if uid, gid, ok := getOwner(info); ok && canChown() {
+		header.Extra = append(header.Extra, encodeUnixOwner(uid, gid)...)
+	}
+
+	switch {
+	case info.IsDir():
+		header.Name += "/"
+		header.Method = zip.Store
+		if _, err := zw.CreateHeader(header); err != nil {
+			return fmt.Errorf("zipエントリの作成に失敗しました (%s): %w", path, err)
+		}
+		return nil
+
+	case info.Mode()&os.ModeSymlink != 0:
+		// シンボリックリンクはリンク先パスを内容として格納する
+		target, err := os.Readlink(path)
+		if err != nil {
+			return fmt.Errorf("シンボリックリンクの読み取りに失敗しました (%s): %w", path, err)
+		}
+		header.Method = zip.Store
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			return fmt.Errorf("zipエントリの作成に失敗しました (%s): %w", path, err)
+		}
+		if _, err := io.WriteString(w, filepath.ToSlash(target)); err != nil {
+			return fmt.Errorf("zipへの書き込みに失敗しました (%s): %w", path, err)
+		}
+		return nil
+
+	case info.Mode().IsRegular():
+		header.Method = zip.Deflate
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			return fmt.Errorf("zipエントリの作成に失敗しました (%s): %w", path, err)
+		}
+		return copyFileTo(ctx, w, path)
+
+	default:
+		// デバイスファイルやソケット等は対象外
+		fmt.Fprintf(os.Stderr, "通常ファイルではないためスキップします: %s\n", path)
+		return nil
+	}
+}
+
+// copyFileTo はファイル path の内容を w に書き込みます。
+func copyFileTo(ctx context.Context, w io.Writer, path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("ファイルを開けませんでした (%s): %w", path, err)
+	}
+	defer func(in *os.File) {
+		inErr := in.Close()
+		if inErr != nil {
+			fmt.Fprintf(os.Stderr, "ファイルのクローズに失敗しました: %v\n", inErr)
+		}
+	}(in)
+
+	if _, err := io.Copy(w, ctxReader{ctx: ctx, r: in}); err != nil {
+		return fmt.Errorf("zipへの書き込みに失敗しました (%s): %w", path, err)
 	}
 	return nil
 }
 
 // Unzip は zipFilePath を dstDir に展開します。
-func (f *FileSystem) Unzip(zipFilePath, dstDir string) error {
+// 権限, 更新日時, シンボリックリンクを復元します。root権限で実行している場合は所有者も復元します。
+// 展開先の外を指すシンボリックリンクは、allowExternalSymlinks が true の場合のみ展開し、それ以外はエラーを返します。
+// ctx がキャンセルされた場合、エントリ単位で展開を中断します。
+func (f *FileSystem) Unzip(ctx context.Context, zipFilePath, dstDir string, allowExternalSymlinks bool) error {
 	// 絶対パスへ変換
 	zipFilePath, err := f.getAbsolutePath(zipFilePath)
 	if err != nil {
@@ -118,8 +253,24 @@ func (f *FileSystem) Unzip(zipFilePath, dstDir string) error {
 	}
 
 	// ZIP内の各ファイル・ディレクトリを順番に処理
+	// ディレクトリの属性は中身を書き込んだ後に設定するため、後回しにする
+	var dirs []*zip.File
 	for _, file := range r.File {
-		if err := extractAndWriteFile(dstDir, file); err != nil {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("展開を中断しました: %w", err)
+		}
+		if file.FileInfo().IsDir() {
+			dirs = append(dirs, file)
+		}
+		if err := extractAndWriteFile(ctx, dstDir, file, allowExternalSymlinks); err != nil {
+			return err
+		}
+	}
+
+	// 深い階層から順に設定する
+	for i := len(dirs) - 1; i >= 0; i-- {
+		fpath := filepath.Join(dstDir, filepath.Clean(dirs[i].Name))
+		if err := applyZipAttributes(fpath, dirs[i]); err != nil {
 			return err
 		}
 	}
@@ -127,25 +278,174 @@ func (f *FileSystem) Unzip(zipFilePath, dstDir string) error {
 	return nil
 }
 
+// maxZipEntryReadSize は ReadZipEntry で読み込むエントリの最大サイズ 64MB です。
+const maxZipEntryReadSize int64 = 64 * 1024 * 1024
+
+// ReadZipEntry はzipファイル内の name で指定したエントリの内容を読み込みます。
+func (f *FileSystem) ReadZipEntry(zipFilePath, name string) ([]byte, error) {
+	zipFilePath, err := f.getAbsolutePath(zipFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("zipファイルパスの取得エラー: %w", err)
+	}
+
+	r, err := zip.OpenReader(zipFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("ZIPファイルを開けませんでした: %w", err)
+	}
+	defer func(r *zip.ReadCloser) {
+		err := r.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "zipファイル %s のクローズに失敗しました: %v\n", zipFilePath, err)
+		}
+	}(r)
+
+	rc, err := r.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("zip内に %s が見つかりません: %w", name, err)
+	}
+	defer func(rc io.ReadCloser) {
+		rcErr := rc.Close()
+		if rcErr != nil {
+			fmt.Fprintf(os.Stderr, "zip内ファイルのクローズに失敗しました: %v\n", rcErr)
+		}
+	}(rc)
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxZipEntryReadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("zip内の %s の読み込みに失敗しました: %w", name, err)
+	}
+	if int64(len(data)) > maxZipEntryReadSize {
+		return nil, fmt.Errorf("zip内の %s のサイズが大きすぎます", name)
+	}
+	return data, nil
+}
+
+// ListZipEntries はzipファイル内のエントリの名前を一覧で返します。
+func (f *FileSystem) ListZipEntries(zipFilePath string) ([]string, error) {
+	zipFilePath, err := f.getAbsolutePath(zipFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("zipファイルパスの取得エラー: %w", err)
+	}
+
+	r, err := zip.OpenReader(zipFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("ZIPファイルを開けませんでした: %w", err)
+	}
+	defer func(r *zip.ReadCloser) {
+		err := r.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "zipファイル %s のクローズに失敗しました: %v\n", zipFilePath, err)
+		}
+	}(r)
+
+	names := make([]string, 0, len(r.File))
+	for _, file := range r.File {
+		names = append(names, file.Name)
+	}
+	return names, nil
+}
+
+// ReplaceZipEntry はzipファイル内の name で指定したエントリの内容を data に置き換えます。
+// 他のエントリは再圧縮せずにそのままコピーします。
+// 一時ファイルに書き出してから置き換えるため、途中で失敗しても元のzipファイルは壊れません。
+func (f *FileSystem) ReplaceZipEntry(zipFilePath, name string, data []byte) error {
+	zipFilePath, err := f.getAbsolutePath(zipFilePath)
+	if err != nil {
+		return fmt.Errorf("zipファイルパスの取得エラー: %w", err)
+	}
+
+	partialPath := zipFilePath + PartialSuffix
+	if err := rewriteZip(zipFilePath, partialPath, name, data); err != nil {
+		if rmErr := os.Remove(partialPath); rmErr != nil && !os.IsNotExist(rmErr) {
+			fmt.Fprintf(os.Stderr, "書き込み途中のzipファイル %s の削除に失敗しました: %v\n", partialPath, rmErr)
+		}
+		return err
+	}
+
+	return commitFile(partialPath, zipFilePath)
+}
+
+// rewriteZip は src の内容を name のエントリだけ data に置き換えて dst に書き出します。
+func rewriteZip(src, dst, name string, data []byte) (err error) {
+	r, err := zip.OpenReader(src)
+	if err != nil {
+		return fmt.Errorf("ZIPファイルを開けませんでした: %w", err)
+	}
+	defer func(r *zip.ReadCloser) {
+		closeErr := r.Close()
+		if closeErr != nil {
+			fmt.Fprintf(os.Stderr, "zipファイル %s のクローズに失敗しました: %v\n", src, closeErr)
+		}
+	}(r)
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("zipファイル %s の作成に失敗しました: %w", dst, err)
+	}
+	defer func(out *os.File) {
+		if closeErr := out.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("zipファイル %s のクローズに失敗しました: %w", dst, closeErr)
+		}
+	}(out)
+
+	zw := zip.NewWriter(out)
+	found := false
+	for _, file := range r.File {
+		if file.Name != name {
+			if err := zw.Copy(file); err != nil {
+				return fmt.Errorf("zipエントリ %s のコピーに失敗しました: %w", file.Name, err)
+			}
+			continue
+		}
+
+		found = true
+		header := file.FileHeader
+		header.Method = zip.Deflate
+		w, err := zw.CreateHeader(&header)
+		if err != nil {
+			return fmt.Errorf("zipエントリ %s の作成に失敗しました: %w", name, err)
+		}
+		if _, err := w.Write(data); err != nil {
+			return fmt.Errorf("zipエントリ %s の書き込みに失敗しました: %w", name, err)
+		}
+	}
+	if !found {
+		return fmt.Errorf("zip内に %s が見つかりません", name)
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("zipファイル %s の書き込みに失敗しました: %w", dst, err)
+	}
+	if err := out.Sync(); err != nil {
+		return fmt.Errorf("zipファイル %s の同期に失敗しました: %w", dst, err)
+	}
+	return nil
+}
+
 // extractAndWriteFile は単一のファイルを安全に解凍・書き出しします
 // ※ループ内で defer を安全に実行するために関数を分離しています
-func extractAndWriteFile(dstDir string, file *zip.File) error {
+func extractAndWriteFile(ctx context.Context, dstDir string, file *zip.File, allowExternalSymlinks bool) error {
 	// 展開先のフルパスを構築
 	fpath := filepath.Join(dstDir, filepath.Clean(file.Name))
 
 	// Zip Slip脆弱性対策：展開先パスが指定ディレクトリ内にあるか確認
-	if !strings.HasPrefix(fpath, filepath.Clean(dstDir)+string(os.PathSeparator)) {
+	if !isWithinDir(dstDir, fpath) {
 		return fmt.Errorf("不正なファイルパスを検出しました (Zip Slip対策): %s", fpath)
 	}
 
+	// シンボリックリンク経由で展開先の外に書き込まれるのを防ぐ
+	if err := checkNoSymlinkParent(dstDir, fpath); err != nil {
+		return err
+	}
+
 	// Zip Bomb対策: 異常な圧縮率のデータを弾く
 	if isSuspiciousRatio(file) {
 		return fmt.Errorf("圧縮率が異常なファイルを検出しました。zip bombの可能性があります: %s", file.Name)
 	}
 
-	// エントリがディレクトリの場合は作成して終了
+	// エントリがディレクトリの場合は作成して終了 (属性は Unzip の最後に設定する)
 	if file.FileInfo().IsDir() {
-		if err := os.MkdirAll(fpath, os.ModePerm); err != nil {
+		if err := os.MkdirAll(fpath, 0o700); err != nil {
 			return fmt.Errorf("展開先ディレクトリの作成に失敗しました: %w", err)
 		}
 		return nil
@@ -156,6 +456,18 @@ func extractAndWriteFile(dstDir string, file *zip.File) error {
 		return fmt.Errorf("展開先ディレクトリの作成に失敗しました: %w", err)
 	}
 
+	// 既存のファイル, シンボリックリンクは先に削除する (リンク経由の書き込みを防ぐ)
+	if info, err := os.Lstat(fpath); err == nil && !info.IsDir() {
+		if err := os.Remove(fpath); err != nil {
+			return fmt.Errorf("既存ファイルの削除に失敗しました (%s): %w", fpath, err)
+		}
+	}
+
+	// シンボリックリンク
+	if file.Mode()&os.ModeSymlink != 0 {
+		return extractSymlink(dstDir, fpath, file, allowExternalSymlinks)
+	}
+
 	// ZIP内のファイルを開く
 	rc, err := file.Open()
 	if err != nil {
@@ -174,11 +486,10 @@ func extractAndWriteFile(dstDir string, file *zip.File) error {
 	}
 
 	// nolint:gosec // G115 size already checked
-	limitedReader := io.LimitReader(rc, int64(file.UncompressedSize64)+bufSize)
+	limitedReader := io.LimitReader(ctxReader{ctx: ctx, r: rc}, int64(file.UncompressedSize64)+bufSize)
 
 	// 展開先のファイルを作成・オープン
-	// f.Mode() で元のファイルの権限を引き継ぐ
-	outFile, err := os.OpenFile(fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, file.Mode())
+	outFile, err := os.OpenFile(fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, file.Mode().Perm()|0o200)
 	if err != nil {
 		return fmt.Errorf("展開先ファイル(%s)を開けませんでした: %w", fpath, err)
 	}
@@ -194,9 +505,163 @@ func extractAndWriteFile(dstDir string, file *zip.File) error {
 		return fmt.Errorf("ファイルのコピーに失敗しました (%s): %w", file.Name, err)
 	}
 
+	// f.Mode() で元のファイルの権限を引き継ぐ (umaskの影響を受けないよう明示的に設定)
+	return applyZipAttributes(fpath, file)
+}
+
+// maxSymlinkTargetLen はシンボリックリンク先パスの最大長です。
+const maxSymlinkTargetLen = 4096
+
+// extractSymlink はzip内のシンボリックリンクを展開します。
+// 展開先の外を指すリンクは、allowExternalSymlinks が true の場合のみ警告を表示して展開します。
+func extractSymlink(dstDir, fpath string, file *zip.File, allowExternalSymlinks bool) error {
+	rc, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("zip内のファイルを開けませんでした: %w", err)
+	}
+	defer func(rc io.ReadCloser) {
+		rcErr := rc.Close()
+		if rcErr != nil {
+			fmt.Fprintf(os.Stderr, "zip内ファイルのクローズに失敗しました: %v\n", rcErr)
+		}
+	}(rc)
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxSymlinkTargetLen+1))
+	if err != nil {
+		return fmt.Errorf("シンボリックリンク先の読み取りに失敗しました (%s): %w", file.Name, err)
+	}
+	if len(data) > maxSymlinkTargetLen {
+		return fmt.Errorf("シンボリックリンク先のパスが長すぎます: %s", file.Name)
+	}
+	target := filepath.FromSlash(string(data))
+
+	// リンク先の検証
+	// 展開先の外を指すリンク(絶対パス等)は、展開後にリンクを経由して展開先の外を読み書きされるおそれがあるため、Untar と同じく展開しない。
+	// バックアップ元で正当に使われている場合に備えて、allowExternalSymlinks で許可できるようにする。
+	// 展開時にリンクを経由して書き込むことは extractAndWriteFile 側で防いでいる。
+	if target == "" || strings.ContainsRune(target, 0) {
+		return fmt.Errorf("不正なシンボリックリンクを検出しました: %s", file.Name)
+	}
+	resolved := target
+	if !filepath.IsAbs(target) {
+		resolved = filepath.Join(filepath.Dir(fpath), target)
+	}
+	if resolved != filepath.Clean(dstDir) && !isWithinDir(dstDir, resolved) {
+		if !allowExternalSymlinks {
+			return fmt.Errorf("%w: %s -> %s", ErrExternalSymlink, file.Name, target)
+		}
+		fmt.Fprintf(os.Stderr, "警告: アーカイブの外を指すシンボリックリンクです: %s -> %s\n", file.Name, target)
+	}
+
+	if err := os.Symlink(target, fpath); err != nil {
+		return fmt.Errorf("シンボリックリンクの作成に失敗しました (%s -> %s): %w", fpath, target, err)
+	}
+
+	if uid, gid, ok := decodeUnixOwner(file.Extra); ok && canChown() {
+		if err := setOwner(fpath, uid, gid); err != nil {
+			return fmt.Errorf("所有者の設定に失敗しました (%s): %w", fpath, err)
+		}
+	}
 	return nil
 }
 
+// applyZipAttributes はzipエントリに格納された権限, 更新日時, 所有者を path に設定します。
+func applyZipAttributes(path string, file *zip.File) error {
+	if uid, gid, ok := decodeUnixOwner(file.Extra); ok && canChown() {
+		if err := setOwner(path, uid, gid); err != nil {
+			return fmt.Errorf("所有者の設定に失敗しました (%s): %w", path, err)
+		}
+	}
+	if err := os.Chmod(path, file.Mode().Perm()); err != nil {
+		return fmt.Errorf("権限の設定に失敗しました (%s): %w", path, err)
+	}
+	if !file.Modified.IsZero() {
+		if err := os.Chtimes(path, file.Modified, file.Modified); err != nil {
+			return fmt.Errorf("更新日時の設定に失敗しました (%s): %w", path, err)
+		}
+	}
+	return nil
+}
+
+// checkNoSymlinkParent は dstDir から path までの途中のディレクトリにシンボリックリンクが含まれていないか確認します。
+func checkNoSymlinkParent(dstDir, path string) error {
+	rel, err := filepath.Rel(filepath.Clean(dstDir), filepath.Dir(path))
+	if err != nil {
+		return fmt.Errorf("相対パスの取得に失敗しました (%s): %w", path, err)
+	}
+	if rel == "." {
+		return nil
+	}
+
+	current := filepath.Clean(dstDir)
+	for _, elem := range strings.Split(rel, string(os.PathSeparator)) {
+		current = filepath.Join(current, elem)
+		info, err := os.Lstat(current)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s の情報取得に失敗しました: %w", current, err)
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("シンボリックリンクを経由したファイルの展開を検出しました: %s", path)
+		}
+	}
+	return nil
+}
+
+// isWithinDir は path が dir 配下にあるかを判定します。
+func isWithinDir(dir, path string) bool {
+	return strings.HasPrefix(path, filepath.Clean(dir)+string(os.PathSeparator))
+}
+
+// unixOwnerExtraID は Info-ZIP の "New Unix" 拡張フィールド(uid/gid)のIDです。
+const unixOwnerExtraID = 0x7875
+
+// encodeUnixOwner は uid, gid を Info-ZIP New Unix 拡張フィールドとしてエンコードします。
+func encodeUnixOwner(uid, gid int) []byte {
+	// Header ID(2) + Size(2) + Version(1) + UIDSize(1) + UID(4) + GIDSize(1) + GID(4)
+	buf := make([]byte, 15)
+	binary.LittleEndian.PutUint16(buf[0:2], unixOwnerExtraID)
+	binary.LittleEndian.PutUint16(buf[2:4], 11)
+	buf[4] = 1
+	buf[5] = 4
+	binary.LittleEndian.PutUint32(buf[6:10], uint32(uid)) // nolint:gosec // G115 uid is 32bit
+	buf[10] = 4
+	binary.LittleEndian.PutUint32(buf[11:15], uint32(gid)) // nolint:gosec // G115 gid is 32bit
+	return buf
+}
+
+// decodeUnixOwner は拡張フィールドから Info-ZIP New Unix 形式の uid, gid を取り出します。
+func decodeUnixOwner(extra []byte) (uid, gid int, ok bool) {
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra[0:2])
+		size := int(binary.LittleEndian.Uint16(extra[2:4]))
+		if len(extra) < 4+size {
+			return 0, 0, false
+		}
+		body := extra[4 : 4+size]
+		extra = extra[4+size:]
+
+		if id != unixOwnerExtraID || len(body) < 2 || body[0] != 1 {
+			continue
+		}
+
+		uidSize := int(body[1])
+		if uidSize != 4 || len(body) < 2+uidSize+1 {
+			return 0, 0, false
+		}
+		uid = int(binary.LittleEndian.Uint32(body[2:6]))
+		gidSize := int(body[6])
+		if gidSize != 4 || len(body) < 7+gidSize {
+			return 0, 0, false
+		}
+		gid = int(binary.LittleEndian.Uint32(body[7:11]))
+		return uid, gid, true
+	}
+	return 0, 0, false
+}
+
 const maxCompressionRatio = 100
 
 // isSuspiciousRatio は圧縮率が異常かどうかを判定します
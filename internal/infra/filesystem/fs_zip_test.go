@@ -0,0 +1,84 @@
+package filesystem
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeSymlinkZip はシンボリックリンク name -> target のみを含む zip ファイルを作成する
+func writeSymlinkZip(t *testing.T, path, name, target string) {
+	t.Helper()
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	zw := zip.NewWriter(file)
+	header := &zip.FileHeader{Name: name, Method: zip.Store}
+	header.SetMode(fs.ModeSymlink | 0o777)
+	w, err := zw.CreateHeader(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte(target)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUnzipExternalSymlinks(t *testing.T) {
+	tests := []struct {
+		name     string
+		link     string
+		target   string
+		external bool
+	}{
+		{name: "absolute", link: "link", target: "/etc", external: true},
+		{name: "relative", link: "dir/link", target: "../../outside", external: true},
+		{name: "inside", link: "dir/link", target: "../file", external: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			archive := filepath.Join(dir, "archive.zip")
+			writeSymlinkZip(t, archive, tt.link, tt.target)
+
+			// 展開先の外を指すリンクはエラー
+			dst := filepath.Join(dir, "dst")
+			err := NewFileSystem().Unzip(context.Background(), archive, dst, false)
+			if tt.external {
+				if !errors.Is(err, ErrExternalSymlink) {
+					t.Fatalf("Unzip() error = %v, want %v", err, ErrExternalSymlink)
+				}
+				if _, err := os.Lstat(filepath.Join(dst, tt.link)); !os.IsNotExist(err) {
+					t.Errorf("展開先の外を指すリンクが作成されています: %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("Unzip() error = %v", err)
+			}
+
+			// allowExternalSymlinks が true の場合は展開する
+			dst = filepath.Join(dir, "allowed")
+			if err := NewFileSystem().Unzip(context.Background(), archive, dst, true); err != nil {
+				t.Fatalf("Unzip() error = %v", err)
+			}
+			if got, err := os.Readlink(filepath.Join(dst, tt.link)); err != nil || got != tt.target {
+				t.Errorf("Readlink() = %q, %v, want %q", got, err, tt.target)
+			}
+		})
+	}
+}
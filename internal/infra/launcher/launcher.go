@@ -0,0 +1,193 @@
+package launcher
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+	"github.com/nonuplet/grimoire-archon/internal/infra/runas"
+	"github.com/nonuplet/grimoire-archon/internal/infra/sandbox"
+	"github.com/nonuplet/grimoire-archon/internal/infra/wine"
+)
+
+// Launcher ゲームサーバプロセスの起動・停止
+type Launcher struct {
+	archonCfg *domain.ArchonConfig
+}
+
+// NewLauncher Launcherのインスタンスを生成する
+// archonCfg は prefix.path が未設定の場合のプレフィックスの場所に使用します。
+func NewLauncher(archonCfg *domain.ArchonConfig) *Launcher {
+	return &Launcher{archonCfg: archonCfg}
+}
+
+// Start は run.command に run.args を付けてゲームサーバを起動し、プロセスIDを返します。
+// サーバは run.workdir (デフォルトでインストールディレクトリ) をカレントディレクトリとして、archon から切り離されて起動します。
+// run.user, run.umask, run.nice, run.ionice が設定されている場合は、それらを適用して起動します。
+// resources が設定されている場合は、ゲームの cgroup に配置した状態で起動します。(Linuxのみ)
+// isolation が有効な場合は、sandbox.Writable のみ書き込みを許可したサンドボックス内で起動します。(Linuxのみ, sandbox.Wrap を参照)
+// この場合に返すプロセスIDは、サーバではなくサンドボックスの初期化プロセスのものです。
+// runtime_env が wine, proton の場合は、その実行環境で起動します。(wine.ResolveRuntime を参照)
+// 標準出力・標準エラー出力は logPath に追記されます。
+func (l *Launcher) Start(gameCfg *domain.GameConfig, logPath string, paths domain.SandboxPaths) (int, error) {
+	if gameCfg.Run == nil || gameCfg.Run.Command == "" {
+		return 0, fmt.Errorf("run.command が設定されていません")
+	}
+
+	rt, err := wine.ResolveRuntime(l.archonCfg, gameCfg)
+	if err != nil {
+		return 0, err
+	}
+	env, err := environ(rt, gameCfg)
+	if err != nil {
+		return 0, err
+	}
+
+	// サーバは archon とは独立して動作するため、キャンセルされないコンテキストで起動する
+	cmd, err := serverCommand(context.Background(), gameCfg.Run, rt.Command(gameCfg.Run.CommandLine()))
+	if err != nil {
+		return 0, err
+	}
+	cmd.Dir = gameCfg.Run.GetWorkdir(gameCfg.InstallDir)
+	cmd.Env = env
+	detach(cmd)
+	if err := runas.SetUser(cmd, gameCfg.Run.User); err != nil {
+		return 0, err
+	}
+	if gameCfg.Isolation.IsEnabled() {
+		if err := sandbox.Wrap(cmd, paths, rt.ReadOnly); err != nil {
+			return 0, err
+		}
+	}
+	closeCgroup, err := l.joinCgroup(cmd, gameCfg)
+	if err != nil {
+		return 0, err
+	}
+	defer closeCgroup()
+
+	if err := os.MkdirAll(filepath.Dir(logPath), 0o755); err != nil {
+		return 0, fmt.Errorf("ログディレクトリの作成に失敗しました: %w", err)
+	}
+	logFile, err := os.OpenFile(logPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return 0, fmt.Errorf("ログファイル %s を開けませんでした: %w", logPath, err)
+	}
+	defer func(f *os.File) {
+		if err := f.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "ログファイルのクローズに失敗しました: %v\n", err)
+		}
+	}(logFile)
+
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("サーバの起動に失敗しました: %w", err)
+	}
+
+	// archon が動作し続ける場合(スケジューラ等)に備えて、終了したプロセスを回収しておく
+	go func() {
+		_ = cmd.Wait()
+	}()
+
+	return cmd.Process.Pid, nil
+}
+
+// Run は command をインストールディレクトリでシェル経由で実行し、終了するまで待機します。
+// 出力はそのまま標準出力・標準エラー出力に表示します。終了コードが0以外の場合はエラーを返します。
+// run.command と同じく runtime_env の環境変数 (WINEPREFIX など) と run.envs, run.env_file の環境変数を設定し、
+// run.user が設定されている場合はそのユーザーで実行します。
+func (l *Launcher) Run(ctx context.Context, gameCfg *domain.GameConfig, command string) error {
+	rt, err := wine.ResolveRuntime(l.archonCfg, gameCfg)
+	if err != nil {
+		return err
+	}
+	env, err := environ(rt, gameCfg)
+	if err != nil {
+		return err
+	}
+
+	cmd := shellCommand(ctx, command)
+	cmd.Dir = gameCfg.InstallDir
+	cmd.Env = env
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if gameCfg.Run != nil {
+		if err := runas.SetUser(cmd, gameCfg.Run.User); err != nil {
+			return err
+		}
+	}
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("コマンド '%s' の実行に失敗しました: %w", command, err)
+	}
+	return nil
+}
+
+// Exec は args を run.command と同じ実行環境(環境変数, run.workdir, run.user)で実行し、終了するまで待機します。
+// シェルは経由せず、標準入出力はそのまま接続します。終了コードが0以外の場合は *exec.ExitError をラップしたエラーを返します。
+func (l *Launcher) Exec(ctx context.Context, gameCfg *domain.GameConfig, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("実行するコマンドを指定してください")
+	}
+	rt, err := wine.ResolveRuntime(l.archonCfg, gameCfg)
+	if err != nil {
+		return err
+	}
+	env, err := environ(rt, gameCfg)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = gameCfg.Run.GetWorkdir(gameCfg.InstallDir)
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if gameCfg.Run != nil {
+		if err := runas.SetUser(cmd, gameCfg.Run.User); err != nil {
+			return err
+		}
+	}
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("コマンド '%s' の実行に失敗しました: %w", strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// environ はコマンドに渡す環境変数を返します。
+// run.user のユーザーの HOME など、実行環境の環境変数、run.env_file、run.envs の順に追加し、同じ変数がある場合は後のものが優先されます。
+func environ(rt *wine.Runtime, gameCfg *domain.GameConfig) ([]string, error) {
+	env := os.Environ()
+	if gameCfg.Run == nil {
+		return append(env, rt.Env...), nil
+	}
+
+	if gameCfg.Run.User != "" {
+		userEnv, err := runas.Env(gameCfg.Run.User)
+		if err != nil {
+			return nil, err
+		}
+		env = append(env, userEnv...)
+	}
+	env = append(env, rt.Env...)
+
+	if path := gameCfg.Run.GetEnvFile(gameCfg.InstallDir); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("run.env_file (%s) の読み込みに失敗しました: %w", path, err)
+		}
+		fileEnv, err := domain.ParseDotEnv(data)
+		if err != nil {
+			return nil, fmt.Errorf("run.env_file (%s) のパースに失敗しました: %w", path, err)
+		}
+		env = append(env, fileEnv...)
+	}
+	return append(env, gameCfg.Run.Envs...), nil
+}
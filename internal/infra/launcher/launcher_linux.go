@@ -0,0 +1,107 @@
+//go:build linux
+
+package launcher
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+	"github.com/nonuplet/grimoire-archon/internal/infra/cgroup"
+)
+
+// signals は run.stop_signal の名前とシグナルの対応です。
+var signals = map[string]unix.Signal{
+	"SIGTERM": unix.SIGTERM,
+	"SIGINT":  unix.SIGINT,
+	"SIGHUP":  unix.SIGHUP,
+	"SIGQUIT": unix.SIGQUIT,
+	"SIGUSR1": unix.SIGUSR1,
+	"SIGUSR2": unix.SIGUSR2,
+}
+
+// shellCommand は command をシェル経由で実行するコマンドを生成します。
+// exec で置き換えることで、起動したプロセスのPIDがサーバ本体のものになります。
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	return exec.CommandContext(ctx, "sh", "-c", "exec "+command)
+}
+
+// serverCommand は run の設定を適用して command を実行するコマンドを生成します。
+// umask はシェルで設定し、nice, ionice は exec で置き換えながら起動するため、PIDはサーバ本体のものになります。
+func serverCommand(ctx context.Context, run *domain.RunConfig, command string) (*exec.Cmd, error) {
+	var wrappers []string
+	if run.Nice != nil {
+		wrappers = append(wrappers, "nice", "-n", strconv.Itoa(*run.Nice))
+	}
+	ionice, err := run.IONiceArgs()
+	if err != nil {
+		return nil, err
+	}
+	if ionice != nil {
+		wrappers = append(append(wrappers, "ionice"), ionice...)
+	}
+	if len(wrappers) > 0 {
+		command = strings.Join(wrappers, " ") + " " + command
+	}
+
+	script := "exec " + command
+	if run.Umask != "" {
+		script = "umask " + domain.ShellQuote(run.Umask) + " && " + script
+	}
+	return exec.CommandContext(ctx, "sh", "-c", script), nil
+}
+
+// joinCgroup は resources が設定されている場合に、プロセスをゲームの cgroup で起動するよう設定します。
+// 起動時に配置されるため、サーバが起動直後に生成した子プロセスも制限の対象になります。
+// 返された関数で、起動後に cgroup のディレクトリを閉じてください。
+func (l *Launcher) joinCgroup(cmd *exec.Cmd, gameCfg *domain.GameConfig) (func(), error) {
+	if gameCfg.Resources == nil {
+		return func() {}, nil
+	}
+	dir, err := cgroup.NewCgroup(l.archonCfg).Prepare(gameCfg)
+	if err != nil {
+		return nil, fmt.Errorf("resources の適用に失敗しました: %w", err)
+	}
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = int(dir.Fd())
+	return func() { _ = dir.Close() }, nil
+}
+
+// detach はプロセスを新しいセッションで起動し、端末の Ctrl-C が伝わらないようにします。
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
+
+// Terminate はプロセスに終了を要求します。signal は run.stop_signal のシグナル名です。(空の場合は SIGTERM)
+func (l *Launcher) Terminate(pid int, signal string) error {
+	sig := unix.SIGTERM
+	if signal != "" {
+		s, ok := signals[signal]
+		if !ok {
+			return fmt.Errorf("シグナル %s には対応していません", signal)
+		}
+		sig = s
+	}
+	if err := unix.Kill(pid, sig); err != nil {
+		return fmt.Errorf("pid %d への%sの送信に失敗しました: %w", pid, signal, err)
+	}
+	return nil
+}
+
+// Kill はプロセスを強制終了 (SIGKILL) します。
+func (l *Launcher) Kill(pid int) error {
+	if err := unix.Kill(pid, unix.SIGKILL); err != nil {
+		return fmt.Errorf("pid %d へのSIGKILLの送信に失敗しました: %w", pid, err)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+//go:build windows
+
+package launcher
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+// shellCommand は command を cmd.exe 経由で実行するコマンドを生成します。
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	return exec.CommandContext(ctx, "cmd", "/C", command)
+}
+
+// serverCommand は command を実行するコマンドを生成します。
+// user, umask, nice, ionice は Linux のみ対応のため、設定されている場合はエラーを返します。
+func serverCommand(ctx context.Context, run *domain.RunConfig, command string) (*exec.Cmd, error) {
+	if run.User != "" || run.Umask != "" || run.Nice != nil || run.IONice != "" {
+		return nil, fmt.Errorf("run.user, run.umask, run.nice, run.ionice はWindowsでは使用できません")
+	}
+	return shellCommand(ctx, command), nil
+}
+
+// joinCgroup は Windows では cgroup が使用できないため、resources が設定されている場合はエラーを返します。
+func (l *Launcher) joinCgroup(_ *exec.Cmd, gameCfg *domain.GameConfig) (func(), error) {
+	if gameCfg.Resources != nil {
+		return nil, fmt.Errorf("resources はLinuxでのみ使用できます")
+	}
+	return func() {}, nil
+}
+
+// detach はプロセスを新しいプロセスグループで起動し、コンソールの Ctrl-C が伝わらないようにします。
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: windows.CREATE_NEW_PROCESS_GROUP | windows.DETACHED_PROCESS,
+	}
+}
+
+// Terminate はプロセスを終了します。
+// Windows にはシグナルに相当する仕組みがないため、signal に関わらず強制終了します。
+func (l *Launcher) Terminate(pid int, _ string) error {
+	return l.Kill(pid)
+}
+
+// Kill はプロセスを強制終了します。
+func (l *Launcher) Kill(pid int) error {
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return fmt.Errorf("pid %d のプロセスが見つかりません: %w", pid, err)
+	}
+	if err := proc.Kill(); err != nil {
+		return fmt.Errorf("pid %d の終了に失敗しました: %w", pid, err)
+	}
+	return nil
+}
@@ -0,0 +1,291 @@
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-yaml"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+const (
+	// pollInterval はロック待機中に再取得を試みる間隔です。
+	pollInterval = 500 * time.Millisecond
+	// maxInfoSize はロックファイルから読み込む保持者情報の最大サイズです。
+	maxInfoSize = 64 << 10
+)
+
+// ErrLocked は他のプロセスがロックを保持していることを示します。
+var ErrLocked = errors.New("他の操作が実行中です")
+
+// Locker ゲームごとの操作ロック (flock / LockFileEx によるアドバイザリロック)
+type Locker struct {
+	held    map[string]*heldLock
+	dir     string
+	timeout time.Duration
+	mu      sync.Mutex
+	wait    bool
+}
+
+// heldLock はこのプロセスが保持しているロックです。同一プロセス内での再取得に対応するため参照カウントを持ちます。
+type heldLock struct {
+	file  *os.File
+	count int
+}
+
+// NewLocker Lockerのインスタンスを生成する
+// ロックファイルは dir に作成します。(archon.GetLockDir)
+// wait が true の場合、ロックが解放されるまで待機します。timeout が 0 の場合は無期限に待機します。
+func NewLocker(dir string, wait bool, timeout time.Duration) *Locker {
+	return &Locker{
+		held:    make(map[string]*heldLock),
+		dir:     dir,
+		wait:    wait,
+		timeout: timeout,
+	}
+}
+
+// Lock は name で指定したゲームの操作ロックを取得し、解放用の関数を返します。
+// operation はロック保持者の情報として記録され、他のプロセスがロックを取得しようとした際に表示されます。
+// 同一プロセス内で既にロックを保持している場合は、そのまま取得に成功します。
+func (l *Locker) Lock(name, operation string) (func(), error) {
+	return l.lock(name, operation, l.wait)
+}
+
+// TryLock は name で指定したゲームの操作ロックを、待機せずに取得します。
+// 他のプロセスがロックを保持している場合は ok が false になります。
+func (l *Locker) TryLock(name, operation string) (unlock func(), ok bool, err error) {
+	unlock, err = l.lock(name, operation, false)
+	if errors.Is(err, ErrLocked) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return unlock, true, nil
+}
+
+// lock はロックを取得します。wait が true の場合、ロックが解放されるまで待機します。
+// 待機中に同一プロセス内の他の操作がロックを解放・取得できるよう、l.mu は取得を試みる間だけ保持します。
+func (l *Locker) lock(name, operation string, wait bool) (func(), error) {
+	if unlock, ok := l.reacquire(name); ok {
+		return unlock, nil
+	}
+
+	if err := l.prepareDir(); err != nil {
+		return nil, err
+	}
+
+	// 他のユーザーが置いたシンボリックリンクをたどって任意のファイルを書き換えないよう、リンクはたどらない
+	path := filepath.Join(l.dir, name+".lock")
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|openNoFollow, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("ロックファイル %s を開けませんでした: %w", path, err)
+	}
+	closeFile := func() {
+		if closeErr := file.Close(); closeErr != nil {
+			fmt.Fprintf(os.Stderr, "ロックファイル %s のクローズに失敗しました: %v\n", path, closeErr)
+		}
+	}
+
+	var deadline time.Time
+	if l.timeout > 0 {
+		deadline = time.Now().Add(l.timeout)
+	}
+
+	notified := false
+	for {
+		unlock, ok, err := l.tryAcquire(file, name, operation)
+		if err != nil {
+			closeFile()
+			return nil, err
+		}
+		if ok {
+			return unlock, nil
+		}
+
+		holder := "不明"
+		if info, err := readInfo(file); err == nil && info != nil {
+			holder = describe(info)
+			if info.Host == hostname() && !isProcessAlive(info.PID) {
+				holder += " ※プロセスが存在しません。子プロセスがロックを保持している可能性があります"
+			}
+		}
+
+		if !wait {
+			closeFile()
+			return nil, fmt.Errorf("%w: %s (%s)。--wait を指定すると終了を待機します", ErrLocked, name, holder)
+		}
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			closeFile()
+			return nil, fmt.Errorf("%w: %s (%s)。待機がタイムアウトしました", ErrLocked, name, holder)
+		}
+		if !notified {
+			fmt.Printf("%s は他の操作が実行中です (%s)。終了を待機しています...\n", name, holder)
+			notified = true
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// prepareDir はロックディレクトリを作成し、他のユーザーがロックファイルを置き換えられないことを確認します。
+func (l *Locker) prepareDir() error {
+	if err := os.MkdirAll(l.dir, 0o700); err != nil {
+		return fmt.Errorf("ロックディレクトリ %s の作成に失敗しました: %w", l.dir, err)
+	}
+	if err := checkDirOwner(l.dir); err != nil {
+		return fmt.Errorf("ロックディレクトリ %s を使用できません: %w", l.dir, err)
+	}
+	return nil
+}
+
+// reacquire は同一プロセス内で既にロックを保持している場合に、参照カウントを増やして解放用の関数を返します。
+func (l *Locker) reacquire(name string) (func(), bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	h, ok := l.held[name]
+	if !ok {
+		return nil, false
+	}
+	h.count++
+	return l.unlockFunc(name), true
+}
+
+// tryAcquire はロックの取得を1回試みます。他のプロセスがロックを保持している場合は ok が false になります。
+// 待機中に同一プロセス内の他の操作がロックを取得していた場合は、file を閉じてそのロックを共有します。
+func (l *Locker) tryAcquire(file *os.File, name, operation string) (unlock func(), ok bool, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if h, held := l.held[name]; held {
+		if closeErr := file.Close(); closeErr != nil {
+			fmt.Fprintf(os.Stderr, "ロックファイル %s のクローズに失敗しました: %v\n", file.Name(), closeErr)
+		}
+		h.count++
+		return l.unlockFunc(name), true, nil
+	}
+
+	locked, err := tryLockFile(file)
+	if err != nil {
+		return nil, false, fmt.Errorf("ロックの取得に失敗しました: %w", err)
+	}
+	if !locked {
+		return nil, false, nil
+	}
+
+	// 前回の保持者が正常に終了しなかった場合は通知する
+	if prev, err := readInfo(file); err == nil && prev != nil {
+		fmt.Fprintf(os.Stderr, "警告: %s の前回の操作 (%s) が正常に終了しなかった可能性があります。\n", name, describe(prev))
+	}
+
+	if err := writeInfo(file, operation); err != nil {
+		if unlockErr := unlockFile(file); unlockErr != nil {
+			fmt.Fprintf(os.Stderr, "ロックの解放に失敗しました: %v\n", unlockErr)
+		}
+		return nil, false, fmt.Errorf("ロックファイル %s の書き込みに失敗しました: %w", file.Name(), err)
+	}
+
+	l.held[name] = &heldLock{file: file, count: 1}
+	return l.unlockFunc(name), true, nil
+}
+
+// unlockFunc はロック解放用の関数を返します。
+func (l *Locker) unlockFunc(name string) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.release(name)
+		})
+	}
+}
+
+// release はロックを解放します。参照カウントが0になった時点でファイルのロックを解除します。
+func (l *Locker) release(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	h, ok := l.held[name]
+	if !ok {
+		return
+	}
+	h.count--
+	if h.count > 0 {
+		return
+	}
+	delete(l.held, name)
+
+	// 正常終了の印として保持者情報を消してから解放する
+	if err := h.file.Truncate(0); err != nil {
+		fmt.Fprintf(os.Stderr, "ロックファイルの初期化に失敗しました: %v\n", err)
+	}
+	if err := unlockFile(h.file); err != nil {
+		fmt.Fprintf(os.Stderr, "ロックの解放に失敗しました: %v\n", err)
+	}
+	if err := h.file.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "ロックファイルのクローズに失敗しました: %v\n", err)
+	}
+}
+
+// readInfo はロックファイルから保持者情報を読み込みます。空の場合は nil を返します。
+// パスを開き直すとロックしたファイルと異なるファイルを読む可能性があるため、開いているファイルから読み込みます。
+func readInfo(file *os.File) (*domain.LockInfo, error) {
+	data, err := io.ReadAll(io.NewSectionReader(file, 0, maxInfoSize))
+	if err != nil {
+		return nil, fmt.Errorf("ロックファイルの読み込みに失敗しました: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, nil //nolint:nilnil // 保持者なし
+	}
+
+	var info domain.LockInfo
+	if err := yaml.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("ロックファイルのデコードに失敗しました: %w", err)
+	}
+	return &info, nil
+}
+
+// writeInfo はロックファイルに現在のプロセスの情報を書き込みます。
+func writeInfo(file *os.File, operation string) error {
+	info := domain.LockInfo{
+		PID:       os.Getpid(),
+		Host:      hostname(),
+		Operation: operation,
+		StartedAt: time.Now(),
+	}
+	data, err := yaml.Marshal(info)
+	if err != nil {
+		return fmt.Errorf("ロック情報のマーシャリングに失敗しました: %w", err)
+	}
+
+	if err := file.Truncate(0); err != nil {
+		return fmt.Errorf("ロックファイルの初期化に失敗しました: %w", err)
+	}
+	if _, err := file.WriteAt(data, 0); err != nil {
+		return fmt.Errorf("ロックファイルの書き込みに失敗しました: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("ロックファイルの同期に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// describe は保持者情報を表示用の文字列にします。
+func describe(info *domain.LockInfo) string {
+	return fmt.Sprintf("%s, pid %d@%s, %s から実行中", info.Operation, info.PID, info.Host, info.StartedAt.Local().Format("2006-01-02 15:04:05"))
+}
+
+// hostname はホスト名を返します。取得できない場合は空文字列を返します。
+func hostname() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+	return name
+}
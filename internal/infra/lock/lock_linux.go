@@ -0,0 +1,67 @@
+//go:build linux
+
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// tryLockFile はファイルの排他ロック(flock)を取得します。他のプロセスが保持している場合は false を返します。
+func tryLockFile(file *os.File) (bool, error) {
+	err := unix.Flock(int(file.Fd()), unix.LOCK_EX|unix.LOCK_NB) // nolint:gosec // G115 fd fits in int
+	if errors.Is(err, unix.EWOULDBLOCK) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("flock: %w", err)
+	}
+	return true, nil
+}
+
+// unlockFile はファイルの排他ロックを解放します。
+func unlockFile(file *os.File) error {
+	if err := unix.Flock(int(file.Fd()), unix.LOCK_UN); err != nil { // nolint:gosec // G115 fd fits in int
+		return fmt.Errorf("flock: %w", err)
+	}
+	return nil
+}
+
+// isProcessAlive は pid のプロセスが存在するかを返します。
+func isProcessAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := unix.Kill(pid, 0)
+	return err == nil || errors.Is(err, unix.EPERM)
+}
+
+// openNoFollow はロックファイルを開く際に、シンボリックリンクをたどらないためのフラグです。
+const openNoFollow = unix.O_NOFOLLOW
+
+// checkDirOwner は dir がシンボリックリンクではなく、実行中のユーザーが所有するディレクトリであることを確認します。
+// 他のユーザーが所有するディレクトリでは、ロックファイルを置き換えられる可能性があるため使用しません。
+func checkDirOwner(dir string) error {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return fmt.Errorf("ディレクトリの情報を取得できませんでした: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("ディレクトリではありません")
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fmt.Errorf("所有者を取得できませんでした")
+	}
+	if uid := os.Geteuid(); int(stat.Uid) != uid {
+		return fmt.Errorf("所有者 (uid %d) が実行中のユーザー (uid %d) と異なります", stat.Uid, uid)
+	}
+	if info.Mode().Perm()&0o022 != 0 {
+		return fmt.Errorf("他のユーザーが書き込めます (%s)。chmod 700 で権限を変更してください", info.Mode().Perm())
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+//go:build windows
+
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockOffset はロックするバイト範囲の開始位置です。
+// Windowsではロックした範囲は他のプロセスから読み込めないため、保持者情報を書き込む範囲の外をロックします。
+const lockOffset = 1 << 30
+
+// stillActive は GetExitCodeProcess が返す、プロセスが実行中であることを示す値です。
+const stillActive = 259
+
+// tryLockFile はファイルの排他ロック(LockFileEx)を取得します。他のプロセスが保持している場合は false を返します。
+func tryLockFile(file *os.File) (bool, error) {
+	ol := &windows.Overlapped{Offset: lockOffset}
+	err := windows.LockFileEx(windows.Handle(file.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, ol)
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("LockFileEx: %w", err)
+	}
+	return true, nil
+}
+
+// unlockFile はファイルの排他ロックを解放します。
+func unlockFile(file *os.File) error {
+	ol := &windows.Overlapped{Offset: lockOffset}
+	if err := windows.UnlockFileEx(windows.Handle(file.Fd()), 0, 1, 0, ol); err != nil {
+		return fmt.Errorf("UnlockFileEx: %w", err)
+	}
+	return nil
+}
+
+// isProcessAlive は pid のプロセスが存在するかを返します。
+func isProcessAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid)) // nolint:gosec // G115 pid fits in uint32
+	if err != nil {
+		return false
+	}
+	defer func(h windows.Handle) {
+		_ = windows.CloseHandle(h)
+	}(h)
+
+	var code uint32
+	if err := windows.GetExitCodeProcess(h, &code); err != nil {
+		return false
+	}
+	return code == stillActive
+}
+
+// openNoFollow はロックファイルを開く際のフラグです。Windowsでは追加のフラグはありません。
+const openNoFollow = 0
+
+// checkDirOwner はロックディレクトリの所有者を確認します。
+// Windowsでは確認しません。(既定のディレクトリは %LocalAppData% 以下にあり、ACLで保護されます)
+func checkDirOwner(_ string) error {
+	return nil
+}
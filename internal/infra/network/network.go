@@ -0,0 +1,47 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+// PortChecker ホストのポートの使用状況の確認
+type PortChecker struct{}
+
+// NewPortChecker PortCheckerのインスタンスを生成する
+func NewPortChecker() *PortChecker {
+	return &PortChecker{}
+}
+
+// InUse は port が他のプロセスにバインドされている場合に true を返します。
+// 実際に全てのアドレスでバインドを試み、使用中のエラーになるかで判定します。
+// 権限がない(1024未満のポートなど)などの理由で判定できない場合はエラーを返します。
+func (c *PortChecker) InUse(port int, protocol domain.PortProtocol) (bool, error) {
+	addr := net.JoinHostPort("", strconv.Itoa(port))
+
+	var err error
+	switch protocol {
+	case domain.ProtocolTCP:
+		var l net.Listener
+		if l, err = net.Listen("tcp", addr); err == nil {
+			_ = l.Close()
+			return false, nil
+		}
+	case domain.ProtocolUDP:
+		var conn net.PacketConn
+		if conn, err = net.ListenPacket("udp", addr); err == nil {
+			_ = conn.Close()
+			return false, nil
+		}
+	default:
+		return false, fmt.Errorf("不明なプロトコルです: %s", protocol)
+	}
+
+	if isAddrInUse(err) {
+		return true, nil
+	}
+	return false, fmt.Errorf("ポート %d/%s の確認に失敗しました: %w", port, protocol, err)
+}
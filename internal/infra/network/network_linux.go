@@ -0,0 +1,14 @@
+//go:build linux
+
+package network
+
+import (
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// isAddrInUse はバインドに失敗した原因が、ポートが使用中であるためかどうかを返します。
+func isAddrInUse(err error) bool {
+	return errors.Is(err, unix.EADDRINUSE)
+}
@@ -0,0 +1,15 @@
+//go:build windows
+
+package network
+
+import (
+	"errors"
+
+	"golang.org/x/sys/windows"
+)
+
+// isAddrInUse はバインドに失敗した原因が、ポートが使用中であるためかどうかを返します。
+// SO_EXCLUSIVEADDRUSE で使用されている場合は WSAEACCES になります。
+func isAddrInUse(err error) bool {
+	return errors.Is(err, windows.WSAEADDRINUSE) || errors.Is(err, windows.WSAEACCES)
+}
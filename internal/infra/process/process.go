@@ -0,0 +1,216 @@
+package process
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+// Finder 実行中のゲームサーバプロセスの検出
+type Finder struct {
+	archonCfg *domain.ArchonConfig
+}
+
+// NewFinder Finderのインスタンスを生成する
+// archonCfg は起動したサーバのPIDファイルの保存先に使用します。
+func NewFinder(archonCfg *domain.ArchonConfig) *Finder {
+	return &Finder{archonCfg: archonCfg}
+}
+
+// procInfo はプロセス一覧の1プロセスの情報です。取得できない項目は空になります。
+type procInfo struct {
+	exe  string
+	cwd  string
+	args []string
+	pid  int
+	ppid int
+}
+
+// FindRunning はゲームサーバのプロセスを探し、見つかったプロセスのPIDを返します。
+// Record で記録したプロセスが実行中の場合は、そのプロセスと子孫のプロセスを返します。
+// 記録がない場合 (archon 以外から起動した場合など) は、newMatcher の条件でプロセス一覧から探します。
+func (p *Finder) FindRunning(gameCfg *domain.GameConfig) ([]int, error) {
+	procs, err := listProcesses()
+	if err != nil {
+		return nil, err
+	}
+
+	if pid, ok := p.recorded(gameCfg); ok {
+		if pids := descendants(procs, pid); len(pids) > 0 {
+			return pids, nil
+		}
+	}
+
+	m := newMatcher(gameCfg)
+	var pids []int
+	for _, proc := range procs {
+		if m.match(proc) {
+			pids = append(pids, proc.pid)
+		}
+	}
+	return pids, nil
+}
+
+// Record は起動したサーバのPIDを、プロセスの開始時刻とともにPIDファイルに記録します。
+// 開始時刻は、サーバの終了後にPIDが別のプロセスに再利用された場合に誤って検出しないために使用します。
+func (p *Finder) Record(gameCfg *domain.GameConfig, pid int) error {
+	if p.archonCfg == nil {
+		return nil
+	}
+	start, err := startTime(pid)
+	if err != nil {
+		return fmt.Errorf("サーバのプロセス (pid: %d) の開始時刻の取得に失敗しました: %w", pid, err)
+	}
+
+	path := p.archonCfg.PidFilePath(gameCfg.Name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("PIDファイルのディレクトリの作成に失敗しました: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(fmt.Sprintf("%d %d\n", pid, start)), 0o644); err != nil {
+		return fmt.Errorf("PIDファイル %s の書き込みに失敗しました: %w", path, err)
+	}
+	return nil
+}
+
+// recorded は Record で記録したPIDを返します。記録がない場合や、記録したプロセスが既に終了している場合は false を返します。
+func (p *Finder) recorded(gameCfg *domain.GameConfig) (int, bool) {
+	if p.archonCfg == nil {
+		return 0, false
+	}
+	data, err := os.ReadFile(p.archonCfg.PidFilePath(gameCfg.Name))
+	if err != nil {
+		return 0, false
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) != 2 {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, false
+	}
+	start, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	if current, err := startTime(pid); err != nil || current != start {
+		return 0, false
+	}
+	return pid, true
+}
+
+// descendants は pid のプロセスと、その子孫のプロセスのPIDを返します。pid のプロセスがない場合は空を返します。
+// wine/proton やサンドボックス経由で起動した場合、サーバ本体は記録したプロセスの子孫になります。
+func descendants(procs []procInfo, pid int) []int {
+	children := make(map[int][]int)
+	found := false
+	for _, proc := range procs {
+		children[proc.ppid] = append(children[proc.ppid], proc.pid)
+		found = found || proc.pid == pid
+	}
+	if !found {
+		return nil
+	}
+
+	pids := []int{pid}
+	for i := 0; i < len(pids); i++ {
+		pids = append(pids, children[pids[i]]...)
+	}
+	return pids
+}
+
+// matcher はプロセスがゲームサーバのものかを判定する条件です。
+type matcher struct {
+	installDir string
+	command    string
+}
+
+// newMatcher は GameConfig から判定条件を構築します。
+// インストールディレクトリ配下の実行ファイル・引数を持つプロセス、
+// またはカレントディレクトリがインストールディレクトリ配下で、run.command の実行ファイル名と一致するプロセスが対象です。
+// (java などインストールディレクトリ外の実行ファイルで起動する場合のため。実行ファイル名のみでは無関係のプロセスと一致するため、カレントディレクトリも確認します)
+func newMatcher(gameCfg *domain.GameConfig) matcher {
+	m := matcher{}
+	if gameCfg.InstallDir != "" {
+		if abs, err := filepath.Abs(gameCfg.InstallDir); err == nil {
+			m.installDir = abs
+		}
+	}
+	if gameCfg.Run != nil {
+		if fields := strings.Fields(gameCfg.Run.Command); len(fields) > 0 {
+			m.command = filepath.Base(fields[0])
+		}
+	}
+	return m
+}
+
+// maxMatchArgs は判定に使う引数の数です。
+// wine/proton 経由の場合、実行ファイルは第1, 第2引数として渡されます。
+// エディタ等でインストールディレクトリ内のファイルを開いているだけのプロセスを除外するため、それ以降の引数は見ません。
+const maxMatchArgs = 2
+
+// match はプロセスがゲームサーバのものかを判定します。
+func (m matcher) match(proc procInfo) bool {
+	if m.installDir == "" {
+		return false
+	}
+	if m.underInstallDir(proc.exe) {
+		return true
+	}
+	args := proc.args
+	if len(args) > maxMatchArgs {
+		args = args[:maxMatchArgs]
+	}
+	for _, arg := range args {
+		if m.underInstallDir(fromWinePath(arg)) {
+			return true
+		}
+	}
+
+	if !m.underInstallDir(proc.cwd) {
+		return false
+	}
+	for _, path := range append([]string{proc.exe}, args...) {
+		if m.matchCommand(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchCommand は path のファイル名が run.command の実行ファイル名と一致するかを判定します。
+func (m matcher) matchCommand(path string) bool {
+	return m.command != "" && path != "" && (filepath.Base(path) == m.command || windowsBase(path) == m.command)
+}
+
+// underInstallDir は path がインストールディレクトリ配下の絶対パスかを判定します。
+func (m matcher) underInstallDir(path string) bool {
+	return path != "" && filepath.IsAbs(path) && isUnder(m.installDir, path)
+}
+
+// isUnder は path が dir 配下にあるかを判定します。
+func isUnder(dir, path string) bool {
+	path = filepath.Clean(path)
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// fromWinePath は wine の Z: ドライブ (ルートディレクトリ) 形式のパスをUnix形式に変換します。
+// それ以外のパスはそのまま返します。
+func fromWinePath(path string) string {
+	if len(path) >= 3 && (path[0] == 'Z' || path[0] == 'z') && path[1] == ':' && path[2] == '\\' {
+		return "/" + strings.ReplaceAll(path[3:], `\`, "/")
+	}
+	return path
+}
+
+// windowsBase は "\" 区切りのパスのファイル名部分を返します。
+func windowsBase(path string) string {
+	if i := strings.LastIndex(path, `\`); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
@@ -0,0 +1,83 @@
+//go:build linux
+
+package process
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// listProcesses は /proc からプロセスの一覧を取得します。archon 自身は除外します。
+func listProcesses() ([]procInfo, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, fmt.Errorf("/proc の読み込みに失敗しました: %w", err)
+	}
+
+	self := os.Getpid()
+	var procs []procInfo
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid == self {
+			continue
+		}
+
+		procDir := filepath.Join("/proc", entry.Name())
+		// 権限がない, 既に終了した等で読めないプロセスは無視する
+		stat, err := readStat(pid)
+		if err != nil {
+			continue
+		}
+		cmdline, err := os.ReadFile(filepath.Join(procDir, "cmdline"))
+		if err != nil {
+			continue
+		}
+		proc := procInfo{pid: pid}
+		proc.exe, _ = os.Readlink(filepath.Join(procDir, "exe"))
+		proc.cwd, _ = os.Readlink(filepath.Join(procDir, "cwd"))
+		proc.ppid, _ = strconv.Atoi(stat[statPPID])
+		for _, arg := range bytes.Split(bytes.TrimRight(cmdline, "\x00"), []byte{0}) {
+			proc.args = append(proc.args, string(arg))
+		}
+		procs = append(procs, proc)
+	}
+
+	return procs, nil
+}
+
+// /proc/<pid>/stat のコマンド名より後のフィールドの位置 (proc(5) のフィールド番号 - 3)
+const (
+	statPPID      = 1
+	statStartTime = 19
+)
+
+// startTime はプロセスの開始時刻 (起動からのクロック数) を返します。
+func startTime(pid int) (uint64, error) {
+	stat, err := readStat(pid)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(stat[statStartTime], 10, 64)
+}
+
+// readStat は /proc/<pid>/stat のコマンド名より後のフィールドを返します。
+// コマンド名は空白や括弧を含む場合があるため、最後の ")" 以降を分割します。
+func readStat(pid int) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return nil, err
+	}
+	i := bytes.LastIndexByte(data, ')')
+	if i < 0 {
+		return nil, fmt.Errorf("/proc/%d/stat の形式が不正です", pid)
+	}
+	fields := strings.Fields(string(data[i+1:]))
+	if len(fields) <= statStartTime {
+		return nil, fmt.Errorf("/proc/%d/stat の形式が不正です", pid)
+	}
+	return fields, nil
+}
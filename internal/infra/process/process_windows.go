@@ -0,0 +1,81 @@
+//go:build windows
+
+package process
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// listProcesses はプロセス一覧を取得します。archon 自身は除外します。
+// カレントディレクトリ, 引数は取得しません。
+func listProcesses() ([]procInfo, error) {
+	snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return nil, fmt.Errorf("プロセス一覧の取得に失敗しました: %w", err)
+	}
+	defer func(h windows.Handle) {
+		_ = windows.CloseHandle(h)
+	}(snapshot)
+
+	self := uint32(os.Getpid()) // nolint:gosec // G115 pid fits in uint32
+	var procs []procInfo
+	var entry windows.ProcessEntry32
+	entry.Size = uint32(unsafe.Sizeof(entry))
+
+	err = windows.Process32First(snapshot, &entry)
+	for err == nil {
+		if entry.ProcessID != self {
+			exe := queryImagePath(entry.ProcessID)
+			if exe == "" {
+				exe = windows.UTF16ToString(entry.ExeFile[:])
+			}
+			procs = append(procs, procInfo{pid: int(entry.ProcessID), ppid: int(entry.ParentProcessID), exe: exe})
+		}
+		err = windows.Process32Next(snapshot, &entry)
+	}
+	if !errors.Is(err, windows.ERROR_NO_MORE_FILES) {
+		return nil, fmt.Errorf("プロセス一覧の読み込みに失敗しました: %w", err)
+	}
+
+	return procs, nil
+}
+
+// startTime はプロセスの作成時刻 (1601年からの100ナノ秒単位) を返します。
+func startTime(pid int) (uint64, error) {
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid)) // nolint:gosec // G115 pid fits in uint32
+	if err != nil {
+		return 0, err
+	}
+	defer func(h windows.Handle) {
+		_ = windows.CloseHandle(h)
+	}(h)
+
+	var creation, exit, kernel, user windows.Filetime
+	if err := windows.GetProcessTimes(h, &creation, &exit, &kernel, &user); err != nil {
+		return 0, err
+	}
+	return uint64(creation.HighDateTime)<<32 | uint64(creation.LowDateTime), nil
+}
+
+// queryImagePath はプロセスの実行ファイルのフルパスを返します。取得できない場合は空文字列を返します。
+func queryImagePath(pid uint32) string {
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, pid)
+	if err != nil {
+		return ""
+	}
+	defer func(h windows.Handle) {
+		_ = windows.CloseHandle(h)
+	}(h)
+
+	buf := make([]uint16, windows.MAX_LONG_PATH)
+	size := uint32(len(buf))
+	if err := windows.QueryFullProcessImageName(h, 0, &buf[0], &size); err != nil {
+		return ""
+	}
+	return windows.UTF16ToString(buf[:size])
+}
@@ -0,0 +1,128 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+	"github.com/nonuplet/grimoire-archon/internal/infra/download"
+	"github.com/nonuplet/grimoire-archon/internal/infra/filesystem"
+)
+
+// HTTP install.url のアーカイブによるインストール
+// Terraria の専用サーバのように、zip や tar で配布されているゲームに使用します。
+type HTTP struct {
+	fs          *filesystem.FileSystem
+	downloader  *download.Downloader
+	downloadDir string
+}
+
+// NewHTTP HTTPのインスタンスを生成する
+// downloadDir 以下にゲームごとのディレクトリを作成し、ダウンロードしたアーカイブを保存します。
+func NewHTTP(fs *filesystem.FileSystem, downloader *download.Downloader, downloadDir string) *HTTP {
+	return &HTTP{
+		fs:          fs,
+		downloader:  downloader,
+		downloadDir: downloadDir,
+	}
+}
+
+// Check 外部コマンドを使用しないため、常に成功する
+func (p *HTTP) Check() error {
+	return nil
+}
+
+// Install アーカイブをダウンロードしてSHA-256を確認し、install_dir に展開する
+// install.archive_dir が指定されている場合は、アーカイブ内のそのディレクトリの中身のみを展開する。
+func (p *HTTP) Install(ctx context.Context, gameCfg *domain.GameConfig) error {
+	install := gameCfg.Install
+	dir := filepath.Join(p.downloadDir, gameCfg.Name)
+	archive := filepath.Join(dir, install.ArchiveName())
+
+	// 同じアーカイブをダウンロード済みであれば再利用する
+	if err := verifyChecksum(p.fs, archive, sha256.New(), install.SHA256); err == nil {
+		fmt.Printf("ダウンロード済みの %s を使用します。\n", install.ArchiveName())
+	} else {
+		fmt.Printf("%s をダウンロードしています...\n", install.URL)
+		if err := p.downloader.Download(ctx, install.URL, archive); err != nil {
+			return err
+		}
+		if err := verifyChecksum(p.fs, archive, sha256.New(), install.SHA256); err != nil {
+			if rmErr := p.fs.RemoveAll(archive); rmErr != nil {
+				fmt.Fprintf(os.Stderr, "ダウンロードしたアーカイブの削除に失敗しました: %v\n", rmErr)
+			}
+			return err
+		}
+	}
+
+	extractDir := filepath.Join(dir, "extract")
+	if err := p.fs.RemoveAll(extractDir); err != nil {
+		return fmt.Errorf("展開先のディレクトリの削除に失敗しました: %w", err)
+	}
+	defer func() {
+		if err := p.fs.RemoveAll(extractDir); err != nil {
+			fmt.Fprintf(os.Stderr, "展開先のディレクトリの削除に失敗しました: %v\n", err)
+		}
+	}()
+
+	fmt.Printf("%s を展開しています...\n", install.ArchiveName())
+	switch install.GetFormat() {
+	case domain.ArchiveZip:
+		if err := p.fs.Unzip(ctx, archive, extractDir, false); err != nil {
+			return fmt.Errorf("アーカイブの展開に失敗しました: %w", err)
+		}
+	case domain.ArchiveTar:
+		if err := p.fs.Untar(ctx, archive, extractDir); err != nil {
+			return fmt.Errorf("アーカイブの展開に失敗しました: %w", err)
+		}
+	}
+
+	src := filepath.Join(extractDir, install.ArchiveDir)
+	entries, err := p.fs.ReadDir(src)
+	if err != nil {
+		return fmt.Errorf("アーカイブ内の %s が見つかりません: %w", install.ArchiveDir, err)
+	}
+
+	// install_dir 自体の権限を変えないよう、中身を1つずつコピーする
+	var files []string
+	for _, entry := range entries {
+		dst := filepath.Join(gameCfg.InstallDir, entry.Name())
+		if err := p.fs.CopyFileOrDir(ctx, filepath.Join(src, entry.Name()), dst, true); err != nil {
+			return fmt.Errorf("%s のインストールに失敗しました: %w", entry.Name(), err)
+		}
+		files = append(files, entry.Name())
+	}
+
+	return writeRecord(p.fs, gameCfg, &domain.InstallRecord{
+		Provider: domain.InstallProviderHTTP,
+		Version:  install.HTTPVersion(),
+		Source:   install.URL,
+		Files:    files,
+	})
+}
+
+// InstalledVersion インストール済みのバージョンを返す。未インストールの場合は空文字列を返す。
+func (p *HTTP) InstalledVersion(gameCfg *domain.GameConfig) (string, error) {
+	return recordedVersion(p.fs, gameCfg)
+}
+
+// Status インストール済みのバージョンと、コンフィグで指定されたバージョンを比較する
+func (p *HTTP) Status(_ context.Context, gameCfg *domain.GameConfig) (*domain.UpdateStatus, error) {
+	installed, err := recordedVersion(p.fs, gameCfg)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.UpdateStatus{InstalledVersion: installed, LatestVersion: gameCfg.Install.HTTPVersion()}, nil
+}
+
+// VersionFiles バージョンの記録と、前回インストールしたファイルを返す
+func (p *HTTP) VersionFiles(gameCfg *domain.GameConfig) []string {
+	files := []string{domain.InstalledVersionFile}
+	if record, err := readRecord(p.fs, gameCfg); err == nil && record != nil {
+		files = append(files, record.Files...)
+	}
+	return files
+}
@@ -0,0 +1,120 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+// sha256Hex は data のSHA-256を16進数の文字列で返す
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestHTTPInstallZip(t *testing.T) {
+	archive := zipArchive(t, map[string]string{
+		"server/TerrariaServer.bin": "binary",
+		"server/Content/data.xnb":   "content",
+		"readme.txt":                "readme",
+	})
+	server := newTestServer(t, map[string][]byte{"/terraria-server-1449.zip": archive})
+
+	fs, downloader, downloadDir := newTestDeps(t)
+	gameCfg := newTestGame(t, &domain.InstallConfig{
+		Provider:   domain.InstallProviderHTTP,
+		URL:        server.URL + "/terraria-server-1449.zip",
+		SHA256:     sha256Hex(archive),
+		ArchiveDir: "server",
+		Version:    "1.4.4.9",
+	})
+
+	if err := NewHTTP(fs, downloader, downloadDir).Install(context.Background(), gameCfg); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+
+	assertFile(t, filepath.Join(gameCfg.InstallDir, "TerrariaServer.bin"), "binary")
+	assertFile(t, filepath.Join(gameCfg.InstallDir, "Content", "data.xnb"), "content")
+	if _, err := os.Stat(filepath.Join(gameCfg.InstallDir, "readme.txt")); !os.IsNotExist(err) {
+		t.Errorf("archive_dir の外のファイルがインストールされています: %v", err)
+	}
+	assertRecordedVersion(t, fs, gameCfg, "1.4.4.9")
+}
+
+func TestHTTPInstallTar(t *testing.T) {
+	archive := tarGzArchive(t, map[string]string{"bin/server": "#!/bin/sh\n"})
+	server := newTestServer(t, map[string][]byte{"/server.tar.gz": archive})
+
+	fs, downloader, downloadDir := newTestDeps(t)
+	gameCfg := newTestGame(t, &domain.InstallConfig{
+		Provider: domain.InstallProviderHTTP,
+		URL:      server.URL + "/server.tar.gz",
+		SHA256:   sha256Hex(archive),
+	})
+
+	if err := NewHTTP(fs, downloader, downloadDir).Install(context.Background(), gameCfg); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+
+	path := filepath.Join(gameCfg.InstallDir, "bin", "server")
+	assertFile(t, path, "#!/bin/sh\n")
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm()&0o100 == 0 {
+		t.Errorf("実行権限が維持されていません: %v, %v", info, err)
+	}
+	// install.version が未設定の場合は sha256 の先頭12文字
+	assertRecordedVersion(t, fs, gameCfg, sha256Hex(archive)[:12])
+}
+
+func TestHTTPInstallChecksumMismatch(t *testing.T) {
+	archive := zipArchive(t, map[string]string{"server.bin": "binary"})
+	server := newTestServer(t, map[string][]byte{"/server.zip": archive})
+
+	fs, downloader, downloadDir := newTestDeps(t)
+	gameCfg := newTestGame(t, &domain.InstallConfig{
+		Provider: domain.InstallProviderHTTP,
+		URL:      server.URL + "/server.zip",
+		SHA256:   sha256Hex([]byte("other")),
+	})
+
+	if err := NewHTTP(fs, downloader, downloadDir).Install(context.Background(), gameCfg); err == nil {
+		t.Fatal("Install() error = nil, want checksum error")
+	}
+	if _, err := os.Stat(filepath.Join(gameCfg.InstallDir, "server.bin")); !os.IsNotExist(err) {
+		t.Errorf("チェックサムが一致しないアーカイブがインストールされています: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(downloadDir, gameCfg.Name, "server.zip")); !os.IsNotExist(err) {
+		t.Errorf("チェックサムが一致しないアーカイブが残っています: %v", err)
+	}
+}
+
+func TestHTTPInstallReusesDownloadedArchive(t *testing.T) {
+	archive := zipArchive(t, map[string]string{"server.bin": "binary"})
+	routes := map[string][]byte{"/server.zip": archive}
+	server := newTestServer(t, routes)
+
+	fs, downloader, downloadDir := newTestDeps(t)
+	gameCfg := newTestGame(t, &domain.InstallConfig{
+		Provider: domain.InstallProviderHTTP,
+		URL:      server.URL + "/server.zip",
+		SHA256:   sha256Hex(archive),
+	})
+	provider := NewHTTP(fs, downloader, downloadDir)
+	if err := provider.Install(context.Background(), gameCfg); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+
+	// 配布元から消えても、ダウンロード済みのアーカイブでインストールできる
+	delete(routes, "/server.zip")
+	if err := os.RemoveAll(gameCfg.InstallDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := provider.Install(context.Background(), gameCfg); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	assertFile(t, filepath.Join(gameCfg.InstallDir, "server.bin"), "binary")
+}
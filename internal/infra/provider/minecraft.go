@@ -0,0 +1,146 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha1" // nolint:gosec // Mojangが配布するチェックサムがSHA-1のため
+	"fmt"
+	"path/filepath"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+	"github.com/nonuplet/grimoire-archon/internal/infra/download"
+	"github.com/nonuplet/grimoire-archon/internal/infra/filesystem"
+)
+
+// DefaultMinecraftManifestURL はMojangのバージョンマニフェストのURLです。
+const DefaultMinecraftManifestURL = "https://piston-meta.mojang.com/mc/game/version_manifest_v2.json"
+
+// Minecraft Mojangのバージョンマニフェストによる、Minecraftのバニラサーバのインストール
+type Minecraft struct {
+	fs          *filesystem.FileSystem
+	downloader  *download.Downloader
+	downloadDir string
+}
+
+// NewMinecraft Minecraftのインスタンスを生成する
+// downloadDir 以下にゲームごとのディレクトリを作成し、ダウンロードしたjarを保存します。
+func NewMinecraft(fs *filesystem.FileSystem, downloader *download.Downloader, downloadDir string) *Minecraft {
+	return &Minecraft{
+		fs:          fs,
+		downloader:  downloader,
+		downloadDir: downloadDir,
+	}
+}
+
+// versionManifest はバージョンマニフェストのうち、使用する項目です。
+type versionManifest struct {
+	Latest struct {
+		Release  string `json:"release"`
+		Snapshot string `json:"snapshot"`
+	} `json:"latest"`
+	Versions []struct {
+		ID  string `json:"id"`
+		URL string `json:"url"`
+	} `json:"versions"`
+}
+
+// versionInfo はバージョンごとの情報のうち、使用する項目です。
+type versionInfo struct {
+	Downloads struct {
+		Server *struct {
+			SHA1 string `json:"sha1"`
+			URL  string `json:"url"`
+		} `json:"server"`
+	} `json:"downloads"`
+}
+
+// Check 外部コマンドを使用しないため、常に成功する
+func (p *Minecraft) Check() error {
+	return nil
+}
+
+// Install install.version のサーバのjarをダウンロードしてSHA-1を確認し、install_dir に install.jar として配置する
+func (p *Minecraft) Install(ctx context.Context, gameCfg *domain.GameConfig) error {
+	id, infoURL, err := p.resolve(ctx, gameCfg)
+	if err != nil {
+		return err
+	}
+
+	var info versionInfo
+	if err := p.downloader.FetchJSON(ctx, infoURL, &info); err != nil {
+		return fmt.Errorf("バージョン %s の情報の取得に失敗しました: %w", id, err)
+	}
+	server := info.Downloads.Server
+	if server == nil {
+		return fmt.Errorf("バージョン %s はサーバのjarが配布されていません", id)
+	}
+
+	jar := gameCfg.Install.GetJar()
+	fmt.Printf("Minecraft %s のサーバをダウンロードしています...\n", id)
+	tmp := filepath.Join(p.downloadDir, gameCfg.Name, fmt.Sprintf("minecraft_server.%s.jar", id))
+	if err := p.downloader.Download(ctx, server.URL, tmp); err != nil {
+		return err
+	}
+	if err := verifyChecksum(p.fs, tmp, sha1.New(), server.SHA1); err != nil { // nolint:gosec // 同上
+		return err
+	}
+	if err := p.fs.CopyFileOrDir(ctx, tmp, filepath.Join(gameCfg.InstallDir, jar), true); err != nil {
+		return fmt.Errorf("%s の配置に失敗しました: %w", jar, err)
+	}
+
+	return writeRecord(p.fs, gameCfg, &domain.InstallRecord{
+		Provider: domain.InstallProviderMinecraft,
+		Version:  id,
+		Source:   server.URL,
+		Files:    []string{jar},
+	})
+}
+
+// InstalledVersion インストール済みのバージョンを返す。未インストールの場合は空文字列を返す。
+func (p *Minecraft) InstalledVersion(gameCfg *domain.GameConfig) (string, error) {
+	return recordedVersion(p.fs, gameCfg)
+}
+
+// Status インストール済みのバージョンと、install.version が指すバージョンを比較する
+func (p *Minecraft) Status(ctx context.Context, gameCfg *domain.GameConfig) (*domain.UpdateStatus, error) {
+	installed, err := recordedVersion(p.fs, gameCfg)
+	if err != nil {
+		return nil, err
+	}
+	latest, _, err := p.resolve(ctx, gameCfg)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.UpdateStatus{InstalledVersion: installed, LatestVersion: latest}, nil
+}
+
+// VersionFiles バージョンの記録とサーバのjarを返す
+func (p *Minecraft) VersionFiles(gameCfg *domain.GameConfig) []string {
+	return []string{domain.InstalledVersionFile, gameCfg.Install.GetJar()}
+}
+
+// resolve install.version をバージョンマニフェストから探し、バージョンとその情報のURLを返す
+func (p *Minecraft) resolve(ctx context.Context, gameCfg *domain.GameConfig) (string, string, error) {
+	manifestURL := DefaultMinecraftManifestURL
+	if gameCfg.Install.APIURL != "" {
+		manifestURL = gameCfg.Install.APIURL
+	}
+
+	var manifest versionManifest
+	if err := p.downloader.FetchJSON(ctx, manifestURL, &manifest); err != nil {
+		return "", "", fmt.Errorf("バージョンマニフェストの取得に失敗しました: %w", err)
+	}
+
+	id := gameCfg.Install.GetVersion()
+	switch id {
+	case domain.LatestVersion:
+		id = manifest.Latest.Release
+	case domain.MinecraftSnapshot:
+		id = manifest.Latest.Snapshot
+	}
+	for _, version := range manifest.Versions {
+		if version.ID == id {
+			return id, version.URL, nil
+		}
+	}
+	return "", "", fmt.Errorf("バージョン %s がバージョンマニフェストに見つかりません", id)
+}
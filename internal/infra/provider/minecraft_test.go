@@ -0,0 +1,110 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha1" // nolint:gosec // Mojang のマニフェストが SHA-1 のため
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+// newMinecraftServer はバージョンマニフェストと、release, snapshot の2つのバージョンのサーバのjarを返すサーバを起動する
+func newMinecraftServer(t *testing.T, release, snapshot string) string {
+	t.Helper()
+
+	routes := map[string][]byte{}
+	server := newTestServer(t, routes)
+
+	var versions string
+	for i, id := range []string{snapshot, release} {
+		jar := []byte("jar " + id)
+		sum := sha1.Sum(jar) // nolint:gosec // 同上
+		routes["/jar/"+id+".jar"] = jar
+		routes["/v1/"+id+".json"] = fmt.Appendf(nil,
+			`{"downloads":{"server":{"sha1":%q,"url":%q}}}`, hex.EncodeToString(sum[:]), server.URL+"/jar/"+id+".jar")
+		if i > 0 {
+			versions += ","
+		}
+		versions += fmt.Sprintf(`{"id":%q,"url":%q}`, id, server.URL+"/v1/"+id+".json")
+	}
+	routes["/manifest.json"] = fmt.Appendf(nil,
+		`{"latest":{"release":%q,"snapshot":%q},"versions":[%s]}`, release, snapshot, versions)
+	return server.URL + "/manifest.json"
+}
+
+func TestMinecraftInstall(t *testing.T) {
+	manifestURL := newMinecraftServer(t, "1.21.4", "25w02a")
+
+	tests := []struct {
+		name    string
+		version string
+		jar     string
+		want    string
+	}{
+		{name: "latest", version: "", want: "1.21.4"},
+		{name: "snapshot", version: domain.MinecraftSnapshot, want: "25w02a"},
+		{name: "id", version: "1.21.4", jar: "minecraft.jar", want: "1.21.4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs, downloader, downloadDir := newTestDeps(t)
+			gameCfg := newTestGame(t, &domain.InstallConfig{
+				Provider: domain.InstallProviderMinecraft,
+				Version:  tt.version,
+				Jar:      tt.jar,
+				APIURL:   manifestURL,
+			})
+
+			if err := NewMinecraft(fs, downloader, downloadDir).Install(context.Background(), gameCfg); err != nil {
+				t.Fatalf("Install() error = %v", err)
+			}
+			assertFile(t, filepath.Join(gameCfg.InstallDir, gameCfg.Install.GetJar()), "jar "+tt.want)
+			assertRecordedVersion(t, fs, gameCfg, tt.want)
+		})
+	}
+}
+
+func TestMinecraftInstallUnknownVersion(t *testing.T) {
+	fs, downloader, downloadDir := newTestDeps(t)
+	gameCfg := newTestGame(t, &domain.InstallConfig{
+		Provider: domain.InstallProviderMinecraft,
+		Version:  "1.0.0",
+		APIURL:   newMinecraftServer(t, "1.21.4", "25w02a"),
+	})
+
+	if err := NewMinecraft(fs, downloader, downloadDir).Install(context.Background(), gameCfg); err == nil {
+		t.Fatal("Install() error = nil, want error")
+	}
+}
+
+func TestMinecraftStatus(t *testing.T) {
+	fs, downloader, downloadDir := newTestDeps(t)
+	gameCfg := newTestGame(t, &domain.InstallConfig{
+		Provider: domain.InstallProviderMinecraft,
+		APIURL:   newMinecraftServer(t, "1.21.4", "25w02a"),
+	})
+	provider := NewMinecraft(fs, downloader, downloadDir)
+
+	status, err := provider.Status(context.Background(), gameCfg)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if status.InstalledVersion != "" || status.LatestVersion != "1.21.4" {
+		t.Errorf("Status() = %+v, want installed \"\", latest 1.21.4", status)
+	}
+
+	if err := provider.Install(context.Background(), gameCfg); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	status, err = provider.Status(context.Background(), gameCfg)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if status.Available() {
+		t.Errorf("Status().Available() = true after install: %+v", status)
+	}
+}
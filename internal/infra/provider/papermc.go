@@ -0,0 +1,234 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+	"github.com/nonuplet/grimoire-archon/internal/infra/download"
+	"github.com/nonuplet/grimoire-archon/internal/infra/filesystem"
+)
+
+// DefaultPaperMCAPIURL はPaperMCのビルドAPIのURLです。
+const DefaultPaperMCAPIURL = "https://fill.papermc.io/v3"
+
+// paperStableChannel は安定版のビルドのチャンネルです。
+const paperStableChannel = "STABLE"
+
+// paperDownloadKey はサーバのjarのダウンロードのキーです。
+const paperDownloadKey = "server:default"
+
+// PaperMC PaperMCのビルドAPIによる、Paperなどのサーバのインストール
+type PaperMC struct {
+	fs          *filesystem.FileSystem
+	downloader  *download.Downloader
+	downloadDir string
+}
+
+// NewPaperMC PaperMCのインスタンスを生成する
+// downloadDir 以下にゲームごとのディレクトリを作成し、ダウンロードしたjarを保存します。
+func NewPaperMC(fs *filesystem.FileSystem, downloader *download.Downloader, downloadDir string) *PaperMC {
+	return &PaperMC{
+		fs:          fs,
+		downloader:  downloader,
+		downloadDir: downloadDir,
+	}
+}
+
+// paperVersions はバージョン一覧のレスポンスのうち、使用する項目です。
+type paperVersions struct {
+	Versions []struct {
+		Version struct {
+			ID string `json:"id"`
+		} `json:"version"`
+		Builds []int `json:"builds"`
+	} `json:"versions"`
+}
+
+// paperBuild はビルドの情報のうち、使用する項目です。
+type paperBuild struct {
+	Downloads map[string]struct {
+		Checksums struct {
+			SHA256 string `json:"sha256"`
+		} `json:"checksums"`
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"downloads"`
+	Channel string `json:"channel"`
+	ID      int    `json:"id"`
+}
+
+// paperRelease はインストールするビルドです。
+type paperRelease struct {
+	version string
+	build   paperBuild
+}
+
+// label はバージョンとビルド番号を組み合わせた、インストール済みのバージョンとして記録する文字列を返します。
+func (r *paperRelease) label() string {
+	return fmt.Sprintf("%s-%d", r.version, r.build.ID)
+}
+
+// Check 外部コマンドを使用しないため、常に成功する
+func (p *PaperMC) Check() error {
+	return nil
+}
+
+// Install install.version の最新のビルドをダウンロードしてSHA-256を確認し、install_dir に install.jar として配置する
+func (p *PaperMC) Install(ctx context.Context, gameCfg *domain.GameConfig) error {
+	release, err := p.resolve(ctx, gameCfg)
+	if err != nil {
+		return err
+	}
+
+	dl, ok := release.build.Downloads[paperDownloadKey]
+	if !ok {
+		return fmt.Errorf("ビルド %s にサーバのjarが見つかりません", release.label())
+	}
+	if release.build.Channel != paperStableChannel {
+		fmt.Printf("ビルド %s は安定版ではありません。(チャンネル: %s)\n", release.label(), release.build.Channel)
+	}
+
+	jar := gameCfg.Install.GetJar()
+	fmt.Printf("%s %s をダウンロードしています...\n", gameCfg.Install.GetProject(), release.label())
+	tmp := filepath.Join(p.downloadDir, gameCfg.Name, filepath.Base(dl.Name))
+	if err := p.downloader.Download(ctx, dl.URL, tmp); err != nil {
+		return err
+	}
+	if err := verifyChecksum(p.fs, tmp, sha256.New(), dl.Checksums.SHA256); err != nil {
+		return err
+	}
+	if err := p.fs.CopyFileOrDir(ctx, tmp, filepath.Join(gameCfg.InstallDir, jar), true); err != nil {
+		return fmt.Errorf("%s の配置に失敗しました: %w", jar, err)
+	}
+
+	return writeRecord(p.fs, gameCfg, &domain.InstallRecord{
+		Provider: domain.InstallProviderPaperMC,
+		Version:  release.label(),
+		Source:   dl.URL,
+		Files:    []string{jar},
+	})
+}
+
+// InstalledVersion インストール済みのバージョンを返す。未インストールの場合は空文字列を返す。
+func (p *PaperMC) InstalledVersion(gameCfg *domain.GameConfig) (string, error) {
+	return recordedVersion(p.fs, gameCfg)
+}
+
+// Status インストール済みのバージョンと、install.version の最新のビルドを比較する
+func (p *PaperMC) Status(ctx context.Context, gameCfg *domain.GameConfig) (*domain.UpdateStatus, error) {
+	installed, err := recordedVersion(p.fs, gameCfg)
+	if err != nil {
+		return nil, err
+	}
+	release, err := p.resolve(ctx, gameCfg)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.UpdateStatus{InstalledVersion: installed, LatestVersion: release.label()}, nil
+}
+
+// VersionFiles バージョンの記録とサーバのjarを返す
+func (p *PaperMC) VersionFiles(gameCfg *domain.GameConfig) []string {
+	return []string{domain.InstalledVersionFile, gameCfg.Install.GetJar()}
+}
+
+// resolve install.version のインストールするビルドを返す
+// 安定版のビルドがあれば最新の安定版を、なければ最新のビルドを選ぶ。
+func (p *PaperMC) resolve(ctx context.Context, gameCfg *domain.GameConfig) (*paperRelease, error) {
+	projectURL := p.apiURL(gameCfg) + "/projects/" + url.PathEscape(gameCfg.Install.GetProject())
+
+	version := gameCfg.Install.GetVersion()
+	if version == domain.LatestVersion {
+		var err error
+		if version, err = p.latestVersion(ctx, projectURL); err != nil {
+			return nil, err
+		}
+	}
+
+	var builds []paperBuild
+	if err := p.downloader.FetchJSON(ctx, projectURL+"/versions/"+url.PathEscape(version)+"/builds", &builds); err != nil {
+		return nil, fmt.Errorf("バージョン %s のビルドの取得に失敗しました: %w", version, err)
+	}
+
+	var latest, stable *paperBuild
+	for i := range builds {
+		build := &builds[i]
+		if latest == nil || build.ID > latest.ID {
+			latest = build
+		}
+		if build.Channel == paperStableChannel && (stable == nil || build.ID > stable.ID) {
+			stable = build
+		}
+	}
+	switch {
+	case stable != nil:
+		return &paperRelease{version: version, build: *stable}, nil
+	case latest != nil:
+		return &paperRelease{version: version, build: *latest}, nil
+	default:
+		return nil, fmt.Errorf("バージョン %s のビルドが見つかりません", version)
+	}
+}
+
+// latestVersion ビルドのある最新のリリースバージョンを返す
+// プレリリース(1.21-pre1 など)は対象外とする。
+func (p *PaperMC) latestVersion(ctx context.Context, projectURL string) (string, error) {
+	var versions paperVersions
+	if err := p.downloader.FetchJSON(ctx, projectURL+"/versions", &versions); err != nil {
+		return "", fmt.Errorf("バージョンの一覧の取得に失敗しました: %w", err)
+	}
+
+	var latest string
+	for _, version := range versions.Versions {
+		id := version.Version.ID
+		if len(version.Builds) == 0 || strings.Contains(id, "-") {
+			continue
+		}
+		if latest == "" || compareVersion(id, latest) > 0 {
+			latest = id
+		}
+	}
+	if latest == "" {
+		return "", fmt.Errorf("リリースされたバージョンが見つかりません")
+	}
+	return latest, nil
+}
+
+// apiURL PaperMCのAPIのURLを返す
+func (p *PaperMC) apiURL(gameCfg *domain.GameConfig) string {
+	if gameCfg.Install.APIURL != "" {
+		return strings.TrimSuffix(gameCfg.Install.APIURL, "/")
+	}
+	return DefaultPaperMCAPIURL
+}
+
+// compareVersion ドット区切りのバージョンを数値として比較する
+// a が新しい場合は正、古い場合は負、同じ場合は0を返す。数値でない部分は文字列として比較する。
+func compareVersion(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y string
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+
+		xn, xErr := strconv.Atoi(x)
+		yn, yErr := strconv.Atoi(y)
+		switch {
+		case xErr == nil && yErr == nil && xn != yn:
+			return xn - yn
+		case (xErr != nil || yErr != nil) && x != y:
+			return strings.Compare(x, y)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,104 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+// paperTestBuild はテスト用のPaperMCのビルドです。
+type paperTestBuild struct {
+	id      int
+	channel string
+}
+
+// newPaperMCServer は versions のバージョンの一覧と、builds のバージョンのビルドを返すPaperMCのAPIを起動する
+// ビルドのjarの内容は "<バージョン>-<ビルド番号>" とする。
+func newPaperMCServer(t *testing.T, versions string, builds map[string][]paperTestBuild) string {
+	t.Helper()
+
+	routes := map[string][]byte{"/v3/projects/paper/versions": []byte(versions)}
+	server := newTestServer(t, routes)
+
+	for version, list := range builds {
+		var body string
+		for i, build := range list {
+			name := fmt.Sprintf("paper-%s-%d.jar", version, build.id)
+			jar := fmt.Appendf(nil, "%s-%d", version, build.id)
+			routes["/jar/"+name] = jar
+			if i > 0 {
+				body += ","
+			}
+			body += fmt.Sprintf(`{"id":%d,"channel":%q,"downloads":{"server:default":{"name":%q,"url":%q,"checksums":{"sha256":%q}}}}`,
+				build.id, build.channel, name, server.URL+"/jar/"+name, sha256Hex(jar))
+		}
+		routes["/v3/projects/paper/versions/"+version+"/builds"] = []byte("[" + body + "]")
+	}
+	return server.URL + "/v3/"
+}
+
+func TestPaperMCInstall(t *testing.T) {
+	versions := `{"versions":[
+		{"version":{"id":"1.21.5-pre1"},"builds":[1]},
+		{"version":{"id":"1.21.10"},"builds":[]},
+		{"version":{"id":"1.21.4"},"builds":[12,13]},
+		{"version":{"id":"1.20.6"},"builds":[151]}
+	]}`
+	apiURL := newPaperMCServer(t, versions, map[string][]paperTestBuild{
+		"1.21.4": {{id: 12, channel: "STABLE"}, {id: 13, channel: "BETA"}, {id: 11, channel: "STABLE"}},
+		"1.20.6": {{id: 150, channel: "ALPHA"}, {id: 151, channel: "BETA"}},
+	})
+
+	tests := []struct {
+		name    string
+		version string
+		want    string
+	}{
+		// ビルドのないバージョンとプレリリースは除外し、安定版のビルドを優先する
+		{name: "latest", version: "", want: "1.21.4-12"},
+		// 安定版がない場合は最新のビルド
+		{name: "no stable", version: "1.20.6", want: "1.20.6-151"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs, downloader, downloadDir := newTestDeps(t)
+			gameCfg := newTestGame(t, &domain.InstallConfig{
+				Provider: domain.InstallProviderPaperMC,
+				Version:  tt.version,
+				APIURL:   apiURL,
+			})
+
+			if err := NewPaperMC(fs, downloader, downloadDir).Install(context.Background(), gameCfg); err != nil {
+				t.Fatalf("Install() error = %v", err)
+			}
+			assertFile(t, filepath.Join(gameCfg.InstallDir, gameCfg.Install.GetJar()), tt.want)
+			assertRecordedVersion(t, fs, gameCfg, tt.want)
+		})
+	}
+}
+
+func TestPaperMCInstallChecksumMismatch(t *testing.T) {
+	routes := map[string][]byte{"/jar/paper.jar": []byte("jar")}
+	server := newTestServer(t, routes)
+	routes["/projects/paper/versions/1.21.4/builds"] = fmt.Appendf(nil,
+		`[{"id":12,"channel":"STABLE","downloads":{"server:default":{"name":"paper.jar","url":%q,"checksums":{"sha256":%q}}}}]`,
+		server.URL+"/jar/paper.jar", sha256Hex([]byte("other")))
+
+	fs, downloader, downloadDir := newTestDeps(t)
+	gameCfg := newTestGame(t, &domain.InstallConfig{
+		Provider: domain.InstallProviderPaperMC,
+		Version:  "1.21.4",
+		APIURL:   server.URL,
+	})
+
+	if err := NewPaperMC(fs, downloader, downloadDir).Install(context.Background(), gameCfg); err == nil {
+		t.Fatal("Install() error = nil, want checksum error")
+	}
+	if version, err := recordedVersion(fs, gameCfg); err != nil || version != "" {
+		t.Errorf("recordedVersion() = %q, %v, want empty", version, err)
+	}
+}
@@ -0,0 +1,73 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+	"github.com/nonuplet/grimoire-archon/internal/infra/filesystem"
+)
+
+// recordPath インストールしたバージョンの記録ファイルのパス
+func recordPath(gameCfg *domain.GameConfig) string {
+	return filepath.Join(gameCfg.InstallDir, domain.InstalledVersionFile)
+}
+
+// readRecord インストールしたバージョンの記録を読み込む
+// 未インストールの場合は nil を返す
+func readRecord(fs *filesystem.FileSystem, gameCfg *domain.GameConfig) (*domain.InstallRecord, error) {
+	data, err := fs.ReadFile(recordPath(gameCfg))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("バージョンの記録の読み込みに失敗しました: %w", err)
+	}
+
+	var record domain.InstallRecord
+	if err := yaml.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("バージョンの記録 %s のパースに失敗しました: %w", recordPath(gameCfg), err)
+	}
+	return &record, nil
+}
+
+// writeRecord インストールしたバージョンを記録する
+func writeRecord(fs *filesystem.FileSystem, gameCfg *domain.GameConfig, record *domain.InstallRecord) error {
+	record.InstalledAt = time.Now()
+	data, err := yaml.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("バージョンの記録のシリアライズに失敗しました: %w", err)
+	}
+	if err := fs.WriteFile(recordPath(gameCfg), data, 0o644); err != nil {
+		return fmt.Errorf("バージョンの記録の書き込みに失敗しました: %w", err)
+	}
+	return nil
+}
+
+// recordedVersion 記録されたバージョンを返す。未インストールの場合は空文字列を返す。
+func recordedVersion(fs *filesystem.FileSystem, gameCfg *domain.GameConfig) (string, error) {
+	record, err := readRecord(fs, gameCfg)
+	if err != nil || record == nil {
+		return "", err
+	}
+	return record.Version, nil
+}
+
+// verifyChecksum ファイルのハッシュ値が expected と一致するか確認する
+func verifyChecksum(fs *filesystem.FileSystem, path string, h hash.Hash, expected string) error {
+	actual, err := fs.Checksum(path, h)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(actual, expected) {
+		return fmt.Errorf("%s のチェックサムが一致しません (期待値: %s, 実際: %s)", filepath.Base(path), expected, actual)
+	}
+	return nil
+}
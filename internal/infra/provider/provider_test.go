@@ -0,0 +1,122 @@
+package provider
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+	"github.com/nonuplet/grimoire-archon/internal/infra/download"
+	"github.com/nonuplet/grimoire-archon/internal/infra/filesystem"
+)
+
+// newTestServer は routes のパスに対応する内容を返すHTTPサーバを起動する
+// 登録されていないパスには 404 を返す。
+func newTestServer(t *testing.T, routes map[string][]byte) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := routes[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newTestGame はテスト用の一時ディレクトリにインストールするゲームの設定を返す
+func newTestGame(t *testing.T, install *domain.InstallConfig) *domain.GameConfig {
+	t.Helper()
+	return &domain.GameConfig{
+		Name:       "test",
+		InstallDir: filepath.Join(t.TempDir(), "install"),
+		Install:    install,
+	}
+}
+
+// newTestDeps はプロバイダに渡すファイル操作、ダウンローダ、ダウンロード先を返す
+func newTestDeps(t *testing.T) (*filesystem.FileSystem, *download.Downloader, string) {
+	t.Helper()
+	return filesystem.NewFileSystem(), download.NewDownloader(), filepath.Join(t.TempDir(), "downloads")
+}
+
+// zipArchive は files (パスと内容) のzipを生成する
+func zipArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// tarGzArchive は files (パスと内容) の tar.gz を生成する
+func tarGzArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, body := range files {
+		header := &tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o755, Size: int64(len(body))}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// assertFile は path の内容が want であることを確認する
+func assertFile(t *testing.T, path, want string) {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%s が読み込めません: %v", path, err)
+	}
+	if string(data) != want {
+		t.Errorf("%s の内容 = %q, want %q", path, data, want)
+	}
+}
+
+// assertRecordedVersion はインストール済みのバージョンとして want が記録されていることを確認する
+func assertRecordedVersion(t *testing.T, fs *filesystem.FileSystem, gameCfg *domain.GameConfig, want string) {
+	t.Helper()
+
+	got, err := recordedVersion(fs, gameCfg)
+	if err != nil {
+		t.Fatalf("recordedVersion() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("recordedVersion() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,93 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+	"github.com/nonuplet/grimoire-archon/internal/infra/filesystem"
+	"github.com/nonuplet/grimoire-archon/internal/infra/steamcmd"
+)
+
+// Steam steamcmdによるインストール
+type Steam struct {
+	steamCmd *steamcmd.SteamCmd
+	fs       *filesystem.FileSystem
+}
+
+// NewSteam Steamのインスタンスを生成する
+func NewSteam(steamCmd *steamcmd.SteamCmd, fs *filesystem.FileSystem) *Steam {
+	return &Steam{
+		steamCmd: steamCmd,
+		fs:       fs,
+	}
+}
+
+// Check steamcmdコマンドの存在確認
+func (p *Steam) Check() error {
+	return p.steamCmd.Check()
+}
+
+// Install steam.branch のビルドをインストール/アップデートする
+func (p *Steam) Install(ctx context.Context, gameCfg *domain.GameConfig) error {
+	fmt.Printf("ブランチ %s に更新します。\n", gameCfg.Steam.GetBranch())
+	return p.steamCmd.Update(ctx, gameCfg.InstallDir, gameCfg.Steam)
+}
+
+// InstalledVersion インストール済みのビルドIDを返す。未インストールの場合は空文字列を返す。
+func (p *Steam) InstalledVersion(gameCfg *domain.GameConfig) (string, error) {
+	manifest, err := p.loadManifest(gameCfg)
+	if err != nil || manifest == nil {
+		return "", err
+	}
+	return manifest.BuildID, nil
+}
+
+// Status インストール済みのビルドと、steam.branch で配信中の最新ビルドを比較する
+func (p *Steam) Status(ctx context.Context, gameCfg *domain.GameConfig) (*domain.UpdateStatus, error) {
+	status := &domain.UpdateStatus{Branch: gameCfg.Steam.GetBranch()}
+
+	manifest, err := p.loadManifest(gameCfg)
+	if err != nil {
+		return nil, err
+	}
+	if manifest != nil {
+		status.InstalledVersion = manifest.BuildID
+		status.InstalledBranch = manifest.Branch
+	}
+
+	latest, err := p.steamCmd.LatestBuildID(ctx, gameCfg.Steam)
+	if err != nil {
+		return nil, fmt.Errorf("最新のビルドIDの取得に失敗しました: %w", err)
+	}
+	status.LatestVersion = latest
+
+	return status, nil
+}
+
+// VersionFiles インストール済みのビルドを表すファイル(アプリのマニフェスト)を返す
+func (p *Steam) VersionFiles(gameCfg *domain.GameConfig) []string {
+	return []string{domain.SteamManifestPath(gameCfg.Steam.AppID)}
+}
+
+// loadManifest インストール済みのアプリのマニフェストを読み込む
+// 未インストールの場合は nil を返す
+func (p *Steam) loadManifest(gameCfg *domain.GameConfig) (*domain.AppManifest, error) {
+	path := filepath.Join(gameCfg.InstallDir, domain.SteamManifestPath(gameCfg.Steam.AppID))
+	data, err := p.fs.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("マニフェストの読み込みに失敗しました: %w", err)
+	}
+
+	manifest, err := domain.ParseAppManifest(data)
+	if err != nil {
+		return nil, fmt.Errorf("マニフェスト %s のパースに失敗しました: %w", path, err)
+	}
+	return manifest, nil
+}
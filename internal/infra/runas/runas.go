@@ -0,0 +1,17 @@
+// Package runas は run.user のユーザーとしてコマンドを実行するための設定を提供します。
+// サーバの起動 (launcher) とプレフィックスの作成 (wine) で同じユーザーを使用するために共有します。
+package runas
+
+import (
+	"fmt"
+	"os/user"
+)
+
+// Env は name のユーザーとして実行する場合の HOME, USER, LOGNAME を返します。
+func Env(name string) ([]string, error) {
+	u, err := user.Lookup(name)
+	if err != nil {
+		return nil, fmt.Errorf("run.user のユーザー %s が見つかりません: %w", name, err)
+	}
+	return []string{"HOME=" + u.HomeDir, "USER=" + u.Username, "LOGNAME=" + u.Username}, nil
+}
@@ -0,0 +1,75 @@
+//go:build linux
+
+package runas
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// SetUser はコマンドを name のユーザーで実行するよう設定します。name が空または実行中のユーザーの場合は何もしません。
+func SetUser(cmd *exec.Cmd, name string) error {
+	cred, err := lookup(name)
+	if err != nil || cred == nil {
+		return err
+	}
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Credential = cred
+	return nil
+}
+
+// Chown は path の所有者を name のユーザーに変更します。name が空または実行中のユーザーの場合は何もしません。
+// rootで作成したディレクトリを、name のユーザーで実行するコマンドが書き込めるようにするために使用します。
+func Chown(path, name string) error {
+	cred, err := lookup(name)
+	if err != nil || cred == nil {
+		return err
+	}
+	if err := os.Chown(path, int(cred.Uid), int(cred.Gid)); err != nil {
+		return fmt.Errorf("%s の所有者を %s に変更できませんでした: %w", path, name, err)
+	}
+	return nil
+}
+
+// lookup は name のユーザーの資格情報を返します。name が空または実行中のユーザーの場合は nil を返します。
+// 他のユーザーで実行するにはrootで実行している必要があります。
+func lookup(name string) (*syscall.Credential, error) {
+	if name == "" {
+		return nil, nil //nolint:nilnil // 切り替え不要
+	}
+	u, err := user.Lookup(name)
+	if err != nil {
+		return nil, fmt.Errorf("run.user のユーザー %s が見つかりません: %w", name, err)
+	}
+	if u.Uid == strconv.Itoa(os.Geteuid()) {
+		return nil, nil //nolint:nilnil // 切り替え不要
+	}
+	if os.Geteuid() != 0 {
+		return nil, fmt.Errorf("run.user (%s) で実行するには archon をrootで実行してください", name)
+	}
+
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("ユーザー %s のUIDが不正です: %w", name, err)
+	}
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("ユーザー %s のGIDが不正です: %w", name, err)
+	}
+	var groups []uint32
+	if ids, err := u.GroupIds(); err == nil {
+		for _, id := range ids {
+			if g, err := strconv.ParseUint(id, 10, 32); err == nil {
+				groups = append(groups, uint32(g))
+			}
+		}
+	}
+	return &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid), Groups: groups}, nil
+}
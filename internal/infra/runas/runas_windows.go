@@ -0,0 +1,24 @@
+//go:build windows
+
+package runas
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// SetUser は Windows では対応していないため、name が指定されている場合はエラーを返します。
+func SetUser(_ *exec.Cmd, name string) error {
+	if name != "" {
+		return fmt.Errorf("run.user はWindowsでは使用できません")
+	}
+	return nil
+}
+
+// Chown は Windows では対応していないため、name が指定されている場合はエラーを返します。
+func Chown(_, name string) error {
+	if name != "" {
+		return fmt.Errorf("run.user はWindowsでは使用できません")
+	}
+	return nil
+}
@@ -0,0 +1,332 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+const (
+	// baseDir は新しいルートを組み立てる tmpfs のマウント先です。ピボット後は元の /tmp の内容が見えます。
+	baseDir = "/tmp"
+	// oldRoot, newRoot は組み立て中の元のルートと新しいルートの tmpfs 上のパスです。
+	oldRoot = "/oldroot"
+	newRoot = "/newroot"
+)
+
+// forwardSignals はサーバに転送するシグナルです。
+var forwardSignals = []os.Signal{unix.SIGTERM, unix.SIGINT, unix.SIGHUP, unix.SIGQUIT, unix.SIGUSR1, unix.SIGUSR2}
+
+// Init はサンドボックスの初期化プロセスとして、ファイルシステムを準備してからサーバを起動し、終了するまで待機します。
+// 初期化プロセスは新しいPID名前空間の PID 1 として動作し、シグナルをサーバに転送して孤児プロセスを回収します。
+// サーバの終了コードで終了し、初期化に失敗した場合は 125 で終了します。
+func Init() {
+	code, err := runInit()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "サンドボックスの初期化に失敗しました: %v\n", err)
+		os.Exit(exitInitFailed)
+	}
+	os.Exit(code)
+}
+
+// runInit サンドボックスを準備してサーバを起動し、サーバの終了コードを返す
+func runInit() (int, error) {
+	// 権限と no_new_privs はスレッドごとに設定されるため、権限を外したスレッドからサーバを起動する
+	runtime.LockOSThread()
+
+	var spec domain.SandboxSpec
+	if err := json.Unmarshal([]byte(os.Getenv(SpecEnv)), &spec); err != nil {
+		return 0, fmt.Errorf("%s が不正です: %w", SpecEnv, err)
+	}
+	if err := os.Unsetenv(SpecEnv); err != nil {
+		return 0, fmt.Errorf("%s の削除に失敗しました: %w", SpecEnv, err)
+	}
+
+	if err := setupRoot(&spec); err != nil {
+		return 0, err
+	}
+	if err := dropCapabilities(); err != nil {
+		return 0, err
+	}
+
+	// シグナルは起動前から受け取り、起動後にサーバへ転送する
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwardSignals...)
+
+	proc, err := os.StartProcess(spec.Path, spec.Args, &os.ProcAttr{
+		Dir:   spec.Dir,
+		Env:   os.Environ(),
+		Files: []*os.File{os.Stdin, os.Stdout, os.Stderr},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("サーバの起動に失敗しました: %w", err)
+	}
+	go func() {
+		for sig := range signals {
+			_ = proc.Signal(sig)
+		}
+	}()
+
+	return reap(proc.Pid)
+}
+
+// reap PID 1 として全ての子プロセスを回収し、サーバが終了した時点での終了コードを返す
+// 初期化プロセスが終了すると、名前空間に残ったプロセスはカーネルによって終了される
+func reap(pid int) (int, error) {
+	for {
+		var status unix.WaitStatus
+		wpid, err := unix.Wait4(-1, &status, 0, nil)
+		if errors.Is(err, unix.EINTR) {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("サーバの終了の待機に失敗しました: %w", err)
+		}
+		if wpid != pid {
+			continue
+		}
+		if status.Signaled() {
+			return 128 + int(status.Signal()), nil
+		}
+		return status.ExitStatus(), nil
+	}
+}
+
+// setupRoot 書き込みを許可したパス以外を読み取り専用にした新しいルートを用意し、ルートを切り替える
+// spec.Hidden は空の tmpfs で隠し、その上に spec.ReadOnly を読み取り専用、spec.Writable を書き込み可能で重ねる
+// /proc は新しいPID名前空間のものをマウントする
+func setupRoot(spec *domain.SandboxSpec) error {
+	// 以降のマウントがホストに伝播しないようにする
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("マウントの伝播の無効化に失敗しました: %w", err)
+	}
+
+	// tmpfs を新しいルートの作業場所にし、元のルートは /oldroot から参照する
+	if err := unix.Mount("tmpfs", baseDir, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "mode=0755"); err != nil {
+		return fmt.Errorf("作業用の tmpfs のマウントに失敗しました: %w", err)
+	}
+	if err := os.Mkdir(baseDir+oldRoot, 0o755); err != nil {
+		return fmt.Errorf("作業ディレクトリの作成に失敗しました: %w", err)
+	}
+	if err := unix.PivotRoot(baseDir, baseDir+oldRoot); err != nil {
+		return fmt.Errorf("ルートの切り替えに失敗しました: %w", err)
+	}
+	if err := os.Chdir("/"); err != nil {
+		return fmt.Errorf("ルートへの移動に失敗しました: %w", err)
+	}
+
+	// 元のルートを複製し、全て読み取り専用にする
+	if err := os.Mkdir(newRoot, 0o755); err != nil {
+		return fmt.Errorf("作業ディレクトリの作成に失敗しました: %w", err)
+	}
+	if err := unix.Mount(oldRoot, newRoot, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+		return fmt.Errorf("ルートの複製に失敗しました: %w", err)
+	}
+	if err := remountReadOnly(newRoot); err != nil {
+		return err
+	}
+
+	// 親のディレクトリを先に隠すと、その下のパスは見つからなくなるため除外される
+	for _, path := range spec.Hidden {
+		if _, err := os.Lstat(newRoot + path); err != nil {
+			continue
+		}
+		mode := "mode=0755"
+		if path == "/tmp" || path == "/dev/shm" {
+			mode = "mode=1777"
+		}
+		if err := unix.Mount("tmpfs", newRoot+path, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, mode); err != nil {
+			return fmt.Errorf("%s を隠す tmpfs のマウントに失敗しました: %w", path, err)
+		}
+	}
+	for _, path := range spec.ReadOnly {
+		if err := bind(path, true); err != nil {
+			return err
+		}
+	}
+	for _, path := range spec.Writable {
+		if err := bind(path, false); err != nil {
+			return err
+		}
+	}
+
+	// ホストの /proc のままでは、同じユーザーで動く他のゲームのサーバのファイルに /proc/<pid>/root などから触れられるため、
+	// 新しい PID 名前空間の /proc をマウントできない場合は起動しない
+	// (ホストの /proc が一部隠されている環境(コンテナ内など)ではマウントできない)
+	if err := unix.Mount("proc", newRoot+"/proc", "proc", unix.MS_NOSUID|unix.MS_NODEV|unix.MS_NOEXEC, ""); err != nil {
+		return fmt.Errorf("サンドボックスの /proc のマウントに失敗しました。この環境では isolation を使用できません: %w", err)
+	}
+
+	// 新しいルートに切り替え、元のルートを切り離す
+	if err := os.Chdir(newRoot); err != nil {
+		return fmt.Errorf("新しいルートへの移動に失敗しました: %w", err)
+	}
+	if err := unix.PivotRoot(".", "."); err != nil {
+		return fmt.Errorf("新しいルートへの切り替えに失敗しました: %w", err)
+	}
+	if err := unix.Unmount(".", unix.MNT_DETACH); err != nil {
+		return fmt.Errorf("元のルートの切り離しに失敗しました: %w", err)
+	}
+	if err := os.Chdir("/"); err != nil {
+		return fmt.Errorf("ルートへの移動に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// bind 元のルートの path を新しいルートの同じ場所に重ねてマウントする
+// readOnly でない場合は、元のルートのマウントのまま書き込み可能になる
+// 新しいルートに path がない場合(隠した tmpfs の下など)は、マウント先を作成する
+func bind(path string, readOnly bool) error {
+	src := oldRoot + path
+	dst := newRoot + path
+	info, err := os.Stat(src)
+	if err != nil {
+		return fmt.Errorf("%s が見つかりません: %w", path, err)
+	}
+
+	if _, err := os.Stat(dst); errors.Is(err, os.ErrNotExist) {
+		if info.IsDir() {
+			err = os.MkdirAll(dst, 0o755)
+		} else if err = os.MkdirAll(filepath.Dir(dst), 0o755); err == nil {
+			err = os.WriteFile(dst, nil, 0o644)
+		}
+		if err != nil {
+			return fmt.Errorf("%s のマウント先の作成に失敗しました: %w", path, err)
+		}
+	}
+
+	if err := unix.Mount(src, dst, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+		return fmt.Errorf("%s のマウントに失敗しました: %w", path, err)
+	}
+	if readOnly {
+		return remountReadOnly(dst)
+	}
+	return nil
+}
+
+// remountReadOnly root 以下の全てのマウントを読み取り専用で再マウントする
+// 名前空間の外から引き継いだ nosuid, nodev, noexec などのフラグは外せないため、現在のフラグに読み取り専用を加える
+func remountReadOnly(root string) error {
+	mounts, err := mountPoints(oldRoot + "/proc/self/mountinfo")
+	if err != nil {
+		return err
+	}
+	for _, mount := range mounts {
+		if mount != root && !strings.HasPrefix(mount, root+"/") {
+			continue
+		}
+
+		var stat unix.Statfs_t
+		if err := unix.Statfs(mount, &stat); err != nil {
+			// サーバと同じユーザーから辿れないマウントは、サーバからも書き込めない
+			if errors.Is(err, unix.EACCES) || errors.Is(err, unix.ENOENT) {
+				continue
+			}
+			return fmt.Errorf("%s の確認に失敗しました: %w", strings.TrimPrefix(mount, root), err)
+		}
+		flags := uintptr(unix.MS_BIND | unix.MS_REMOUNT | unix.MS_RDONLY)
+		for st, ms := range statfsFlags {
+			if stat.Flags&st != 0 {
+				flags |= ms
+			}
+		}
+		if err := unix.Mount("", mount, "", flags, ""); err != nil {
+			return fmt.Errorf("%s の読み取り専用での再マウントに失敗しました: %w", strings.TrimPrefix(mount, root), err)
+		}
+	}
+	return nil
+}
+
+// statfsFlags は statfs のフラグと、再マウント時に引き継ぐマウントフラグの対応です。
+var statfsFlags = map[int64]uintptr{
+	unix.ST_NOSUID:     unix.MS_NOSUID,
+	unix.ST_NODEV:      unix.MS_NODEV,
+	unix.ST_NOEXEC:     unix.MS_NOEXEC,
+	unix.ST_NOATIME:    unix.MS_NOATIME,
+	unix.ST_NODIRATIME: unix.MS_NODIRATIME,
+	unix.ST_RELATIME:   unix.MS_RELATIME,
+}
+
+// mountPoints mountinfo からマウント先の一覧を返す
+func mountPoints(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("マウントの一覧の読み込みに失敗しました: %w", err)
+	}
+	defer func(f *os.File) {
+		_ = f.Close()
+	}(f)
+
+	var mounts []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// "36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw" の5番目がマウント先
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		mounts = append(mounts, unescapeMountInfo(fields[4]))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("マウントの一覧の読み込みに失敗しました: %w", err)
+	}
+	return mounts, nil
+}
+
+// unescapeMountInfo mountinfo で \040 などの8進数にエスケープされた空白等を戻す
+func unescapeMountInfo(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if c, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				sb.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+// dropCapabilities サーバがマウントを戻すなどしてサンドボックスから抜け出せないよう、権限を全て外す
+// バウンディングセットを空にするため、名前空間内で root として実行されるサーバも権限を持たない
+func dropCapabilities() error {
+	for c := 0; c <= unix.CAP_LAST_CAP; c++ {
+		// 実行中のカーネルが対応していない権限は EINVAL になる
+		if err := unix.Prctl(unix.PR_CAPBSET_DROP, uintptr(c), 0, 0, 0); err != nil && !errors.Is(err, unix.EINVAL) {
+			return fmt.Errorf("権限の削除に失敗しました: %w", err)
+		}
+	}
+	if err := unix.Prctl(unix.PR_CAP_AMBIENT, unix.PR_CAP_AMBIENT_CLEAR_ALL, 0, 0, 0); err != nil {
+		return fmt.Errorf("権限の削除に失敗しました: %w", err)
+	}
+
+	header := unix.CapUserHeader{Version: unix.LINUX_CAPABILITY_VERSION_3}
+	var data [2]unix.CapUserData
+	if err := unix.Capget(&header, &data[0]); err != nil {
+		return fmt.Errorf("権限の取得に失敗しました: %w", err)
+	}
+	data[0].Inheritable, data[1].Inheritable = 0, 0
+	if err := unix.Capset(&header, &data[0]); err != nil {
+		return fmt.Errorf("権限の削除に失敗しました: %w", err)
+	}
+
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("no_new_privs の設定に失敗しました: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package sandbox
+
+import (
+	"os"
+)
+
+const (
+	// InitArg は archon をサンドボックスの初期化プロセスとして起動する際の第1引数です。
+	InitArg = "__sandbox-init"
+	// SpecEnv は初期化プロセスに domain.SandboxSpec を JSON で渡す環境変数です。サーバには渡されません。
+	SpecEnv = "ARCHON_SANDBOX_SPEC"
+	// exitInitFailed はサンドボックスの初期化に失敗した場合の終了コードです。
+	exitInitFailed = 125
+)
+
+// IsInit は archon がサンドボックスの初期化プロセスとして起動されたかどうかを返します。
+func IsInit() bool {
+	return len(os.Args) > 1 && os.Args[1] == InitArg
+}
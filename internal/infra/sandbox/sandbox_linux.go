@@ -0,0 +1,115 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+// Wrap は cmd をサンドボックス内で実行するよう書き換えます。
+// cmd の代わりに archon 自身を新しい user, mount, PID, IPC 名前空間で初期化プロセスとして起動し、
+// 初期化プロセスがファイルシステムを準備してから cmd を実行します。(Init を参照)
+// paths.Hidden に加えて、domain.SandboxHiddenDirs とサーバ・archon のホームディレクトリを隠し、readOnly (実行環境) は読み取り専用で見えるようにします。
+// ネットワークはサーバの公開に必要なため、ホストと共有します。
+// user 名前空間のIDは、実行するユーザー (run.user または archon を実行しているユーザー) のみを同じIDに対応付けます。
+// root のままではホストの root 所有のファイルを読めるため、root で実行する場合は run.user に root 以外のユーザーが必要です。
+// paths.Writable のうち存在しないパスは、サンドボックス内に用意できないため警告を表示して除外します。
+func Wrap(cmd *exec.Cmd, paths domain.SandboxPaths, readOnly []string) error {
+	uid, gid := os.Getuid(), os.Getgid()
+	if cmd.SysProcAttr != nil && cmd.SysProcAttr.Credential != nil {
+		uid, gid = int(cmd.SysProcAttr.Credential.Uid), int(cmd.SysProcAttr.Credential.Gid)
+	}
+	if uid == 0 {
+		return fmt.Errorf("isolation を root で使用するには run.user に root 以外のユーザーを指定してください")
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("archon の実行ファイルのパスを取得できません: %w", err)
+	}
+
+	hidden := append(slices.Clone(domain.SandboxHiddenDirs), paths.Hidden...)
+	if u, err := user.LookupId(strconv.Itoa(uid)); err == nil {
+		hidden = append(hidden, u.HomeDir)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		hidden = append(hidden, home)
+	}
+
+	spec := domain.SandboxSpec{
+		Path:     cmd.Path,
+		Args:     cmd.Args,
+		Dir:      cmd.Dir,
+		Writable: resolvePaths(paths.Writable, true),
+		ReadOnly: resolvePaths(readOnly, false),
+		Hidden:   resolvePaths(hidden, false),
+	}
+	// 隠したディレクトリの下のリンクは辿れないため、カレントディレクトリもリンクを解決しておく
+	if dir, err := filepath.EvalSymlinks(cmd.Dir); err == nil && cmd.Dir != "" {
+		spec.Dir = dir
+	}
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return fmt.Errorf("サンドボックスの設定の生成に失敗しました: %w", err)
+	}
+
+	cmd.Path = self
+	cmd.Args = []string{"archon", InitArg}
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, SpecEnv+"="+string(data))
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	attr := cmd.SysProcAttr
+	attr.Cloneflags |= unix.CLONE_NEWUSER | unix.CLONE_NEWNS | unix.CLONE_NEWPID | unix.CLONE_NEWIPC
+
+	if attr.Credential != nil {
+		// 補助グループは名前空間に対応付けられないため、外してから実行する
+		attr.Credential.Groups = nil
+		attr.GidMappingsEnableSetgroups = true
+	}
+	attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: uid, HostID: uid, Size: 1}}
+	attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: gid, HostID: gid, Size: 1}}
+	// root 以外のユーザーは exec で権限を失うため、初期化プロセスのマウントと権限の削除に必要な権限を引き継ぐ
+	attr.AmbientCaps = []uintptr{unix.CAP_SYS_ADMIN, unix.CAP_SETPCAP}
+	return nil
+}
+
+// resolvePaths シンボリックリンクを解決した絶対パスを返す
+// サンドボックス内ではホストのルートを基準にリンクを辿れないため、事前に解決しておく
+// 存在しないパスは除外し、warn が true の場合は書き込めない旨を表示する
+func resolvePaths(paths []string, warn bool) []string {
+	var resolved []string
+	for _, path := range paths {
+		if path == "" || !filepath.IsAbs(path) {
+			continue
+		}
+		real, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			if warn {
+				fmt.Fprintf(os.Stderr, "%s が見つからないため、サンドボックス内では書き込めません。\n", path)
+			}
+			continue
+		}
+		if real != "/" {
+			resolved = append(resolved, real)
+		}
+	}
+	slices.Sort(resolved)
+	return slices.Compact(resolved)
+}
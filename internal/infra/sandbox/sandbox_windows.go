@@ -0,0 +1,22 @@
+//go:build windows
+
+package sandbox
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+// Wrap は Windows ではサンドボックスが使用できないため、エラーを返します。
+func Wrap(_ *exec.Cmd, _ domain.SandboxPaths, _ []string) error {
+	return fmt.Errorf("isolation はLinuxでのみ使用できます")
+}
+
+// Init は Windows ではサンドボックスが使用できないため、エラーを表示して終了します。
+func Init() {
+	fmt.Fprintln(os.Stderr, "isolation はLinuxでのみ使用できます")
+	os.Exit(exitInitFailed)
+}
@@ -1,55 +1,300 @@
 package steamcmd
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+const (
+	// maxAttempts は一時的なエラーの場合に steamcmd を実行する最大の回数です。
+	maxAttempts = 3
+	// retryDelay は最初の再実行までの待機時間です。再実行ごとに2倍になります。
+	retryDelay = 15 * time.Second
 )
 
 // SteamCmd steamcmdの操作
-// TODO: 将来的にバイナリのパスを指定できるようにする
-type SteamCmd struct{}
+type SteamCmd struct {
+	passwords PasswordResolver
+	// path は steamcmd.path で指定された実行ファイルのパス
+	path string
+	// managedDir は steamcmd install でsteamcmdを展開したディレクトリ
+	managedDir string
+}
+
+// PasswordResolver はSteamアカウントのパスワードを取得するインターフェース
+type PasswordResolver interface {
+	Password(login *domain.SteamLoginConfig) (string, error)
+}
 
 // NewSteamCmd SteamCmdのインスタンスを生成する
-func NewSteamCmd() *SteamCmd {
-	return &SteamCmd{}
+// path が空の場合は managedDir 内のsteamcmd、PATH 上の steamcmd の順に探します。
+func NewSteamCmd(path, managedDir string, passwords PasswordResolver) *SteamCmd {
+	return &SteamCmd{
+		passwords:  passwords,
+		path:       path,
+		managedDir: managedDir,
+	}
 }
 
 // Check steamcmdコマンドの存在確認
 func (s *SteamCmd) Check() error {
-	if _, err := exec.LookPath("steamcmd"); err != nil {
-		return fmt.Errorf("steamcmdコマンドが見つかりません: %w", err)
+	if _, err := s.binary(); err != nil {
+		return err
 	}
 	return nil
 }
 
+// binary 実行するsteamcmdのパスを返す
+func (s *SteamCmd) binary() (string, error) {
+	if s.path != "" {
+		if _, err := os.Stat(s.path); err != nil {
+			return "", fmt.Errorf("steamcmd.path に指定された %s が見つかりません: %w", s.path, err)
+		}
+		return s.path, nil
+	}
+
+	if s.managedDir != "" {
+		managed := filepath.Join(s.managedDir, managedBinary)
+		if _, err := os.Stat(managed); err == nil {
+			return managed, nil
+		}
+	}
+
+	path, err := exec.LookPath("steamcmd")
+	if err != nil {
+		return "", fmt.Errorf("steamcmdコマンドが見つかりません。archon steamcmd install でインストールできます: %w", err)
+	}
+	return path, nil
+}
+
 // Update 対象アプリのインストール/アップデート
-func (s *SteamCmd) Update(ctx context.Context, appID, installDir, platform string) error {
-	if err := s.Check(); err != nil {
-		return fmt.Errorf("アップデートに失敗しました: %w", err)
+func (s *SteamCmd) Update(ctx context.Context, installDir string, steam *domain.SteamConfig) error {
+	login, password, err := s.loginCommand(steam.Login)
+	if err != nil {
+		return err
 	}
 
-	// 引数の構築
+	// パスワードを含むコマンドはスクリプト経由で渡す
+	commands := [][]string{login, appUpdateCommand(steam, installedBranch(installDir, steam.AppID))}
+	secure, cleanup, err := scriptArgs(commands, password != "" || steam.BranchPassword != "")
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
 	var args []string
-	if platform != "" {
-		args = append(args, "+@sSteamCmdForcePlatformType", platform)
-	}
-	args = append(args,
-		"+force_install_dir", installDir,
-		"+login", "anonymous",
-		"+app_update", appID,
-		"+quit",
-	)
-
-	// 実行
-	cmd := exec.CommandContext(ctx, "steamcmd", args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	if steam.Platform != "" {
+		args = append(args, "+@sSteamCmdForcePlatformType", steam.Platform)
+	}
+	args = append(args, steam.ExtraArgs...)
+	args = append(args, "+force_install_dir", installDir)
+	args = append(args, secure...)
+	args = append(args, "+quit")
 
-	if err := cmd.Run(); err != nil {
+	if err := s.run(ctx, args...); err != nil {
 		return fmt.Errorf("steamcmdを使ったアップデートに失敗しました: %w", err)
 	}
+	return nil
+}
+
+// appUpdateCommand app_update コマンドを構築する
+// installed はインストール済みのブランチです。(未インストールの場合は空)
+func appUpdateCommand(steam *domain.SteamConfig, installed string) []string {
+	command := []string{"app_update", steam.AppID}
+
+	// 公開ブランチ以外の場合はブランチを指定する
+	// steamcmd はインストール済みのブランチを引き継ぐため、他のブランチから公開ブランチに戻す場合も明示的に指定する
+	switch branch := steam.GetBranch(); {
+	case branch != domain.DefaultSteamBranch:
+		command = append(command, "-beta", branch)
+		if steam.BranchPassword != "" {
+			command = append(command, "-betapassword", steam.BranchPassword)
+		}
+	case installed != "" && installed != branch:
+		command = append(command, "-beta", branch)
+	}
+	if steam.Validate {
+		command = append(command, "validate")
+	}
+
+	return command
+}
+
+// installedBranch installDir にインストール済みのブランチを返す
+// 未インストールの場合や、マニフェストを読み込めない場合は空文字列を返す
+func installedBranch(installDir, appID string) string {
+	data, err := os.ReadFile(filepath.Join(installDir, domain.SteamManifestPath(appID)))
+	if err != nil {
+		return ""
+	}
+	manifest, err := domain.ParseAppManifest(data)
+	if err != nil {
+		return ""
+	}
+	return manifest.Branch
+}
+
+// DownloadWorkshopItems ワークショップのアイテムをダウンロードする
+// アイテムは downloadDir 以下の steamapps/workshop/content/<appID>/<item> に展開される。
+func (s *SteamCmd) DownloadWorkshopItems(ctx context.Context, downloadDir, appID string, items []string, steam *domain.SteamConfig) error {
+	var loginCfg *domain.SteamLoginConfig
+	var extraArgs []string
+	if steam != nil {
+		loginCfg = steam.Login
+		extraArgs = steam.ExtraArgs
+	}
+	login, password, err := s.loginCommand(loginCfg)
+	if err != nil {
+		return err
+	}
+
+	commands := [][]string{login}
+	for _, item := range items {
+		commands = append(commands, []string{"workshop_download_item", appID, item})
+	}
+	secure, cleanup, err := scriptArgs(commands, password != "")
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	args := append([]string{}, extraArgs...)
+	args = append(args, "+force_install_dir", downloadDir)
+	args = append(args, secure...)
+	args = append(args, "+quit")
+
+	if err := s.run(ctx, args...); err != nil {
+		return fmt.Errorf("ワークショップのアイテムのダウンロードに失敗しました: %w", err)
+	}
+	return nil
+}
+
+// SelfUpdate installDir に展開したsteamcmd自体を更新する
+// steamcmd は起動時に自身を更新するため、何もせずに終了させる。
+// steamcmd.path が設定されている場合も、そちらではなく installDir のsteamcmdを実行する。
+func (s *SteamCmd) SelfUpdate(ctx context.Context, installDir string) error {
+	bin := filepath.Join(installDir, managedBinary)
+	if _, err := os.Stat(bin); err != nil {
+		return fmt.Errorf("展開したsteamcmd %s が見つかりません: %w", bin, err)
+	}
+	if err := s.runBinary(ctx, bin, nil, "+quit"); err != nil {
+		return fmt.Errorf("steamcmdの更新に失敗しました: %w", err)
+	}
+	return nil
+}
 
+// LatestBuildID は steam.branch で配信されている最新のビルドIDを返します。
+// app_info_print の出力をパースして取得します。
+func (s *SteamCmd) LatestBuildID(ctx context.Context, steam *domain.SteamConfig) (string, error) {
+	login, password, err := s.loginCommand(steam.Login)
+	if err != nil {
+		return "", err
+	}
+	secure, cleanup, err := scriptArgs([][]string{login}, password != "")
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+
+	appID := steam.AppID
+	branch := steam.GetBranch()
+	args := append(secure, "+app_info_update", "1", "+app_info_print", appID, "+quit")
+
+	var stdout bytes.Buffer
+	if err := s.runWithRetry(ctx, &stdout, args...); err != nil {
+		return "", err
+	}
+
+	block, err := domain.ExtractVDFBlock(stdout.String(), appID)
+	if err != nil {
+		return "", fmt.Errorf("app_info_print の出力の解析に失敗しました: %w", err)
+	}
+	info, err := domain.ParseVDF(block)
+	if err != nil {
+		return "", fmt.Errorf("app_info_print の出力の解析に失敗しました: %w", err)
+	}
+
+	buildID, ok := info.Get(appID, "depots", "branches", branch, "buildid")
+	if !ok {
+		return "", fmt.Errorf("ブランチ %s のビルドIDが見つかりません", branch)
+	}
+	return buildID, nil
+}
+
+// run steamcmdを実行し、出力を表示する
+func (s *SteamCmd) run(ctx context.Context, args ...string) error {
+	return s.runWithRetry(ctx, nil, args...)
+}
+
+// runWithRetry steamcmdを実行する
+// 出力を解析してエラーを判別し、通信の失敗などの一時的なエラーの場合は間隔をあけて再実行する。
+// capture が nil でない場合は出力を表示せずに capture に書き込む。再実行の際は capture をリセットする。
+func (s *SteamCmd) runWithRetry(ctx context.Context, capture *bytes.Buffer, args ...string) error {
+	bin, err := s.binary()
+	if err != nil {
+		return err
+	}
+	return s.runBinary(ctx, bin, capture, args...)
+}
+
+// runBinary bin のsteamcmdを実行する (runWithRetry を参照)
+func (s *SteamCmd) runBinary(ctx context.Context, bin string, capture *bytes.Buffer, args ...string) error {
+	delay := retryDelay
+	for attempt := 1; ; attempt++ {
+		var stdout io.Writer = os.Stdout
+		if capture != nil {
+			capture.Reset()
+			stdout = capture
+		}
+
+		err := runOnce(ctx, bin, stdout, args)
+		var steamErr *domain.SteamCmdError
+		if err == nil || !errors.As(err, &steamErr) || !steamErr.Transient() || attempt >= maxAttempts {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "%s\n%s 後に再実行します。(%d/%d)\n", steamErr.Message(), delay, attempt+1, maxAttempts)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w (再実行の待機中に中断しました: %w)", err, ctx.Err())
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// runOnce steamcmdを一度実行する
+// 終了コードが0でも、出力からエラーを判別した場合は *domain.SteamCmdError を返す。
+func runOnce(ctx context.Context, bin string, stdout io.Writer, args []string) error {
+	output := &domain.SteamCmdOutput{}
+	w := newOutputWriter(stdout, output)
+
+	// Steam Guard のコード入力に応答できるよう、標準入力をつなぐ
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = w
+	cmd.Stderr = os.Stderr
+
+	runErr := cmd.Run()
+	w.Flush()
+
+	if err := output.Err(); err != nil {
+		if p := output.Progress; p != nil {
+			return fmt.Errorf("%w (最後の進捗: %s %.2f%%)", err, p.State, p.Percent)
+		}
+		return err
+	}
+	if runErr != nil {
+		return fmt.Errorf("%s の実行に失敗しました: %w", bin, runErr)
+	}
 	return nil
 }
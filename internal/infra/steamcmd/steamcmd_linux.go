@@ -0,0 +1,6 @@
+//go:build linux
+
+package steamcmd
+
+// managedBinary は公式のアーカイブに含まれるsteamcmdの起動スクリプトです。
+const managedBinary = "steamcmd.sh"
@@ -0,0 +1,79 @@
+package steamcmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+// loginCommand login コマンドと、使用するパスワードを返す
+// パスワードが設定されていない場合は、steamcmd にキャッシュされたセッションでログインする。
+func (s *SteamCmd) loginCommand(login *domain.SteamLoginConfig) ([]string, string, error) {
+	if login == nil || login.Username == "" {
+		return []string{"login", "anonymous"}, "", nil
+	}
+
+	var password string
+	if s.passwords != nil {
+		var err error
+		if password, err = s.passwords.Password(login); err != nil {
+			return nil, "", fmt.Errorf("%s のパスワードの取得に失敗しました: %w", login.Username, err)
+		}
+	}
+	if password == "" {
+		return []string{"login", login.Username}, "", nil
+	}
+	return []string{"login", login.Username, password}, password, nil
+}
+
+// scriptArgs commands を steamcmd の引数に変換し、後始末用の関数と共に返す
+// secret が true の場合、パスワードをプロセスの引数に含めないよう、所有者のみ読めるスクリプトファイルに書き出して +runscript で読み込ませる。
+func scriptArgs(commands [][]string, secret bool) ([]string, func(), error) {
+	noop := func() {}
+	if !secret {
+		var args []string
+		for _, command := range commands {
+			args = append(args, "+"+command[0])
+			args = append(args, command[1:]...)
+		}
+		return args, noop, nil
+	}
+
+	var sb strings.Builder
+	for _, command := range commands {
+		for i, token := range command {
+			if strings.ContainsAny(token, "\"\r\n") {
+				return nil, noop, fmt.Errorf("%s コマンドの引数に使用できない文字 (\", 改行) が含まれています", command[0])
+			}
+			if i > 0 {
+				sb.WriteString(" ")
+			}
+			sb.WriteString(`"` + token + `"`)
+		}
+		sb.WriteString("\n")
+	}
+
+	// CreateTemp は 0600 で作成するため、他のユーザーからは読めない
+	script, err := os.CreateTemp("", "archon-steamcmd-*.txt")
+	if err != nil {
+		return nil, noop, fmt.Errorf("steamcmdのスクリプトの作成に失敗しました: %w", err)
+	}
+	cleanup := func() {
+		if err := os.Remove(script.Name()); err != nil && !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "steamcmdのスクリプトの削除に失敗しました: %v\n", err)
+		}
+	}
+
+	_, writeErr := script.WriteString(sb.String())
+	if err := script.Close(); err != nil && writeErr == nil {
+		writeErr = err
+	}
+	if writeErr != nil {
+		cleanup()
+		return nil, noop, fmt.Errorf("steamcmdのスクリプトの書き込みに失敗しました: %w", writeErr)
+	}
+
+	return []string{"+runscript", script.Name()}, cleanup, nil
+}
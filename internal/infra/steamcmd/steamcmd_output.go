@@ -0,0 +1,46 @@
+package steamcmd
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+// outputWriter steamcmdの出力をそのまま out に書き込みつつ、行ごとに解析する
+// Steam Guard のコード入力など改行のないプロンプトも表示されるよう、out へは受け取った順にすぐ書き込む。
+type outputWriter struct {
+	out    io.Writer
+	output *domain.SteamCmdOutput
+	buf    []byte
+}
+
+// newOutputWriter outputWriterのインスタンスを生成する
+func newOutputWriter(out io.Writer, output *domain.SteamCmdOutput) *outputWriter {
+	return &outputWriter{out: out, output: output}
+}
+
+// Write は p を out に書き込み、改行までの行を解析します。
+// steamcmd は進捗の表示に \r を使うことがあるため、\r も行の区切りとして扱います。
+func (w *outputWriter) Write(p []byte) (int, error) {
+	n, err := w.out.Write(p)
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexAny(w.buf, "\r\n")
+		if i < 0 {
+			break
+		}
+		w.output.Parse(string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+	}
+	return n, err
+}
+
+// Flush は改行で終わっていない最後の行を解析します。
+func (w *outputWriter) Flush() {
+	if len(w.buf) > 0 {
+		w.output.Parse(string(w.buf))
+		w.buf = nil
+	}
+}
@@ -0,0 +1,6 @@
+//go:build windows
+
+package steamcmd
+
+// managedBinary は公式のアーカイブに含まれるsteamcmdの実行ファイルです。
+const managedBinary = "steamcmd.exe"
@@ -0,0 +1,139 @@
+package wine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+	"github.com/nonuplet/grimoire-archon/internal/infra/runas"
+)
+
+// Prefix Wine/Protonのプレフィックスの操作
+type Prefix struct{}
+
+// NewPrefix Prefixのインスタンスを生成する
+func NewPrefix() *Prefix {
+	return &Prefix{}
+}
+
+// Check はプレフィックスの作成に必要なコマンドの存在を確認します。
+func (p *Prefix) Check(gameCfg *domain.GameConfig) error {
+	switch gameCfg.RuntimeEnv {
+	case domain.RuntimeEnvWine:
+		if _, err := exec.LookPath("wineboot"); err != nil {
+			return fmt.Errorf("wineboot コマンドが見つかりません。wineをインストールしてください: %w", err)
+		}
+	case domain.RuntimeEnvProton:
+		home, _, err := gameCfg.RunUser()
+		if err != nil {
+			return err
+		}
+		if _, err := FindProton(gameCfg.Runtime.GetProton(), home); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("実行環境 %s ではプレフィックスを使用できません", gameCfg.RuntimeEnv)
+	}
+
+	if len(gameCfg.Prefix.Winetricks) > 0 {
+		if _, err := exec.LookPath("winetricks"); err != nil {
+			return fmt.Errorf("winetricks コマンドが見つかりません: %w", err)
+		}
+	}
+	return nil
+}
+
+// Boot はプレフィックスを作成(初期化)します。
+// wine では wineboot、proton では proton run wineboot を実行します。
+// wine は実行するユーザーが所有していないプレフィックスを使用できないため、サーバと同じく run.user のユーザーで実行します。
+func (p *Prefix) Boot(ctx context.Context, gameCfg *domain.GameConfig, prefixDir string) error {
+	env, err := prefixEnviron(gameCfg, prefixDir)
+	if err != nil {
+		return err
+	}
+	runUser := gameCfg.Run.GetUser()
+	if err := runas.Chown(prefixDir, runUser); err != nil {
+		return err
+	}
+
+	switch gameCfg.RuntimeEnv {
+	case domain.RuntimeEnvWine:
+		if err := run(ctx, env, runUser, "wineboot", "--init"); err != nil {
+			return err
+		}
+		// wineboot はレジストリの書き込みが終わる前に終了するため、wineserver の終了を待つ
+		return run(ctx, env, runUser, "wineserver", "--wait")
+	case domain.RuntimeEnvProton:
+		home, _, err := gameCfg.RunUser()
+		if err != nil {
+			return err
+		}
+		proton, err := FindProton(gameCfg.Runtime.GetProton(), home)
+		if err != nil {
+			return err
+		}
+		env = append(env, "STEAM_COMPAT_CLIENT_INSTALL_PATH="+steamClientDir(home))
+		return run(ctx, env, runUser, proton, "run", "wineboot", "--init")
+	default:
+		return fmt.Errorf("実行環境 %s ではプレフィックスを使用できません", gameCfg.RuntimeEnv)
+	}
+}
+
+// Winetricks はプレフィックスに winetricks で verbs をインストールします。
+// proton では、Protonに同梱されているwineを使用します。Boot と同じく run.user のユーザーで実行します。
+func (p *Prefix) Winetricks(ctx context.Context, gameCfg *domain.GameConfig, prefixDir string, verbs []string) error {
+	env, err := prefixEnviron(gameCfg, prefixDir)
+	if err != nil {
+		return err
+	}
+
+	if gameCfg.RuntimeEnv == domain.RuntimeEnvProton {
+		home, _, err := gameCfg.RunUser()
+		if err != nil {
+			return err
+		}
+		proton, err := FindProton(gameCfg.Runtime.GetProton(), home)
+		if err != nil {
+			return err
+		}
+		wine, wineserver, err := protonWine(proton)
+		if err != nil {
+			return err
+		}
+		env = append(env, "WINE="+wine, "WINESERVER="+wineserver)
+	}
+
+	args := append([]string{"--unattended"}, verbs...)
+	return run(ctx, env, gameCfg.Run.GetUser(), "winetricks", args...)
+}
+
+// prefixEnviron はプレフィックスの操作に使用する環境変数を返します。
+// run.user が設定されている場合は、そのユーザーの HOME などを設定します。
+func prefixEnviron(gameCfg *domain.GameConfig, prefixDir string) ([]string, error) {
+	env := os.Environ()
+	if runUser := gameCfg.Run.GetUser(); runUser != "" {
+		userEnv, err := runas.Env(runUser)
+		if err != nil {
+			return nil, err
+		}
+		env = append(env, userEnv...)
+	}
+	return append(env, gameCfg.PrefixEnv(prefixDir)...), nil
+}
+
+// run はコマンドを runUser のユーザーで実行し、出力をそのまま表示します。
+func run(ctx context.Context, env []string, runUser, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = env
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := runas.SetUser(cmd, runUser); err != nil {
+		return err
+	}
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s の実行に失敗しました: %w", name, err)
+	}
+	return nil
+}
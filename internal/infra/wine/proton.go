@@ -0,0 +1,127 @@
+package wine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+	"github.com/nonuplet/grimoire-archon/internal/infra/filesystem"
+)
+
+// FindProton は name のProtonのスクリプトのパスを返します。
+// name がパスの場合はそのディレクトリまたはスクリプトを、名前の場合は home のユーザーのSteamの compatibilitytools.d と
+// 全てのSteamライブラリの steamapps/common から同じ名前のディレクトリを探します。
+// name が空の場合は、見つかったProtonのうちバージョンが最も新しいものを返します。(domain.CompareProtonVersions を参照)
+func FindProton(name, home string) (string, error) {
+	if strings.ContainsRune(name, filepath.Separator) {
+		path := name
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			path = filepath.Join(path, domain.ProtonScript)
+		}
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("runtime.proton に指定された %s が見つかりません: %w", name, err)
+		}
+		return path, nil
+	}
+
+	installed := installedProtons(home)
+	if name != "" {
+		for _, proton := range installed {
+			if proton.name() == name {
+				return proton.path, nil
+			}
+		}
+		return "", fmt.Errorf("runtime.proton に指定された %s が見つかりません (インストール済み: %s)", name, protonNames(installed))
+	}
+
+	if len(installed) == 0 {
+		return "", fmt.Errorf("インストール済みのProtonが見つかりません。SteamでProtonをインストールするか、runtime.proton にパスを指定してください")
+	}
+	latest := slices.MaxFunc(installed, func(a, b protonInstall) int {
+		return domain.CompareProtonVersions(a.name(), b.name())
+	})
+	return latest.path, nil
+}
+
+// protonInstall はインストール済みのProtonです。
+type protonInstall struct {
+	path string
+}
+
+// name はProtonのディレクトリ名を返します。
+func (p protonInstall) name() string {
+	return filepath.Base(filepath.Dir(p.path))
+}
+
+// installedProtons は home のユーザーのインストール済みのProtonのスクリプトを探します。
+func installedProtons(home string) []protonInstall {
+	steamRoot := os.Getenv("STEAM_ROOT")
+	libraries := domain.SteamLibraries(filesystem.NewFileSystem(), home, steamRoot)
+
+	var installed []protonInstall
+	seen := make(map[string]bool)
+	for _, dir := range domain.ProtonSearchDirs(domain.SteamRootCandidates(home, steamRoot), libraries) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name(), domain.ProtonScript)
+			info, err := os.Stat(path)
+			if err != nil || info.IsDir() {
+				continue
+			}
+			// ~/.steam/steam は ~/.local/share/Steam へのシンボリックリンクであることが多いため、実体が同じものは除く
+			key := path
+			if resolved, err := filepath.EvalSymlinks(path); err == nil {
+				key = resolved
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			installed = append(installed, protonInstall{path: path})
+		}
+	}
+	return installed
+}
+
+// protonNames はインストール済みのProtonの名前をカンマ区切りで返します。
+func protonNames(installed []protonInstall) string {
+	if len(installed) == 0 {
+		return "なし"
+	}
+	names := make([]string, 0, len(installed))
+	for _, proton := range installed {
+		names = append(names, proton.name())
+	}
+	return strings.Join(names, ", ")
+}
+
+// protonWine はProtonに同梱されている wine, wineserver のパスを返します。
+// Protonのバージョンにより files/bin または dist/bin に配置されています。
+func protonWine(proton string) (string, string, error) {
+	protonDir := filepath.Dir(proton)
+	for _, dir := range []string{"files", "dist"} {
+		bin := filepath.Join(protonDir, dir, "bin")
+		if _, err := os.Stat(filepath.Join(bin, "wine")); err == nil {
+			return filepath.Join(bin, "wine"), filepath.Join(bin, "wineserver"), nil
+		}
+	}
+	return "", "", fmt.Errorf("%s にProtonのwineが見つかりません", protonDir)
+}
+
+// steamClientDir はProtonに渡す、home のユーザーのSteamクライアントのインストール先を返します。
+// Steamクライアントがない環境でもProtonを実行できるよう、見つからない場合も候補のパスを返します。
+func steamClientDir(home string) string {
+	candidates := domain.SteamRootCandidates(home, os.Getenv("STEAM_ROOT"))
+	for _, dir := range candidates {
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+	}
+	return candidates[0]
+}
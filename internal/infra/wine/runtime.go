@@ -0,0 +1,100 @@
+package wine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+	"github.com/nonuplet/grimoire-archon/internal/infra/filesystem"
+)
+
+// Runtime は runtime_env に応じたゲームの実行環境です。
+type Runtime struct {
+	// Env はコマンドに追加する環境変数です。
+	Env []string
+	// Launcher は run.command の前に付けて実行するコマンドです。(wine, proton run など)
+	Launcher []string
+	// ReadOnly は実行環境が読み込むディレクトリです。サンドボックスでホームディレクトリが隠れても見えるようにします。
+	ReadOnly []string
+}
+
+// ResolveRuntime は runtime_env に応じた実行環境を返します。
+// wine では WINEPREFIX (prefix を設定している場合)、WINEDEBUG を設定します。
+// proton では STEAM_COMPAT_DATA_PATH (domain.GameConfig.ResolveProtonCompatData を参照), STEAM_COMPAT_CLIENT_INSTALL_PATH を設定し、Protonに同梱されているwineを PATH に追加します。
+// runtime.xvfb が有効な場合は、xvfb-run 経由で起動します。
+func ResolveRuntime(archonCfg *domain.ArchonConfig, gameCfg *domain.GameConfig) (*Runtime, error) {
+	prefixDir := gameCfg.GetPrefixDir(archonCfg)
+
+	var rt Runtime
+	switch gameCfg.RuntimeEnv {
+	case "", domain.RuntimeEnvNative:
+		return &rt, nil
+
+	case domain.RuntimeEnvWine:
+		// prefix が未設定の場合は、環境変数の WINEPREFIX (または ~/.wine) をそのまま使用する
+		if prefixDir != "" {
+			rt.Env = append(rt.Env, gameCfg.PrefixEnv(prefixDir)...)
+		}
+		rt.Env = append(rt.Env, "WINEDEBUG="+gameCfg.Runtime.GetWineDebug())
+		rt.Launcher = []string{"wine"}
+
+	case domain.RuntimeEnvProton:
+		// バックアップと同じく、サーバを実行するユーザー (run.user) のSteamライブラリのプレフィックスを使用する
+		home, _, err := gameCfg.RunUser()
+		if err != nil {
+			return nil, err
+		}
+		prefixDir, err = gameCfg.ResolveProtonCompatData(archonCfg, filesystem.NewFileSystem(), home, os.Getenv("STEAM_ROOT"), os.Getenv("STEAM_COMPAT_DATA_PATH"))
+		if err != nil {
+			return nil, err
+		}
+		proton, err := FindProton(gameCfg.Runtime.GetProton(), home)
+		if err != nil {
+			return nil, err
+		}
+		// isolation でホームディレクトリが隠れても辿れるよう、~/.steam/steam などのリンクを解決しておく
+		proton = realPath(proton)
+		clientDir := realPath(steamClientDir(home))
+
+		rt.Env = append(rt.Env, "STEAM_COMPAT_DATA_PATH="+prefixDir, "STEAM_COMPAT_CLIENT_INSTALL_PATH="+clientDir)
+		rt.Env = append(rt.Env, "WINEPREFIX="+domain.WinePrefixDir(domain.RuntimeEnvProton, prefixDir))
+		rt.Env = append(rt.Env, "WINEDEBUG="+gameCfg.Runtime.GetWineDebug())
+		// exec で wine, winetricks などを使用した場合も、Protonのwineを使用する
+		if wine, wineserver, err := protonWine(proton); err == nil {
+			rt.Env = append(rt.Env, "WINE="+wine, "WINESERVER="+wineserver)
+			rt.Env = append(rt.Env, "PATH="+filepath.Dir(wine)+string(os.PathListSeparator)+os.Getenv("PATH"))
+		}
+		rt.Launcher = []string{proton, "run"}
+		rt.ReadOnly = []string{filepath.Dir(proton), clientDir}
+
+	default:
+		return nil, fmt.Errorf("未知の RuntimeEnvが指定されています: %s", gameCfg.RuntimeEnv)
+	}
+
+	if gameCfg.Runtime.UseXvfb() {
+		rt.Launcher = append([]string{"xvfb-run", "--auto-servernum"}, rt.Launcher...)
+	}
+	return &rt, nil
+}
+
+// Command は command を実行環境で起動するシェルのコマンドを返します。
+func (r *Runtime) Command(command string) string {
+	if len(r.Launcher) == 0 {
+		return command
+	}
+	quoted := make([]string, 0, len(r.Launcher)+1)
+	for _, arg := range r.Launcher {
+		quoted = append(quoted, domain.ShellQuote(arg))
+	}
+	return strings.Join(append(quoted, command), " ")
+}
+
+// realPath はシンボリックリンクを解決したパスを返します。解決できない場合はそのまま返します。
+func realPath(path string) string {
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		return real
+	}
+	return path
+}
@@ -0,0 +1,81 @@
+package workshop
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// publishedFileDetailsURL はワークショップのアイテム情報を取得するSteam Web APIのURLです。APIキーは不要です。
+const publishedFileDetailsURL = "https://api.steampowered.com/ISteamRemoteStorage/GetPublishedFileDetails/v1/"
+
+// Client Steam Web APIによるワークショップのアイテム情報の取得
+type Client struct {
+	client *http.Client
+}
+
+// NewClient Clientのインスタンスを生成する
+func NewClient() *Client {
+	return &Client{client: http.DefaultClient}
+}
+
+// publishedFileDetails は GetPublishedFileDetails のレスポンスです。
+type publishedFileDetails struct {
+	Response struct {
+		Details []struct {
+			PublishedFileID string `json:"publishedfileid"`
+			Result          int    `json:"result"`
+			TimeUpdated     int64  `json:"time_updated"`
+		} `json:"publishedfiledetails"`
+	} `json:"response"`
+}
+
+// TimeUpdated はアイテムIDごとの、ワークショップで最後に更新された日時(Unix時間)を返します。
+// 削除済みや非公開などで情報を取得できなかったアイテムは結果に含まれません。
+func (c *Client) TimeUpdated(ctx context.Context, items []string) (map[string]int64, error) {
+	form := url.Values{}
+	form.Set("itemcount", strconv.Itoa(len(items)))
+	for i, item := range items {
+		form.Set(fmt.Sprintf("publishedfileids[%d]", i), item)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, publishedFileDetailsURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("リクエストの作成に失敗しました: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ワークショップのアイテム情報の取得に失敗しました: %w", err)
+	}
+	defer func(body io.ReadCloser) {
+		if err := body.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "レスポンスのクローズに失敗しました: %v\n", err)
+		}
+	}(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ワークショップのアイテム情報の取得に失敗しました: %s", resp.Status)
+	}
+
+	var details publishedFileDetails
+	if err := json.NewDecoder(resp.Body).Decode(&details); err != nil {
+		return nil, fmt.Errorf("ワークショップのアイテム情報のパースに失敗しました: %w", err)
+	}
+
+	// result が 1 (OK) 以外のアイテムは情報が取得できていない
+	updated := make(map[string]int64, len(items))
+	for _, detail := range details.Response.Details {
+		if detail.Result == 1 {
+			updated[detail.PublishedFileID] = detail.TimeUpdated
+		}
+	}
+	return updated, nil
+}
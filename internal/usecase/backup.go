@@ -1,10 +1,12 @@
 package usecase
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/nonuplet/grimoire-archon/internal/appversion"
@@ -18,37 +20,43 @@ type BackupUsecase struct {
 	snapshot  Snapshot
 	fs        FileSystem
 	cli       Cli
+	locker    Locker
 }
 
 // NewBackupUsecase backupユースケースの生成
 // nolint:lll // 初期化なので
-func NewBackupUsecase(archonCfg *domain.ArchonConfig, gameCfg *domain.GameConfig, snapshot Snapshot, fs FileSystem, cli Cli) *BackupUsecase {
+func NewBackupUsecase(archonCfg *domain.ArchonConfig, gameCfg *domain.GameConfig, snapshot Snapshot, fs FileSystem, cli Cli, locker Locker) *BackupUsecase {
 	return &BackupUsecase{
 		archonCfg: archonCfg,
 		gameCfg:   gameCfg,
 		snapshot:  snapshot,
 		fs:        fs,
 		cli:       cli,
+		locker:    locker,
 	}
 }
 
 // Execute backupの実行
-func (u *BackupUsecase) Execute() error {
+// opts で指定したメモとタグはメタデータに保存されます。作成したバックアップのパスを返します。
+func (u *BackupUsecase) Execute(ctx context.Context, opts domain.BackupOptions) (string, error) {
 	// 必要なコンフィグの情報があるかチェック
 	if err := u.checkPreBackup(); err != nil {
-		return err
+		return "", err
 	}
 
-	// バックアップディレクトリの存在確認と作成
-	if err := u.snapshot.CheckAndCreateSnapshotDir(); err != nil {
-		return fmt.Errorf("バックアップディレクトリ作成に失敗しました: %w", err)
+	// 同じゲームへの操作が同時に実行されないようにロックする
+	unlock, err := u.locker.Lock(u.gameCfg.Name, "backup")
+	if err != nil {
+		return "", err
 	}
+	defer unlock()
 
-	if err := u.createSnapshot(); err != nil {
-		return err
+	// バックアップディレクトリの存在確認と作成
+	if err := u.snapshot.CheckAndCreateSnapshotDir(); err != nil {
+		return "", fmt.Errorf("バックアップディレクトリ作成に失敗しました: %w", err)
 	}
 
-	return nil
+	return u.createSnapshot(ctx, opts)
 }
 
 // checkPreBackup backupの処理前チェック
@@ -71,15 +79,15 @@ func (u *BackupUsecase) checkPreBackup() error {
 	return nil
 }
 
-// createSnapshot バックアップ処理の実行
-func (u *BackupUsecase) createSnapshot() error {
+// createSnapshot バックアップ処理の実行 作成したzipファイルのパスを返す
+func (u *BackupUsecase) createSnapshot(ctx context.Context, opts domain.BackupOptions) (string, error) {
 	// バックアップ先パスの設定
 	snapshotPath := filepath.Join(u.archonCfg.BackupDir, u.gameCfg.Name)
 
 	// tmpフォルダを作成 作業が終わったら成功しても失敗しても消す
 	tmpDir := filepath.Join(snapshotPath, "tmp")
 	if err := u.fs.MkdirAll(tmpDir, 0o755); err != nil {
-		return fmt.Errorf("一時ディレクトリの作成に失敗しました: %w", err)
+		return "", fmt.Errorf("一時ディレクトリの作成に失敗しました: %w", err)
 	}
 	defer func(path string) {
 		err := u.fs.RemoveAll(path)
@@ -92,9 +100,9 @@ func (u *BackupUsecase) createSnapshot() error {
 	archiveName := fmt.Sprintf("%s_%s", u.gameCfg.Name, u.fs.GetTimestamp())
 	archiveDir := filepath.Join(tmpDir, archiveName)
 
-	entries, err := u.snapshot.CopyToTmp(archiveDir)
+	entries, err := u.snapshot.CopyToTmp(ctx, archiveDir)
 	if err != nil {
-		return fmt.Errorf("バックアップファイルのコピーに失敗しました: %w", err)
+		return "", fmt.Errorf("バックアップファイルのコピーに失敗しました: %w", err)
 	}
 
 	// metadata.yamlの構築と保存
@@ -104,17 +112,47 @@ func (u *BackupUsecase) createSnapshot() error {
 		CreatedAt:   time.Now(),
 		ToolVersion: appversion.Version(),
 		Os:          runtime.GOOS,
+		RuntimeEnv:  u.gameCfg.RuntimeEnv,
+		WinProfile:  u.snapshot.GetWinProfile(),
+		Trigger:     opts.Trigger,
+		Note:        opts.Note,
+		Tags:        buildTags(opts),
 		Files:       entries,
 	}
 	if err := u.snapshot.SaveMetaData(filepath.Join(archiveDir, "metadata.yaml"), meta); err != nil {
-		return fmt.Errorf("metadata.yamlの保存に失敗しました: %w", err)
+		return "", fmt.Errorf("metadata.yamlの保存に失敗しました: %w", err)
 	}
 
 	// zipにする
 	zipPath := filepath.Join(snapshotPath, fmt.Sprintf("%s.zip", archiveName))
-	if err := u.fs.Zip(tmpDir, zipPath); err != nil {
-		return fmt.Errorf("バックアップの圧縮に失敗しました: %w", err)
+	if err := u.fs.Zip(ctx, tmpDir, zipPath); err != nil {
+		return "", fmt.Errorf("バックアップの圧縮に失敗しました: %w", err)
 	}
 
-	return nil
+	return zipPath, nil
+}
+
+// buildTags メタデータに保存するタグを構築する
+// 自動で作成されたバックアップには、きっかけ(trigger)をタグとして付与する
+func buildTags(opts domain.BackupOptions) []string {
+	tags := make([]string, 0, len(opts.Tags)+1)
+	seen := make(map[string]bool, len(opts.Tags)+1)
+
+	add := func(tag string) {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			return
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	for _, tag := range opts.Tags {
+		add(tag)
+	}
+	if opts.Trigger != "" && opts.Trigger != domain.TriggerManual {
+		add(string(opts.Trigger))
+	}
+
+	return tags
 }
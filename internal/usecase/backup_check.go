@@ -1,6 +1,8 @@
 package usecase
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -12,9 +14,9 @@ import (
 
 // Check はバックアップの状態を確認を行い、必要があればバックアップを実行する
 // cleanの前処理として実行される
-func (u *BackupUsecase) Check() error {
+func (u *BackupUsecase) Check(ctx context.Context) error {
 	// 削除前にバックアップがあるかチェック
-	condition, err := u.checkBackupCondition()
+	condition, err := u.checkBackupCondition(ctx)
 	if err != nil {
 		return fmt.Errorf("バックアップチェックに失敗しました: %w", err)
 	}
@@ -55,12 +57,12 @@ func (u *BackupUsecase) Check() error {
 }
 
 // checkBackupCondition 24時間以内に作成されたzipファイルが、バックアップディレクトリ内にあるかチェック
-func (u *BackupUsecase) checkBackupCondition() (domain.SnapshotCondition, error) {
+func (u *BackupUsecase) checkBackupCondition(ctx context.Context) (domain.SnapshotCondition, error) {
 	backupPath := filepath.Join(u.archonCfg.BackupDir, u.gameCfg.Name)
 
 	// バックアップディレクトリをチェック
-	if _, err := u.fs.Stat(backupPath); os.IsNotExist(err) {
-		ok, err := u.askAndBackup(fmt.Sprintf("バックアップ先 '%s' が存在しません。バックアップしますか？", backupPath))
+	if _, err := u.fs.Stat(backupPath); errors.Is(err, os.ErrNotExist) {
+		ok, err := u.askAndBackup(ctx, fmt.Sprintf("バックアップ先 '%s' が存在しません。バックアップしますか？", backupPath))
 		if err != nil {
 			return -1, fmt.Errorf("バックアップに失敗しました: %w", err)
 		}
@@ -79,7 +81,7 @@ func (u *BackupUsecase) checkBackupCondition() (domain.SnapshotCondition, error)
 
 	// バックアップディレクトリはあるが、.zipファイルが見つからない場合
 	if len(matches) > 0 {
-		ok, zipErr := u.askAndBackup("バックアップ先にzipが一つもありません。バックアップしますか？")
+		ok, zipErr := u.askAndBackup(ctx, "バックアップ先にzipが一つもありません。バックアップしますか？")
 		if zipErr != nil {
 			return -1, fmt.Errorf("バックアップに失敗しました: %w", err)
 		}
@@ -95,7 +97,7 @@ func (u *BackupUsecase) checkBackupCondition() (domain.SnapshotCondition, error)
 		return -1, fmt.Errorf("バックアップファイルの確認に失敗しました: %w", err)
 	}
 	if !exist {
-		ok, err := u.askAndBackup("24時間以内に作成されたバックアップがないようです。バックアップしますか？")
+		ok, err := u.askAndBackup(ctx, "24時間以内に作成されたバックアップがないようです。バックアップしますか？")
 		if err != nil {
 			return -1, fmt.Errorf("バックアップに失敗しました: %w", err)
 		}
@@ -108,7 +110,7 @@ func (u *BackupUsecase) checkBackupCondition() (domain.SnapshotCondition, error)
 }
 
 // askAndBackup バックアップの確認と実行
-func (u *BackupUsecase) askAndBackup(msg string) (bool, error) {
+func (u *BackupUsecase) askAndBackup(ctx context.Context, msg string) (bool, error) {
 	ok, err := u.cli.AskYesNo(os.Stdin, msg, true)
 	if err != nil {
 		return false, fmt.Errorf("確認に失敗しました: %w", err)
@@ -120,7 +122,7 @@ func (u *BackupUsecase) askAndBackup(msg string) (bool, error) {
 	}
 
 	// バックアップする
-	err = u.Execute()
+	_, err = u.Execute(ctx, domain.BackupOptions{Trigger: domain.TriggerPreClean})
 	if err != nil {
 		return false, fmt.Errorf("バックアップに失敗しました: %w", err)
 	}
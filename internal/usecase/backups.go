@@ -0,0 +1,210 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+// BackupsUsecase 作成済みバックアップの管理を行うユースケース
+type BackupsUsecase struct {
+	archonCfg *domain.ArchonConfig
+	gameCfg   *domain.GameConfig
+	snapshot  Snapshot
+	fs        FileSystem
+	locker    Locker
+}
+
+// NewBackupsUsecase BackupsUsecaseのインスタンスを生成する
+// nolint:lll // 初期化なので
+func NewBackupsUsecase(archonCfg *domain.ArchonConfig, gameCfg *domain.GameConfig, snapshot Snapshot, fs FileSystem, locker Locker) *BackupsUsecase {
+	return &BackupsUsecase{
+		archonCfg: archonCfg,
+		gameCfg:   gameCfg,
+		snapshot:  snapshot,
+		fs:        fs,
+		locker:    locker,
+	}
+}
+
+// Migrate はバックアップディレクトリ内の全てのバックアップのメタデータを、現在のバージョンに書き換えます。
+func (u *BackupsUsecase) Migrate(ctx context.Context) error {
+	if err := u.checkPreBackups(); err != nil {
+		return err
+	}
+
+	// バックアップの書き換え中に他の操作が実行されないようにロックする
+	unlock, err := u.locker.Lock(u.gameCfg.Name, "backups migrate")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	zips, err := u.listArchives()
+	if err != nil {
+		return err
+	}
+	if len(zips) == 0 {
+		fmt.Printf("%s のバックアップはありません。\n", u.gameCfg.Name)
+		return nil
+	}
+
+	var failed int
+	for _, zipPath := range zips {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("マイグレーションを中断しました: %w", err)
+		}
+		migrated, err := u.snapshot.MigrateArchive(zipPath)
+		switch {
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "%s: マイグレーションに失敗しました: %v\n", filepath.Base(zipPath), err)
+			failed++
+		case migrated:
+			fmt.Printf("%s: バージョン %s に更新しました。\n", filepath.Base(zipPath), domain.MetaVersion)
+		default:
+			fmt.Printf("%s: 最新です。\n", filepath.Base(zipPath))
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d 件のバックアップのマイグレーションに失敗しました", failed)
+	}
+	return nil
+}
+
+// Find は tags を全て持つバックアップを、古い順に返します。tags が空の場合は全てのバックアップを返します。
+// メタデータが読み込めないバックアップは警告を表示してスキップします。
+func (u *BackupsUsecase) Find(ctx context.Context, tags []string) ([]domain.SnapshotArchive, error) {
+	if err := u.checkPreBackups(); err != nil {
+		return nil, err
+	}
+
+	zips, err := u.listArchives()
+	if err != nil {
+		return nil, err
+	}
+
+	var archives []domain.SnapshotArchive
+	for _, zipPath := range zips {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("バックアップの検索を中断しました: %w", err)
+		}
+		meta, err := u.snapshot.LoadArchiveMetaData(zipPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: メタデータの読み込みに失敗したためスキップします: %v\n", filepath.Base(zipPath), err)
+			continue
+		}
+		if !meta.HasTags(tags) {
+			continue
+		}
+		archives = append(archives, domain.SnapshotArchive{Path: zipPath, Meta: meta})
+	}
+
+	return archives, nil
+}
+
+// FindLatest は tags を全て持つバックアップのうち、最も新しいものを返します。
+func (u *BackupsUsecase) FindLatest(ctx context.Context, tags []string) (*domain.SnapshotArchive, error) {
+	archives, err := u.Find(ctx, tags)
+	if err != nil {
+		return nil, err
+	}
+	if len(archives) == 0 {
+		if len(tags) > 0 {
+			return nil, fmt.Errorf("タグ %v を持つバックアップが見つかりません", tags)
+		}
+		return nil, fmt.Errorf("%s のバックアップが見つかりません", u.gameCfg.Name)
+	}
+
+	latest := archives[0]
+	for _, archive := range archives[1:] {
+		if !archive.Meta.CreatedAt.Before(latest.Meta.CreatedAt) {
+			latest = archive
+		}
+	}
+	return &latest, nil
+}
+
+// Prune は新しいものから keep 件を残して、古いバックアップを削除します。
+func (u *BackupsUsecase) Prune(ctx context.Context, keep int) error {
+	if err := u.checkPreBackups(); err != nil {
+		return err
+	}
+	if keep < 1 {
+		return fmt.Errorf("残すバックアップの数は1以上を指定してください: %d", keep)
+	}
+
+	// 削除中に他の操作が実行されないようにロックする
+	unlock, err := u.locker.Lock(u.gameCfg.Name, "backups prune")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	zips, err := u.listArchives()
+	if err != nil {
+		return err
+	}
+	if len(zips) <= keep {
+		fmt.Printf("%s のバックアップは %d 件です。削除するバックアップはありません。\n", u.gameCfg.Name, len(zips))
+		return nil
+	}
+
+	// listArchives は古い順なので、先頭から削除する
+	targets := zips[:len(zips)-keep]
+	for _, zipPath := range targets {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("バックアップの削除を中断しました: %w", err)
+		}
+		if err := u.fs.RemoveAll(zipPath); err != nil {
+			return fmt.Errorf("%s の削除に失敗しました: %w", filepath.Base(zipPath), err)
+		}
+		fmt.Printf("%s を削除しました。\n", filepath.Base(zipPath))
+	}
+
+	fmt.Printf("%s のバックアップを %d 件削除しました。(残り %d 件)\n", u.gameCfg.Name, len(targets), keep)
+	return nil
+}
+
+// checkPreBackups バックアップ管理の処理前チェック
+func (u *BackupsUsecase) checkPreBackups() error {
+	if u.archonCfg == nil {
+		return fmt.Errorf("archonのコンフィグが定義されていません。")
+	}
+	if u.archonCfg.BackupDir == "" {
+		return fmt.Errorf("バックアップ先が設定されていません。")
+	}
+	return nil
+}
+
+// listArchives バックアップディレクトリ内のバックアップzipのパスを、古い順に返す
+func (u *BackupsUsecase) listArchives() ([]string, error) {
+	backupPath := filepath.Join(u.archonCfg.BackupDir, u.gameCfg.Name)
+	if _, err := u.fs.Stat(backupPath); err != nil {
+		return nil, nil
+	}
+
+	files, err := u.fs.ReadDir(backupPath)
+	if err != nil {
+		return nil, fmt.Errorf("バックアップディレクトリの読み込みに失敗しました: %w", err)
+	}
+
+	prefix := u.gameCfg.Name + "_"
+	var zips []string
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ".zip") {
+			continue
+		}
+		zips = append(zips, filepath.Join(backupPath, name))
+	}
+
+	// ファイル名のタイムスタンプ順 = 作成順
+	sort.Strings(zips)
+	return zips, nil
+}
@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"runtime"
@@ -11,22 +12,33 @@ import (
 
 // CheckConfigUsecase コンフィグのチェックを行うユースケース
 type CheckConfigUsecase struct {
-	cfg *domain.Config
-	fs  FileSystem
-	cli Cli
+	cfg         *domain.Config
+	fs          FileSystem
+	cli         Cli
+	newSnapshot SnapshotFactory
+	ports       PortChecker
+	process     ProcessFinder
+	resources   ResourceMonitor
 }
 
 // NewCheckConfigUsecase CheckConfigUsecaseのインスタンスを生成する
-func NewCheckConfigUsecase(cfg *domain.Config, fs FileSystem, cli Cli) *CheckConfigUsecase {
+// newSnapshot は wine, proton のゲームで使用する Windows のプロファイルの表示に使用します。
+// ports, process は ports が他のプロセスに使用されていないかの確認に、resources は cgroup のコントローラの確認に使用します。
+// nolint:lll // 初期化なので
+func NewCheckConfigUsecase(cfg *domain.Config, fs FileSystem, cli Cli, newSnapshot SnapshotFactory, ports PortChecker, process ProcessFinder, resources ResourceMonitor) *CheckConfigUsecase {
 	return &CheckConfigUsecase{
-		cfg: cfg,
-		fs:  fs,
-		cli: cli,
+		cfg:         cfg,
+		fs:          fs,
+		cli:         cli,
+		newSnapshot: newSnapshot,
+		ports:       ports,
+		process:     process,
+		resources:   resources,
 	}
 }
 
 // Execute コンフィグのチェックを実行する
-func (u *CheckConfigUsecase) Execute() error {
+func (u *CheckConfigUsecase) Execute(ctx context.Context) error {
 	// Config のチェック
 	res := u.checkConfig()
 	if res != "" {
@@ -44,7 +56,16 @@ func (u *CheckConfigUsecase) Execute() error {
 	}
 
 	// ゲームのチェック
-	if result := u.checkAllGameConfigs(u.cfg.Games); result {
+	isError := u.checkAllGameConfigs(ctx, u.cfg.Games)
+
+	// ゲーム間のポートの重複のチェック
+	if res := u.checkPortConflicts(); res != "" {
+		fmt.Println("ポートの重複が見つかりました。")
+		fmt.Fprint(os.Stderr, res)
+		isError = true
+	}
+
+	if isError {
 		fmt.Println("ゲーム設定にエラーが見つかりました。")
 		return fmt.Errorf("ゲーム設定にエラーが見つかりました。")
 	}
@@ -94,14 +115,24 @@ func (u *CheckConfigUsecase) checkArchonConfig(archonCfg *domain.ArchonConfig) s
 		u.cli.Writeln(&sb, baseMsg, "Documentディレクトリ ", archonCfg.DocumentDir, " は指定されていますが、見つかりません。")
 	}
 
+	if path := archonCfg.GetSteamCmdPath(); path != "" {
+		if _, err := u.fs.Stat(path); err != nil {
+			u.cli.Writeln(&sb, baseMsg, "steamcmd.path ", path, " は指定されていますが、見つかりません。")
+		}
+	}
+
 	return sb.String()
 }
 
 // checkAllGameConfigs 全ゲームのコンフィグのチェック
-func (u *CheckConfigUsecase) checkAllGameConfigs(games map[string]*domain.GameConfig) bool {
+func (u *CheckConfigUsecase) checkAllGameConfigs(ctx context.Context, games map[string]*domain.GameConfig) bool {
 	isError := false
 
 	for game, gameCfg := range games {
+		if ctx.Err() != nil {
+			fmt.Println("チェックを中断しました。")
+			return true
+		}
 		fmt.Printf("%s ... ", game)
 		if res := u.checkGameConfig(game, gameCfg); res != "" {
 			fmt.Println("error!")
@@ -110,11 +141,110 @@ func (u *CheckConfigUsecase) checkAllGameConfigs(games map[string]*domain.GameCo
 		} else {
 			fmt.Println("OK.")
 		}
+		u.reportWinProfile(gameCfg)
 	}
 
 	return isError
 }
 
+// checkPortConflicts 複数のゲームで同じポートが設定されていないかのチェック
+func (u *CheckConfigUsecase) checkPortConflicts() string {
+	var sb strings.Builder
+	for _, conflict := range domain.FindPortConflicts(u.cfg.Games) {
+		u.cli.Writeln(&sb, "ports: ", "ポート ", conflict.Port.String(), " が複数のゲームで設定されています: ", strings.Join(conflict.Games, ", "))
+	}
+	return sb.String()
+}
+
+// checkPorts ports の値と、ホストで他のプロセスに使用されていないかのチェック
+// サーバが実行中の場合は、サーバ自身が使用しているため確認しない
+func (u *CheckConfigUsecase) checkPorts(sb *strings.Builder, baseMsg string, gameCfg *domain.GameConfig) {
+	if len(gameCfg.Ports) == 0 {
+		return
+	}
+	if err := domain.ValidatePorts(gameCfg.Ports); err != nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			u.cli.Writeln(sb, baseMsg, "ports が不正です: ", line)
+		}
+		return
+	}
+
+	if pids, err := u.process.FindRunning(gameCfg); err != nil || len(pids) > 0 {
+		return
+	}
+	for _, port := range gameCfg.Ports {
+		// 権限がないなどで確認できない場合はスキップする
+		if inUse, err := u.ports.InUse(port.Port, port.Protocol); err == nil && inUse {
+			u.cli.Writeln(sb, baseMsg, "ポート ", port.String(), " は他のプロセスが使用中です。")
+		}
+	}
+}
+
+// checkResources resources の値と、制限に必要な cgroup v2 のコントローラが使用できるかのチェック
+func (u *CheckConfigUsecase) checkResources(sb *strings.Builder, baseMsg string, gameCfg *domain.GameConfig) {
+	if gameCfg.Resources == nil {
+		return
+	}
+	if err := gameCfg.Resources.Validate(); err != nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			u.cli.Writeln(sb, baseMsg, "resources が不正です: ", line)
+		}
+		return
+	}
+	if err := u.resources.Check(gameCfg); err != nil {
+		u.cli.Writeln(sb, baseMsg, "resources を使用できません: ", err.Error())
+	}
+}
+
+// checkRunConfig run のチェック
+// run.command の実行ファイルの存在は、wine, proton の場合にパスの解釈が異なるためチェックしない
+func (u *CheckConfigUsecase) checkRunConfig(sb *strings.Builder, baseMsg string, gameCfg *domain.GameConfig) {
+	run := gameCfg.Run
+	if run == nil {
+		return
+	}
+	if err := run.Validate(); err != nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			u.cli.Writeln(sb, baseMsg, "run が不正です: ", line)
+		}
+	}
+	if runtime.GOOS != "linux" && run.UsesPlatformSettings() {
+		u.cli.Writeln(sb, baseMsg, "run.user, run.umask, run.nice, run.ionice, run.stop_signal はLinuxでのみ使用できます。")
+	}
+
+	if workdir := run.GetWorkdir(gameCfg.InstallDir); workdir != gameCfg.InstallDir {
+		if info, err := u.fs.Stat(workdir); err != nil || !info.IsDir() {
+			u.cli.Writeln(sb, baseMsg, "run.workdir ", workdir, " は見つかりません。")
+		}
+	}
+	if envFile := run.GetEnvFile(gameCfg.InstallDir); envFile != "" {
+		if data, err := u.fs.ReadFile(envFile); err != nil {
+			u.cli.Writeln(sb, baseMsg, "run.env_file ", envFile, " を読み込めません: ", err.Error())
+		} else if _, err := domain.ParseDotEnv(data); err != nil {
+			u.cli.Writeln(sb, baseMsg, "run.env_file ", envFile, " が不正です: ", err.Error())
+		}
+	}
+}
+
+// reportWinProfile wine, proton のゲームで、バックアップに使用する Windows のプロファイルを表示する
+// proton の場合は、Steamライブラリから選ばれた compatdata の確認に使う
+func (u *CheckConfigUsecase) reportWinProfile(gameCfg *domain.GameConfig) {
+	if gameCfg.RuntimeEnv != domain.RuntimeEnvWine && gameCfg.RuntimeEnv != domain.RuntimeEnvProton {
+		return
+	}
+
+	profile := u.newSnapshot(gameCfg).GetWinProfile()
+	if profile == "" {
+		fmt.Println("  Windowsのプロファイル: 取得できません")
+		return
+	}
+	if _, err := u.fs.Stat(profile); err != nil {
+		fmt.Printf("  Windowsのプロファイル: %s (見つかりません)\n", profile)
+		return
+	}
+	fmt.Printf("  Windowsのプロファイル: %s\n", profile)
+}
+
 // checkGameConfig ゲーム単体のコンフィグチェック
 func (u *CheckConfigUsecase) checkGameConfig(game string, gameCfg *domain.GameConfig) string {
 	baseMsg := fmt.Sprintf("game: %s: ", game)
@@ -130,8 +260,8 @@ func (u *CheckConfigUsecase) checkGameConfig(game string, gameCfg *domain.GameCo
 		return sb.String()
 	}
 
-	// Linux向けチェック
-	if runtime.GOOS == "linux" {
+	// Linux向けチェック (steamcmdでダウンロードする場合のみ)
+	if runtime.GOOS == "linux" && gameCfg.Steam != nil && gameCfg.Install.GetProvider() == domain.InstallProviderSteam {
 		winBinary := gameCfg.Steam.Platform == "windows"
 		runNative := gameCfg.RuntimeEnv == "" || gameCfg.RuntimeEnv == domain.RuntimeEnvNative
 
@@ -144,6 +274,70 @@ func (u *CheckConfigUsecase) checkGameConfig(game string, gameCfg *domain.GameCo
 		}
 	}
 
+	// install
+	if err := gameCfg.Install.Validate(gameCfg.Steam); err != nil {
+		u.cli.Writeln(&sb, baseMsg, "install が不正です: ", err.Error())
+	}
+
+	// steam
+	if gameCfg.Steam != nil && gameCfg.Steam.BranchPassword != "" && gameCfg.Steam.GetBranch() == domain.DefaultSteamBranch {
+		u.cli.Writeln(&sb, baseMsg, "steam.branch_password を使用するには steam.branch を指定してください。")
+	}
+
+	if gameCfg.Steam != nil && gameCfg.Steam.Login != nil && gameCfg.Steam.Login.Username == "" {
+		u.cli.Writeln(&sb, baseMsg, "steam.login を使用するには steam.login.username を指定してください。")
+	}
+
+	// run
+	u.checkRunConfig(&sb, baseMsg, gameCfg)
+
+	// ports
+	u.checkPorts(&sb, baseMsg, gameCfg)
+
+	// resources
+	u.checkResources(&sb, baseMsg, gameCfg)
+
+	// isolation
+	if err := gameCfg.Isolation.Validate(gameCfg.RuntimeEnv, gameCfg.Prefix); err != nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			u.cli.Writeln(&sb, baseMsg, "isolation が不正です: ", line)
+		}
+	}
+	if runtime.GOOS != "linux" && gameCfg.Isolation.IsEnabled() {
+		u.cli.Writeln(&sb, baseMsg, "isolation はLinuxでのみ使用できます。")
+	}
+	if runtime.GOOS == "linux" && gameCfg.Isolation.IsEnabled() && os.Geteuid() == 0 && (gameCfg.Run == nil || gameCfg.Run.User == "" || gameCfg.Run.User == "root") {
+		u.cli.Writeln(&sb, baseMsg, "isolation を root で使用するには run.user に root 以外のユーザーを指定してください。")
+	}
+
+	// schedule
+	if _, err := gameCfg.Schedule.GetJobs(game); err != nil {
+		u.cli.Writeln(&sb, baseMsg, "schedule が不正です: ", err.Error())
+	}
+	if gameCfg.Schedule != nil && gameCfg.Schedule.Restart != "" && (gameCfg.Run == nil || gameCfg.Run.Command == "") {
+		u.cli.Writeln(&sb, baseMsg, "schedule.restart を使用するには run.command を設定してください。")
+	}
+
+	// update
+	if err := gameCfg.Update.Validate(); err != nil {
+		u.cli.Writeln(&sb, baseMsg, "update が不正です: ", err.Error())
+	}
+
+	// workshop
+	if err := gameCfg.Workshop.Validate(gameCfg.Steam); err != nil {
+		u.cli.Writeln(&sb, baseMsg, "workshop が不正です: ", err.Error())
+	}
+
+	// prefix
+	if err := gameCfg.Prefix.Validate(gameCfg.RuntimeEnv); err != nil {
+		u.cli.Writeln(&sb, baseMsg, "prefix が不正です: ", err.Error())
+	}
+
+	// runtime
+	if err := gameCfg.Runtime.Validate(gameCfg.RuntimeEnv); err != nil {
+		u.cli.Writeln(&sb, baseMsg, "runtime が不正です: ", err.Error())
+	}
+
 	// TODO: 将来的にファイルチェックも行う
 
 	return sb.String()
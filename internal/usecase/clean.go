@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/nonuplet/grimoire-archon/internal/domain"
@@ -12,28 +13,49 @@ type CleanUsecase struct {
 	gameCfg   *domain.GameConfig
 	fs        FileSystem
 	cli       Cli
+	locker    Locker
+	process   ProcessFinder
 }
 
 // NewCleanUsecase cleanのユースケースのインスタンスを生成する
-func NewCleanUsecase(archonCfg *domain.ArchonConfig, gameCfg *domain.GameConfig, fs FileSystem, cli Cli) *CleanUsecase {
+// nolint:lll // 初期化なので
+func NewCleanUsecase(archonCfg *domain.ArchonConfig, gameCfg *domain.GameConfig, fs FileSystem, cli Cli, locker Locker, process ProcessFinder) *CleanUsecase {
 	return &CleanUsecase{
 		archonCfg: archonCfg,
 		gameCfg:   gameCfg,
 		fs:        fs,
 		cli:       cli,
+		locker:    locker,
+		process:   process,
 	}
 }
 
 // Execute cleanの実行
-func (u *CleanUsecase) Execute() error {
+func (u *CleanUsecase) Execute(ctx context.Context) error {
 	// コンフィグのチェック
 	if err := u.checkPreClean(); err != nil {
 		return err
 	}
 
+	// 同じゲームへの操作が同時に実行されないようにロックする
+	unlock, err := u.locker.Lock(u.gameCfg.Name, "clean")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// サーバが実行中なら削除しない
+	if err := checkNotRunning(u.process, u.gameCfg); err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("削除処理を中断しました: %w", err)
+	}
+
 	// ユーザに確認
 	fmt.Printf("%s の削除処理を実行します...\n", u.gameCfg.Name)
-	err := u.fs.ClearDirectoryContents(u.gameCfg.InstallDir)
+	err = u.fs.ClearDirectoryContents(u.gameCfg.InstallDir)
 	if err != nil {
 		return fmt.Errorf("削除処理に失敗しました: %w", err)
 	}
@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"fmt"
+)
+
+// CredentialsUsecase 暗号化済みの認証情報の管理のユースケース
+type CredentialsUsecase struct {
+	store CredentialStore
+	cli   Cli
+}
+
+// NewCredentialsUsecase CredentialsUsecaseのインスタンスを生成する
+func NewCredentialsUsecase(store CredentialStore, cli Cli) *CredentialsUsecase {
+	return &CredentialsUsecase{
+		store: store,
+		cli:   cli,
+	}
+}
+
+// Set は name の認証情報を入力させ、passphrase で暗号化して保存します。
+// passphrase が空の場合は入力を促します。
+func (u *CredentialsUsecase) Set(name, passphrase string) error {
+	if name == "" {
+		return fmt.Errorf("認証情報の名前を指定してください")
+	}
+
+	secret, err := u.askTwice("パスワード")
+	if err != nil {
+		return err
+	}
+	if secret == "" {
+		return fmt.Errorf("パスワードが空です")
+	}
+
+	if passphrase == "" {
+		if passphrase, err = u.askTwice("暗号化に使うパスフレーズ"); err != nil {
+			return err
+		}
+	}
+
+	if err := u.store.Set(name, secret, passphrase); err != nil {
+		return fmt.Errorf("認証情報 %s の保存に失敗しました: %w", name, err)
+	}
+	fmt.Printf("認証情報 %s を保存しました。\n", name)
+	return nil
+}
+
+// Delete は name の認証情報を削除します。
+func (u *CredentialsUsecase) Delete(name string) error {
+	if err := u.store.Delete(name); err != nil {
+		return fmt.Errorf("認証情報 %s の削除に失敗しました: %w", name, err)
+	}
+	fmt.Printf("認証情報 %s を削除しました。\n", name)
+	return nil
+}
+
+// List は保存されている認証情報の名前を表示します。値は表示しません。
+func (u *CredentialsUsecase) List() error {
+	names, err := u.store.List()
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		fmt.Println("保存されている認証情報はありません。")
+		return nil
+	}
+	for _, name := range names {
+		fmt.Println(name)
+	}
+	return nil
+}
+
+// askTwice 入力ミスを防ぐため、秘密情報を2回入力させる
+func (u *CredentialsUsecase) askTwice(label string) (string, error) {
+	first, err := u.cli.AskSecret(label)
+	if err != nil {
+		return "", fmt.Errorf("%sの入力に失敗しました: %w", label, err)
+	}
+	second, err := u.cli.AskSecret(label + " (確認)")
+	if err != nil {
+		return "", fmt.Errorf("%sの入力に失敗しました: %w", label, err)
+	}
+	if first != second {
+		return "", fmt.Errorf("%sが一致しません", label)
+	}
+	return first, nil
+}
@@ -0,0 +1,39 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+// ExecUsecase ゲームの実行環境で任意のコマンドを実行するユースケース
+type ExecUsecase struct {
+	archonCfg *domain.ArchonConfig
+	gameCfg   *domain.GameConfig
+	runner    CommandRunner
+}
+
+// NewExecUsecase ExecUsecaseのインスタンスを生成する
+func NewExecUsecase(archonCfg *domain.ArchonConfig, gameCfg *domain.GameConfig, runner CommandRunner) *ExecUsecase {
+	return &ExecUsecase{
+		archonCfg: archonCfg,
+		gameCfg:   gameCfg,
+		runner:    runner,
+	}
+}
+
+// Execute は args をサーバと同じ実行環境(WINEPREFIX などの環境変数)で、インストールディレクトリで実行します。
+// サーバの実行中に rcon クライアントや winecfg などを使用できるよう、ロックは取得しません。
+func (u *ExecUsecase) Execute(ctx context.Context, args []string) error {
+	if u.archonCfg == nil {
+		return fmt.Errorf("archonのコンフィグが定義されていません。")
+	}
+	if u.gameCfg.InstallDir == "" {
+		return fmt.Errorf("インストールディレクトリが設定されていません")
+	}
+	if err := u.gameCfg.Runtime.Validate(u.gameCfg.RuntimeEnv); err != nil {
+		return err
+	}
+	return u.runner.Exec(ctx, u.gameCfg, args)
+}
@@ -0,0 +1,37 @@
+package usecase
+
+import (
+	"fmt"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+// FirewallUsecase ファイアウォールのルールの出力のユースケース
+type FirewallUsecase struct {
+	cfg *domain.Config
+}
+
+// NewFirewallUsecase FirewallUsecaseのインスタンスを生成する
+func NewFirewallUsecase(cfg *domain.Config) *FirewallUsecase {
+	return &FirewallUsecase{cfg: cfg}
+}
+
+// Export は全てのゲームの ports を許可するファイアウォールのルールを format の形式で返します。
+// 不正な ports やゲーム間のポートの重複がある場合は、ルールを出力せずにエラーを返します。
+func (u *FirewallUsecase) Export(format domain.FirewallFormat) (string, error) {
+	if u.cfg == nil || len(u.cfg.Games) == 0 {
+		return "", fmt.Errorf("ゲーム設定が見つかりません")
+	}
+
+	for name, game := range u.cfg.Games {
+		if err := domain.ValidatePorts(game.Ports); err != nil {
+			return "", fmt.Errorf("%s の ports が不正です: %w", name, err)
+		}
+	}
+	if conflicts := domain.FindPortConflicts(u.cfg.Games); len(conflicts) > 0 {
+		conflict := conflicts[0]
+		return "", fmt.Errorf("ポート %s が複数のゲームで設定されています: %v (archon check-config で全ての重複を確認できます)", conflict.Port, conflict.Games)
+	}
+
+	return domain.FirewallRules(format, u.cfg.Games)
+}
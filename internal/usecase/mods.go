@@ -0,0 +1,267 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/goccy/go-yaml"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+// ModsUsecase Steamワークショップのアイテム(Mod)の同期のユースケース
+type ModsUsecase struct {
+	archonCfg *domain.ArchonConfig
+	gameCfg   *domain.GameConfig
+	steamCmd  SteamCmd
+	workshop  WorkshopClient
+	fs        FileSystem
+	locker    Locker
+	process   ProcessFinder
+}
+
+// NewModsUsecase ModsUsecaseのインスタンスを生成する
+// nolint:lll // 初期化なので
+func NewModsUsecase(archonCfg *domain.ArchonConfig, gameCfg *domain.GameConfig, steamCmd SteamCmd, workshop WorkshopClient, fs FileSystem, locker Locker, process ProcessFinder) *ModsUsecase {
+	return &ModsUsecase{
+		archonCfg: archonCfg,
+		gameCfg:   gameCfg,
+		steamCmd:  steamCmd,
+		workshop:  workshop,
+		fs:        fs,
+		locker:    locker,
+		process:   process,
+	}
+}
+
+// Sync は workshop.items のアイテムをsteamcmdでダウンロードし、install_dir 以下の workshop.mods_dir に配置します。
+// 同期済みのバージョンはロックファイルに記録し、ワークショップで更新されていないアイテムはスキップします。
+// workshop.items から外したアイテムは mods_dir から削除します。
+func (u *ModsUsecase) Sync(ctx context.Context) error {
+	if err := u.checkPreSync(); err != nil {
+		return err
+	}
+
+	// 同じゲームへの操作が同時に実行されないようにロックする
+	unlock, err := u.locker.Lock(u.gameCfg.Name, "mods sync")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// サーバが読み込み中のファイルを置き換えないようにする
+	if err := checkNotRunning(u.process, u.gameCfg); err != nil {
+		return err
+	}
+
+	lock, err := u.loadLock()
+	if err != nil {
+		return err
+	}
+
+	// 取得できなかった場合は全てのアイテムを同期する
+	items := u.gameCfg.Workshop.Items
+	updated, err := u.workshop.TimeUpdated(ctx, items)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ワークショップの更新日時を取得できなかったため、全てのアイテムを同期します: %v\n", err)
+		updated = nil
+	}
+
+	pending, err := u.pendingItems(lock, updated)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		fmt.Printf("%s のワークショップのアイテムは全て最新です。\n", u.gameCfg.Name)
+	} else if err := u.syncItems(ctx, lock, pending, updated); err != nil {
+		return err
+	}
+
+	return u.removeUnlisted(lock)
+}
+
+// checkPreSync 同期前のチェック
+func (u *ModsUsecase) checkPreSync() error {
+	if u.archonCfg == nil {
+		return fmt.Errorf("archonのコンフィグが定義されていません。")
+	}
+	if u.gameCfg.InstallDir == "" {
+		return fmt.Errorf("インストールディレクトリが設定されていません")
+	}
+	if u.gameCfg.Workshop == nil {
+		return fmt.Errorf("workshop が設定されていません")
+	}
+	if err := u.gameCfg.Workshop.Validate(u.gameCfg.Steam); err != nil {
+		return fmt.Errorf("workshop が不正です: %w", err)
+	}
+	if err := u.steamCmd.Check(); err != nil {
+		return fmt.Errorf("ワークショップの同期に失敗しました: %w", err)
+	}
+	return nil
+}
+
+// pendingItems 同期が必要なアイテムを返す
+// ロックファイルに記録された更新日時がワークショップと一致し、配置先が存在するアイテムは同期しない。
+func (u *ModsUsecase) pendingItems(lock *domain.WorkshopLock, updated map[string]int64) ([]string, error) {
+	var pending []string
+	for _, item := range u.gameCfg.Workshop.Items {
+		locked, ok := lock.Items[item]
+		remote, known := updated[item]
+		if !ok || !known || remote == 0 || locked.TimeUpdated != remote {
+			pending = append(pending, item)
+			continue
+		}
+
+		if _, err := u.fs.Stat(u.targetPath(item)); errors.Is(err, os.ErrNotExist) {
+			pending = append(pending, item)
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("%s の確認に失敗しました: %w", u.targetPath(item), err)
+		}
+		fmt.Printf("アイテム %s は変更がないためスキップします。\n", item)
+	}
+	return pending, nil
+}
+
+// syncItems アイテムをダウンロードして mods_dir に配置し、ロックファイルに記録する
+func (u *ModsUsecase) syncItems(ctx context.Context, lock *domain.WorkshopLock, items []string, updated map[string]int64) error {
+	appID := u.gameCfg.Workshop.GetAppID(u.gameCfg.Steam)
+
+	fmt.Printf("%d 個のワークショップのアイテムをダウンロードしています...\n", len(items))
+	if err := u.fs.MkdirAll(u.downloadDir(), 0o755); err != nil {
+		return fmt.Errorf("ダウンロード先のディレクトリの作成に失敗しました: %w", err)
+	}
+	if err := u.steamCmd.DownloadWorkshopItems(ctx, u.downloadDir(), appID, items, u.gameCfg.Steam); err != nil {
+		return err
+	}
+
+	modsDir := filepath.Join(u.gameCfg.InstallDir, u.gameCfg.Workshop.ModsDir)
+	if err := u.fs.MkdirAll(modsDir, 0o755); err != nil {
+		return fmt.Errorf("mods_dir の作成に失敗しました: %w", err)
+	}
+
+	for _, item := range items {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("アイテム %s の配置前に中断しました: %w", item, err)
+		}
+		if err := u.install(ctx, appID, item); err != nil {
+			return err
+		}
+
+		// 途中で失敗しても配置済みのアイテムを再同期しないよう、アイテムごとに記録する
+		lock.Items[item] = domain.WorkshopLockItem{TimeUpdated: updated[item]}
+		if err := u.saveLock(lock); err != nil {
+			return err
+		}
+		fmt.Printf("アイテム %s を同期しました。\n", item)
+	}
+	return nil
+}
+
+// install ダウンロードしたアイテムを workshop.mode に従って mods_dir に配置する
+func (u *ModsUsecase) install(ctx context.Context, appID, item string) error {
+	src := filepath.Join(u.downloadDir(), domain.WorkshopContentPath(appID, item))
+	if _, err := u.fs.Stat(src); err != nil {
+		return fmt.Errorf("ダウンロードしたアイテム %s が見つかりません: %w", item, err)
+	}
+
+	dst := u.targetPath(item)
+	if err := u.fs.RemoveAll(dst); err != nil {
+		return fmt.Errorf("配置済みのアイテム %s の削除に失敗しました: %w", item, err)
+	}
+
+	switch u.gameCfg.Workshop.GetMode() {
+	case domain.WorkshopModeLink:
+		if err := u.fs.Symlink(src, dst); err != nil {
+			return fmt.Errorf("アイテム %s のリンクに失敗しました: %w", item, err)
+		}
+	case domain.WorkshopModeCopy:
+		if err := u.fs.CopyFileOrDir(ctx, src, dst, true); err != nil {
+			return fmt.Errorf("アイテム %s のコピーに失敗しました: %w", item, err)
+		}
+	}
+	return nil
+}
+
+// removeUnlisted workshop.items から外されたアイテムを mods_dir とダウンロード先から削除する
+func (u *ModsUsecase) removeUnlisted(lock *domain.WorkshopLock) error {
+	var removed []string
+	for item := range lock.Items {
+		if !slices.Contains(u.gameCfg.Workshop.Items, item) {
+			removed = append(removed, item)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+	sort.Strings(removed)
+
+	appID := u.gameCfg.Workshop.GetAppID(u.gameCfg.Steam)
+	for _, item := range removed {
+		if err := u.fs.RemoveAll(u.targetPath(item)); err != nil {
+			return fmt.Errorf("アイテム %s の削除に失敗しました: %w", item, err)
+		}
+		if err := u.fs.RemoveAll(filepath.Join(u.downloadDir(), domain.WorkshopContentPath(appID, item))); err != nil {
+			return fmt.Errorf("ダウンロードしたアイテム %s の削除に失敗しました: %w", item, err)
+		}
+		delete(lock.Items, item)
+		fmt.Printf("workshop.items にないアイテム %s を削除しました。\n", item)
+	}
+	return u.saveLock(lock)
+}
+
+// targetPath アイテムの配置先の絶対パス
+func (u *ModsUsecase) targetPath(item string) string {
+	return filepath.Join(u.gameCfg.InstallDir, u.gameCfg.Workshop.ModsDir, item)
+}
+
+// downloadDir steamcmd がアイテムをダウンロードするディレクトリ
+// link の場合はリンク先になるため、同期後も削除しない。
+func (u *ModsUsecase) downloadDir() string {
+	return filepath.Join(u.archonCfg.GetStateDir(), "workshop", u.gameCfg.Name)
+}
+
+// lockPath 同期済みのバージョンを記録するロックファイルのパス
+func (u *ModsUsecase) lockPath() string {
+	return filepath.Join(u.archonCfg.GetStateDir(), "workshop", u.gameCfg.Name+".lock.yaml")
+}
+
+// loadLock ロックファイルを読み込む。ファイルが存在しない場合は空の状態を返す。
+func (u *ModsUsecase) loadLock() (*domain.WorkshopLock, error) {
+	lock := &domain.WorkshopLock{Items: make(map[string]domain.WorkshopLockItem)}
+
+	data, err := u.fs.ReadFile(u.lockPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return lock, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ロックファイルの読み込みに失敗しました: %w", err)
+	}
+	if err := yaml.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("ロックファイル %s のパースに失敗しました: %w", u.lockPath(), err)
+	}
+	if lock.Items == nil {
+		lock.Items = make(map[string]domain.WorkshopLockItem)
+	}
+	return lock, nil
+}
+
+// saveLock ロックファイルを保存する
+func (u *ModsUsecase) saveLock(lock *domain.WorkshopLock) error {
+	data, err := yaml.Marshal(lock)
+	if err != nil {
+		return fmt.Errorf("ロックファイルのシリアライズに失敗しました: %w", err)
+	}
+	if err := u.fs.MkdirAll(filepath.Dir(u.lockPath()), 0o755); err != nil {
+		return fmt.Errorf("ロックファイルのディレクトリの作成に失敗しました: %w", err)
+	}
+	if err := u.fs.WriteFile(u.lockPath(), data, 0o644); err != nil {
+		return fmt.Errorf("ロックファイルの書き込みに失敗しました: %w", err)
+	}
+	return nil
+}
@@ -13,12 +13,23 @@ import (
 
 // Snapshot はスナップショット操作のインターフェース
 type Snapshot interface {
-	CopyToTmp(tmpDir string) ([]domain.FileEntry, error)
+	CopyToTmp(ctx context.Context, tmpDir string) ([]domain.FileEntry, error)
 	SaveMetaData(path string, meta *domain.Metadata) error
 	CheckAndCreateSnapshotDir() error
-	RestoreFromTmp(archiveDir string) error
+	RestoreFromTmp(ctx context.Context, archiveDir string, opts domain.RestoreOptions) error
+	LoadArchiveMetaData(zipPath string) (*domain.Metadata, error)
+	// ArchiveRoot はzipファイル内の、スナップショットのディレクトリの名前を返します。
+	ArchiveRoot(zipPath string) (string, error)
+	MigrateArchive(zipPath string) (bool, error)
+	GetWinProfile() string
+	// TargetPaths は backup_targets の全てのパスを絶対パスに解決して返します。
+	TargetPaths() ([]string, error)
 }
 
+// SnapshotFactory はゲームのコンフィグから Snapshot を生成する関数です。
+// 更新前のバックアップなど、コンフィグを変更してバックアップ・リストアを行う場合に使用します。
+type SnapshotFactory func(gameCfg *domain.GameConfig) Snapshot
+
 // --- infra ---
 
 // FileSystem はファイル操作のインターフェース
@@ -32,26 +43,115 @@ type FileSystem interface {
 	// Write
 	WriteFile(path string, data []byte, perm os.FileMode) error
 	MkdirAll(path string, perm os.FileMode) error
-	CopyFileOrDir(src, dst string, overwrite bool) error
+	CopyFileOrDir(ctx context.Context, src, dst string, overwrite bool) error
+	Symlink(target, link string) error
+	CloneDir(ctx context.Context, src, dst string, hardlink bool) error
+	Rename(src, dst string) error
+	SwapDirs(a, b string) error
 
 	// Remove
 	ClearDirectoryContents(path string) error
 	RemoveAll(path string) error
+	RemovePartialFiles(dir string) ([]string, error)
 
 	// Archive
 	IsZipFile(path string) bool
-	Zip(srcDir, destZip string) error
-	Unzip(src, dest string) error
+	Zip(ctx context.Context, srcDir, destZip string) error
+	Unzip(ctx context.Context, src, dest string, allowExternalSymlinks bool) error
+	Untar(ctx context.Context, src, dest string) error
 }
 
 // SteamCmd はsteamcmd操作のインターフェース
 type SteamCmd interface {
 	Check() error
-	Update(ctx context.Context, appID, installDir, platform string) error
+	Update(ctx context.Context, installDir string, steam *domain.SteamConfig) error
+	SelfUpdate(ctx context.Context, installDir string) error
+	LatestBuildID(ctx context.Context, steam *domain.SteamConfig) (string, error)
+	DownloadWorkshopItems(ctx context.Context, downloadDir, appID string, items []string, steam *domain.SteamConfig) error
+}
+
+// WorkshopClient はSteamワークショップのアイテム情報を取得するインターフェース
+type WorkshopClient interface {
+	TimeUpdated(ctx context.Context, items []string) (map[string]int64, error)
+}
+
+// InstallProvider はゲームのインストール元のインターフェース
+// バージョンはインストール元ごとの表現です。(steam はビルドID、minecraft-vanilla はバージョンなど)
+type InstallProvider interface {
+	Check() error
+	Install(ctx context.Context, gameCfg *domain.GameConfig) error
+	// InstalledVersion はインストール済みのバージョンを返します。未インストールの場合は空文字列を返します。
+	InstalledVersion(gameCfg *domain.GameConfig) (string, error)
+	Status(ctx context.Context, gameCfg *domain.GameConfig) (*domain.UpdateStatus, error)
+	// VersionFiles はインストール済みのバージョンを表すファイルの、install_dir からの相対パスを返します。更新前のバックアップとロールバックの対象になります。
+	VersionFiles(gameCfg *domain.GameConfig) []string
+}
+
+// WinePrefix はWine/Protonのプレフィックスの操作のインターフェース
+type WinePrefix interface {
+	Check(gameCfg *domain.GameConfig) error
+	Boot(ctx context.Context, gameCfg *domain.GameConfig, prefixDir string) error
+	Winetricks(ctx context.Context, gameCfg *domain.GameConfig, prefixDir string, verbs []string) error
+}
+
+// Downloader はファイルのダウンロードのインターフェース
+type Downloader interface {
+	Download(ctx context.Context, url, dst string) error
+}
+
+// Locker はゲームごとの操作ロックのインターフェース
+type Locker interface {
+	Lock(name, operation string) (func(), error)
+	TryLock(name, operation string) (unlock func(), ok bool, err error)
+}
+
+// ProcessFinder は実行中のゲームサーバプロセスを探すインターフェース
+type ProcessFinder interface {
+	FindRunning(gameCfg *domain.GameConfig) ([]int, error)
+	// Record は起動したサーバのPIDを記録します。FindRunning は記録したプロセスとその子孫をサーバとして扱います。
+	Record(gameCfg *domain.GameConfig, pid int) error
+}
+
+// PortChecker はホストのポートの使用状況を確認するインターフェース
+type PortChecker interface {
+	// InUse は port が他のプロセスにバインドされている場合に true を返します。判定できない場合はエラーを返します。
+	InUse(port int, protocol domain.PortProtocol) (bool, error)
+}
+
+// ResourceMonitor はサーバの cgroup のリソース制限を確認するインターフェース
+type ResourceMonitor interface {
+	// Check は resources の制限に必要な cgroup v2 のコントローラが使用できるかを確認します。
+	Check(gameCfg *domain.GameConfig) error
+	// Usage はゲームの cgroup のリソースの使用状況を返します。cgroup がない場合は nil を返します。
+	Usage(gameCfg *domain.GameConfig) (*domain.CgroupUsage, error)
+}
+
+// ServerLauncher はゲームサーバプロセスの起動・停止のインターフェース
+type ServerLauncher interface {
+	// Start はサーバを起動します。sandbox は isolation が有効な場合に、サンドボックス内で書き込みを許可するパスと隠すパスです。
+	Start(gameCfg *domain.GameConfig, logPath string, sandbox domain.SandboxPaths) (int, error)
+	// Terminate はプロセスに signal (run.stop_signal のシグナル名) を送り、終了を要求します。
+	Terminate(pid int, signal string) error
+	Kill(pid int) error
+}
+
+// CommandRunner はコマンドを実行して終了を待つインターフェース
+type CommandRunner interface {
+	Run(ctx context.Context, gameCfg *domain.GameConfig, command string) error
+	// Exec は args を run.command と同じ実行環境で実行します。
+	Exec(ctx context.Context, gameCfg *domain.GameConfig, args []string) error
+}
+
+// CredentialStore は暗号化済みの認証情報のストアのインターフェース
+type CredentialStore interface {
+	Set(name, secret, passphrase string) error
+	Delete(name string) error
+	List() ([]string, error)
 }
 
 // Cli はコマンドライン入出力のインターフェース
 type Cli interface {
 	AskYesNo(r io.Reader, question string, defaultYes bool) (bool, error)
+	AskSecret(prompt string) (string, error)
 	Writeln(builder *strings.Builder, strs ...string)
 }
@@ -0,0 +1,190 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+// PrefixUsecase ゲーム専用のWine/Protonのプレフィックスの管理のユースケース
+type PrefixUsecase struct {
+	archonCfg *domain.ArchonConfig
+	gameCfg   *domain.GameConfig
+	prefix    WinePrefix
+	fs        FileSystem
+	cli       Cli
+	locker    Locker
+	process   ProcessFinder
+}
+
+// NewPrefixUsecase PrefixUsecaseのインスタンスを生成する
+// nolint:lll // 初期化なので
+func NewPrefixUsecase(archonCfg *domain.ArchonConfig, gameCfg *domain.GameConfig, prefix WinePrefix, fs FileSystem, cli Cli, locker Locker, process ProcessFinder) *PrefixUsecase {
+	return &PrefixUsecase{
+		archonCfg: archonCfg,
+		gameCfg:   gameCfg,
+		prefix:    prefix,
+		fs:        fs,
+		cli:       cli,
+		locker:    locker,
+		process:   process,
+	}
+}
+
+// Init はプレフィックスを作成し、prefix.winetricks のverbをインストールします。
+// 作成済みの場合は作成をスキップし、winetricks のみ実行します。(インストール済みのverbはwinetricksがスキップします)
+func (u *PrefixUsecase) Init(ctx context.Context) error {
+	if err := u.checkPrefix(); err != nil {
+		return err
+	}
+
+	// 同じゲームへの操作が同時に実行されないようにロックする
+	unlock, err := u.locker.Lock(u.gameCfg.Name, "prefix init")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return u.init(ctx)
+}
+
+// Info はプレフィックスの状態を返します。
+func (u *PrefixUsecase) Info() (*domain.PrefixStatus, error) {
+	if err := u.checkConfig(); err != nil {
+		return nil, err
+	}
+
+	dir := u.gameCfg.GetPrefixDir(u.archonCfg)
+	status := &domain.PrefixStatus{
+		Dir:        dir,
+		WinePrefix: domain.WinePrefixDir(u.gameCfg.RuntimeEnv, dir),
+	}
+
+	initialized, err := u.initialized()
+	if err != nil {
+		return nil, err
+	}
+	status.Initialized = initialized
+
+	data, err := u.fs.ReadFile(filepath.Join(status.WinePrefix, domain.WinetricksLog))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("winetricksのログの読み込みに失敗しました: %w", err)
+	}
+	status.Verbs = domain.ParseWinetricksLog(data)
+
+	return status, nil
+}
+
+// Reset はプレフィックスを削除して作り直します。
+// プレフィックス内のセーブデータや設定も削除されるため、実行前に確認します。
+func (u *PrefixUsecase) Reset(ctx context.Context) error {
+	if err := u.checkPrefix(); err != nil {
+		return err
+	}
+
+	unlock, err := u.locker.Lock(u.gameCfg.Name, "prefix reset")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// サーバが使用中のプレフィックスは削除しない
+	if err := checkNotRunning(u.process, u.gameCfg); err != nil {
+		return err
+	}
+
+	dir := u.gameCfg.GetPrefixDir(u.archonCfg)
+	if _, err := u.fs.Stat(dir); err == nil {
+		question := fmt.Sprintf("プレフィックス %s を削除して作り直します。プレフィックス内のセーブデータも削除されますが、よろしいですか？", dir)
+		ok, err := u.cli.AskYesNo(os.Stdin, question, false)
+		if err != nil {
+			return fmt.Errorf("確認に失敗しました: %w", err)
+		}
+		if !ok {
+			return fmt.Errorf("%s のプレフィックスのリセットをキャンセルしました。", u.gameCfg.Name)
+		}
+
+		fmt.Printf("プレフィックス %s を削除しています...\n", dir)
+		if err := u.fs.RemoveAll(dir); err != nil {
+			return fmt.Errorf("プレフィックスの削除に失敗しました: %w", err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("プレフィックスの確認に失敗しました: %w", err)
+	}
+
+	return u.init(ctx)
+}
+
+// init プレフィックスを作成し、winetricks を実行する
+func (u *PrefixUsecase) init(ctx context.Context) error {
+	// プレフィックスの作成中にサーバが起動されないよう、ロックの取得後に確認する
+	if err := checkNotRunning(u.process, u.gameCfg); err != nil {
+		return err
+	}
+
+	dir := u.gameCfg.GetPrefixDir(u.archonCfg)
+	initialized, err := u.initialized()
+	if err != nil {
+		return err
+	}
+
+	if initialized {
+		fmt.Printf("プレフィックス %s は作成済みです。\n", dir)
+	} else {
+		if err := u.fs.MkdirAll(dir, 0o750); err != nil {
+			return fmt.Errorf("プレフィックスのディレクトリの作成に失敗しました: %w", err)
+		}
+		fmt.Printf("プレフィックス %s を作成しています...\n", dir)
+		if err := u.prefix.Boot(ctx, u.gameCfg, dir); err != nil {
+			return fmt.Errorf("プレフィックスの作成に失敗しました: %w", err)
+		}
+	}
+
+	if verbs := u.gameCfg.Prefix.Winetricks; len(verbs) > 0 {
+		fmt.Printf("winetricks で %v をインストールしています...\n", verbs)
+		if err := u.prefix.Winetricks(ctx, u.gameCfg, dir, verbs); err != nil {
+			return fmt.Errorf("winetricks の実行に失敗しました: %w", err)
+		}
+	}
+
+	fmt.Printf("%s のプレフィックスの準備が完了しました: %s\n", u.gameCfg.Name, dir)
+	return nil
+}
+
+// initialized プレフィックスが作成済みかどうか
+// wineboot はレジストリ (system.reg) を最後に作成するため、その有無で判定する
+func (u *PrefixUsecase) initialized() (bool, error) {
+	dir := domain.WinePrefixDir(u.gameCfg.RuntimeEnv, u.gameCfg.GetPrefixDir(u.archonCfg))
+	if _, err := u.fs.Stat(filepath.Join(dir, "system.reg")); errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("プレフィックスの確認に失敗しました: %w", err)
+	}
+	return true, nil
+}
+
+// checkConfig プレフィックスのコンフィグのチェック
+func (u *PrefixUsecase) checkConfig() error {
+	if u.gameCfg.Prefix == nil {
+		return fmt.Errorf("prefix が設定されていません")
+	}
+	if err := u.gameCfg.Prefix.Validate(u.gameCfg.RuntimeEnv); err != nil {
+		return err
+	}
+	if u.gameCfg.GetPrefixDir(u.archonCfg) == "" {
+		return fmt.Errorf("prefix.path を設定するか、archon の state_dir を設定してください")
+	}
+	return nil
+}
+
+// checkPrefix プレフィックスの作成前のチェック
+func (u *PrefixUsecase) checkPrefix() error {
+	if err := u.checkConfig(); err != nil {
+		return err
+	}
+	return u.prefix.Check(u.gameCfg)
+}
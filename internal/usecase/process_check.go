@@ -0,0 +1,19 @@
+package usecase
+
+import (
+	"fmt"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+// checkNotRunning ゲームサーバが実行中でないことを確認する
+func checkNotRunning(process ProcessFinder, gameCfg *domain.GameConfig) error {
+	pids, err := process.FindRunning(gameCfg)
+	if err != nil {
+		return fmt.Errorf("サーバの実行状態の確認に失敗しました: %w", err)
+	}
+	if len(pids) > 0 {
+		return fmt.Errorf("%s のサーバが実行中です (pid: %v)。サーバを停止してから実行してください", gameCfg.Name, pids)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+// RecoverUsecase 異常終了した操作の後始末を行うユースケース
+// 中断されたバックアップ/リストアが残した一時ディレクトリや、書き込み途中のzipファイルを削除します。
+type RecoverUsecase struct {
+	cfg    *domain.Config
+	fs     FileSystem
+	locker Locker
+}
+
+// NewRecoverUsecase RecoverUsecaseのインスタンスを生成する
+func NewRecoverUsecase(cfg *domain.Config, fs FileSystem, locker Locker) *RecoverUsecase {
+	return &RecoverUsecase{
+		cfg:    cfg,
+		fs:     fs,
+		locker: locker,
+	}
+}
+
+// Execute 全てのゲームについて後始末を実行する
+// 他のプロセスが操作中のゲームは、一時ファイルを使用中の可能性があるためスキップします。
+func (u *RecoverUsecase) Execute(ctx context.Context) error {
+	if u.cfg.Archon == nil || u.cfg.Archon.BackupDir == "" {
+		return nil
+	}
+
+	names := make([]string, 0, len(u.cfg.Games))
+	for name := range u.cfg.Games {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("後始末を中断しました: %w", err)
+		}
+		if err := u.recoverGame(u.cfg.Games[name]); err != nil {
+			fmt.Fprintf(os.Stderr, "%s の後始末に失敗しました: %v\n", name, err)
+		}
+	}
+	return nil
+}
+
+// recoverGame ゲーム単体の後始末
+func (u *RecoverUsecase) recoverGame(gameCfg *domain.GameConfig) error {
+	snapshotPath := filepath.Join(u.cfg.Archon.BackupDir, gameCfg.Name)
+	if _, err := u.fs.Stat(snapshotPath); err != nil {
+		return nil //nolint:nilerr // バックアップディレクトリがなければ後始末は不要
+	}
+
+	unlock, ok, err := u.locker.TryLock(gameCfg.Name, "recover")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+	defer unlock()
+
+	// 中断された操作の一時ディレクトリ
+	tmpDir := filepath.Join(snapshotPath, "tmp")
+	if _, err := u.fs.Stat(tmpDir); err == nil {
+		fmt.Printf("中断された操作の一時ディレクトリ %s を削除します。\n", tmpDir)
+		if err := u.fs.RemoveAll(tmpDir); err != nil {
+			return fmt.Errorf("一時ディレクトリの削除に失敗しました: %w", err)
+		}
+	}
+
+	// 書き込み途中のzipファイル
+	removed, err := u.fs.RemovePartialFiles(snapshotPath)
+	for _, path := range removed {
+		fmt.Printf("書き込み途中のバックアップ %s を削除しました。\n", path)
+	}
+	if err != nil {
+		return fmt.Errorf("書き込み途中のバックアップの削除に失敗しました: %w", err)
+	}
+
+	return nil
+}
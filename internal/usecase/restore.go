@@ -1,10 +1,10 @@
 package usecase
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/nonuplet/grimoire-archon/internal/domain"
 )
@@ -15,20 +15,23 @@ type RestoreUsecase struct {
 	gameCfg   *domain.GameConfig
 	snapshot  Snapshot
 	fs        FileSystem
+	locker    Locker
 }
 
 // NewRestoreUsecase restoreのユースケースを作成
-func NewRestoreUsecase(archonCfg *domain.ArchonConfig, gameCfg *domain.GameConfig, snapshot Snapshot, fs FileSystem) *RestoreUsecase {
+// nolint:lll // 初期化なので
+func NewRestoreUsecase(archonCfg *domain.ArchonConfig, gameCfg *domain.GameConfig, snapshot Snapshot, fs FileSystem, locker Locker) *RestoreUsecase {
 	return &RestoreUsecase{
 		archonCfg: archonCfg,
 		gameCfg:   gameCfg,
 		snapshot:  snapshot,
 		fs:        fs,
+		locker:    locker,
 	}
 }
 
 // Execute restoreの実行
-func (u *RestoreUsecase) Execute(zipPath string) error {
+func (u *RestoreUsecase) Execute(ctx context.Context, zipPath string, opts domain.RestoreOptions) error {
 	if _, err := u.fs.Stat(zipPath); err != nil {
 		return fmt.Errorf("アーカイブファイル %s が見つかりません。", zipPath)
 	}
@@ -37,11 +40,18 @@ func (u *RestoreUsecase) Execute(zipPath string) error {
 		return err
 	}
 
+	// 同じゲームへの操作が同時に実行されないようにロックする
+	unlock, err := u.locker.Lock(u.gameCfg.Name, "restore")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	if err := u.snapshot.CheckAndCreateSnapshotDir(); err != nil {
 		return fmt.Errorf("展開用ディレクトリの作成に失敗しました: %w", err)
 	}
 
-	if err := u.restoreSnapshot(zipPath); err != nil {
+	if err := u.restoreSnapshot(ctx, zipPath, opts); err != nil {
 		return err
 	}
 
@@ -72,7 +82,7 @@ func (u *RestoreUsecase) checkPreRestore(zipPath string) error {
 	return nil
 }
 
-func (u *RestoreUsecase) restoreSnapshot(zipPath string) error {
+func (u *RestoreUsecase) restoreSnapshot(ctx context.Context, zipPath string, opts domain.RestoreOptions) error {
 	// バックアップ先パスの設定
 	snapshotPath := filepath.Join(u.archonCfg.BackupDir, u.gameCfg.Name)
 
@@ -89,17 +99,21 @@ func (u *RestoreUsecase) restoreSnapshot(zipPath string) error {
 	}(tmpDir)
 
 	// unzipしたあとのディレクトリ指定に使う
-	archiveName := strings.TrimSuffix(filepath.Base(zipPath), filepath.Ext(zipPath))
+	// zipファイルの名前は変更されている場合があるため、zipファイル内のディレクトリ名を使う
+	archiveName, err := u.snapshot.ArchiveRoot(zipPath)
+	if err != nil {
+		return err
+	}
 	archiveDir := filepath.Join(tmpDir, archiveName)
 
 	// <backup_dir>/<game_name>/tmp/ に展開
 	fmt.Printf("zipファイル '%s' を展開しています... \n", filepath.Join(u.archonCfg.BackupDir, u.gameCfg.Name))
-	if err := u.fs.Unzip(zipPath, tmpDir); err != nil {
+	if err := u.fs.Unzip(ctx, zipPath, tmpDir, opts.AllowExternalSymlinks); err != nil {
 		return fmt.Errorf("zipファイルの展開に失敗しました: %w", err)
 	}
 
 	// リストア
-	if err := u.snapshot.RestoreFromTmp(archiveDir); err != nil {
+	if err := u.snapshot.RestoreFromTmp(ctx, archiveDir, opts); err != nil {
 		return fmt.Errorf("リストアに失敗しました: %w", err)
 	}
 
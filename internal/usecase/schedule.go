@@ -0,0 +1,248 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-yaml"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+const (
+	// scheduleLockName はスケジューラの多重起動を防ぐためのロック名です。
+	scheduleLockName = ".schedule"
+	// scheduleStateFile はスケジューラの実行状態を保存するファイル名です。
+	scheduleStateFile = "schedule.yaml"
+	// maxScheduleSleep は次のジョブまでの待機時間の上限です。時刻の変更やスリープ復帰に追従するため、定期的に時刻を確認します。
+	maxScheduleSleep = time.Minute
+)
+
+// JobRunner はジョブを実行する関数です。
+type JobRunner func(ctx context.Context, job domain.ScheduledJob) error
+
+// ScheduleUsecase 定期実行ジョブを実行し続けるスケジューラのユースケース
+// 同じゲームのジョブは同時に実行せず、前回のジョブが実行中の場合はスキップします。
+// 停止中に実行されなかったジョブは、起動時に1回だけ実行します。
+type ScheduleUsecase struct {
+	archonCfg *domain.ArchonConfig
+	runner    JobRunner
+	fs        FileSystem
+	locker    Locker
+	logger    *slog.Logger
+	running   map[string]bool
+	state     domain.ScheduleState
+	jobs      []domain.ScheduledJob
+	mu        sync.Mutex
+}
+
+// NewScheduleUsecase ScheduleUsecaseのインスタンスを生成する
+// nolint:lll // 初期化なので
+func NewScheduleUsecase(archonCfg *domain.ArchonConfig, jobs []domain.ScheduledJob, runner JobRunner, fs FileSystem, locker Locker, logger *slog.Logger) *ScheduleUsecase {
+	return &ScheduleUsecase{
+		archonCfg: archonCfg,
+		jobs:      jobs,
+		runner:    runner,
+		fs:        fs,
+		locker:    locker,
+		logger:    logger,
+		running:   make(map[string]bool),
+	}
+}
+
+// Run はコンテキストがキャンセルされるまでジョブを実行し続けます。
+// キャンセルされた場合は、実行中のジョブの終了を待ってから戻ります。
+func (u *ScheduleUsecase) Run(ctx context.Context) error {
+	if u.archonCfg == nil {
+		return fmt.Errorf("archonのコンフィグが定義されていません。")
+	}
+	if len(u.jobs) == 0 {
+		return fmt.Errorf("定期実行するジョブがありません。コンフィグの schedule を確認してください")
+	}
+
+	unlock, ok, err := u.locker.TryLock(scheduleLockName, "schedule run")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("スケジューラは既に実行中です")
+	}
+	defer unlock()
+
+	if err := u.loadState(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	next := make(map[string]time.Time, len(u.jobs))
+	for _, job := range u.jobs {
+		last := u.state.Jobs[job.Key()].LastRun
+		if last.IsZero() {
+			last = now
+		}
+		n := job.Schedule.Next(last)
+		next[job.Key()] = n
+
+		switch {
+		case n.IsZero():
+			u.logger.Warn("実行タイミングが存在しないため、このジョブは実行されません", "game", job.Game, "job", job.Kind, "spec", job.Spec)
+		case n.Before(now):
+			u.logger.Info("停止中に実行されなかったジョブを実行します", "game", job.Game, "job", job.Kind, "scheduled", n)
+		default:
+			u.logger.Info("ジョブを登録しました", "game", job.Game, "job", job.Kind, "spec", job.Spec, "next", n)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for {
+		timer := time.NewTimer(u.sleepDuration(next, time.Now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			u.logger.Info("スケジューラを停止しています。実行中のジョブの終了を待機します")
+			wg.Wait()
+			u.logger.Info("スケジューラを停止しました")
+			return nil
+		case <-timer.C:
+		}
+
+		// 同じゲームで同時に実行予定のジョブは、定義順にまとめて実行する
+		now = time.Now()
+		var games []string
+		due := make(map[string][]domain.ScheduledJob)
+		for _, job := range u.jobs {
+			n := next[job.Key()]
+			if n.IsZero() || n.After(now) {
+				continue
+			}
+			next[job.Key()] = job.Schedule.Next(now)
+			if _, ok := due[job.Game]; !ok {
+				games = append(games, job.Game)
+			}
+			due[job.Game] = append(due[job.Game], job)
+		}
+
+		for _, game := range games {
+			if !u.markRunning(game) {
+				for _, job := range due[game] {
+					u.logger.Warn("前回のジョブが実行中のためスキップします", "game", game, "job", job.Kind)
+				}
+				continue
+			}
+			wg.Add(1)
+			go func(game string, jobs []domain.ScheduledJob) {
+				defer wg.Done()
+				defer u.unmarkRunning(game)
+				u.runJobs(ctx, jobs)
+			}(game, due[game])
+		}
+	}
+}
+
+// sleepDuration は次のジョブまでの待機時間を返します。
+func (u *ScheduleUsecase) sleepDuration(next map[string]time.Time, now time.Time) time.Duration {
+	d := maxScheduleSleep
+	for _, n := range next {
+		if n.IsZero() {
+			continue
+		}
+		if until := n.Sub(now); until < d {
+			d = until
+		}
+	}
+	return max(d, 0)
+}
+
+// runJobs はジョブを順番に実行し、結果を記録します。
+func (u *ScheduleUsecase) runJobs(ctx context.Context, jobs []domain.ScheduledJob) {
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			return
+		}
+
+		started := time.Now()
+		u.logger.Info("ジョブを開始します", "game", job.Game, "job", job.Kind)
+		err := u.runner(ctx, job)
+
+		result := "ok"
+		if err != nil {
+			result = err.Error()
+			u.logger.Error("ジョブが失敗しました", "game", job.Game, "job", job.Kind, "elapsed", time.Since(started).Round(time.Second), "error", err)
+		} else {
+			u.logger.Info("ジョブが完了しました", "game", job.Game, "job", job.Kind, "elapsed", time.Since(started).Round(time.Second))
+		}
+
+		if err := u.recordRun(job, started, result); err != nil {
+			u.logger.Error("実行状態の保存に失敗しました", "error", err)
+		}
+	}
+}
+
+// markRunning はゲームを実行中にします。既に実行中の場合は false を返します。
+func (u *ScheduleUsecase) markRunning(game string) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.running[game] {
+		return false
+	}
+	u.running[game] = true
+	return true
+}
+
+// unmarkRunning はゲームの実行中を解除します。
+func (u *ScheduleUsecase) unmarkRunning(game string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	delete(u.running, game)
+}
+
+// statePath は実行状態を保存するファイルのパスを返します。
+func (u *ScheduleUsecase) statePath() string {
+	return filepath.Join(u.archonCfg.GetStateDir(), scheduleStateFile)
+}
+
+// loadState は前回までの実行状態を読み込みます。ファイルが存在しない場合は空の状態から開始します。
+func (u *ScheduleUsecase) loadState() error {
+	u.state = domain.ScheduleState{Jobs: make(map[string]domain.JobState)}
+
+	data, err := u.fs.ReadFile(u.statePath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("実行状態の読み込みに失敗しました: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &u.state); err != nil {
+		return fmt.Errorf("実行状態 %s のパースに失敗しました: %w", u.statePath(), err)
+	}
+	if u.state.Jobs == nil {
+		u.state.Jobs = make(map[string]domain.JobState)
+	}
+	return nil
+}
+
+// recordRun はジョブの実行結果を記録し、ファイルに保存します。
+func (u *ScheduleUsecase) recordRun(job domain.ScheduledJob, started time.Time, result string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.state.Jobs[job.Key()] = domain.JobState{LastRun: started, Result: result}
+
+	data, err := yaml.Marshal(u.state)
+	if err != nil {
+		return fmt.Errorf("実行状態のシリアライズに失敗しました: %w", err)
+	}
+	if err := u.fs.MkdirAll(u.archonCfg.GetStateDir(), 0o755); err != nil {
+		return fmt.Errorf("状態ディレクトリの作成に失敗しました: %w", err)
+	}
+	if err := u.fs.WriteFile(u.statePath(), data, 0o644); err != nil {
+		return fmt.Errorf("実行状態の書き込みに失敗しました: %w", err)
+	}
+	return nil
+}
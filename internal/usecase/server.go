@@ -0,0 +1,268 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+// stopPollInterval はサーバの終了を確認する間隔です。
+const stopPollInterval = time.Second
+
+// ServerUsecase ゲームサーバの起動・停止のユースケース
+type ServerUsecase struct {
+	archonCfg   *domain.ArchonConfig
+	gameCfg     *domain.GameConfig
+	games       map[string]*domain.GameConfig
+	launcher    ServerLauncher
+	runner      CommandRunner
+	ports       PortChecker
+	newSnapshot SnapshotFactory
+	locker      Locker
+	process     ProcessFinder
+}
+
+// NewServerUsecase ServerUsecaseのインスタンスを生成する
+// runner は run.stop_command の実行に、ports は起動前の ports の確認に使用します。
+// games, newSnapshot は isolation が有効な場合に、隠す他のゲームのパスと、書き込みを許可する backup_targets のパスの解決に使用します。
+// nolint:lll // 初期化なので
+func NewServerUsecase(archonCfg *domain.ArchonConfig, gameCfg *domain.GameConfig, games map[string]*domain.GameConfig, launcher ServerLauncher, runner CommandRunner, ports PortChecker, newSnapshot SnapshotFactory, locker Locker, process ProcessFinder) *ServerUsecase {
+	return &ServerUsecase{
+		archonCfg:   archonCfg,
+		gameCfg:     gameCfg,
+		games:       games,
+		launcher:    launcher,
+		runner:      runner,
+		ports:       ports,
+		newSnapshot: newSnapshot,
+		locker:      locker,
+		process:     process,
+	}
+}
+
+// Start はゲームサーバを起動します。既に実行中の場合や、ports が他のプロセスに使用されている場合はエラーを返します。
+// isolation が有効な場合は、install_dir, prefix, backup_targets, isolation.writable のみ書き込みを許可したサンドボックスで起動します。
+func (u *ServerUsecase) Start(ctx context.Context) error {
+	if err := u.checkPreServer(); err != nil {
+		return err
+	}
+
+	unlock, err := u.locker.Lock(u.gameCfg.Name, "start")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("起動を中断しました: %w", err)
+	}
+	if err := checkNotRunning(u.process, u.gameCfg); err != nil {
+		return err
+	}
+	if err := u.checkPortsAvailable(); err != nil {
+		return err
+	}
+
+	sandbox, err := u.sandboxPaths()
+	if err != nil {
+		return err
+	}
+
+	logPath := u.LogPath()
+	pid, err := u.launcher.Start(u.gameCfg, logPath, sandbox)
+	if err != nil {
+		return err
+	}
+	if err := u.process.Record(u.gameCfg, pid); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\nstop, status ではプロセス一覧から探します。\n", err)
+	}
+	fmt.Printf("%s を起動しました (pid: %d, ログ: %s)\n", u.gameCfg.Name, pid, logPath)
+	return nil
+}
+
+// Stop はゲームサーバに終了を要求し、終了するまで待機します。
+// run.stop_command が設定されている場合はそれを実行し、そうでなければ run.stop_signal (デフォルトで SIGTERM) を送ります。
+// run.stop_timeout (デフォルトで30秒) 以内に終了しない場合は強制終了します。実行中でない場合は何もしません。
+func (u *ServerUsecase) Stop(ctx context.Context) error {
+	unlock, err := u.locker.Lock(u.gameCfg.Name, "stop")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	pids, err := u.process.FindRunning(u.gameCfg)
+	if err != nil {
+		return fmt.Errorf("サーバの実行状態の確認に失敗しました: %w", err)
+	}
+	if len(pids) == 0 {
+		fmt.Printf("%s のサーバは実行されていません。\n", u.gameCfg.Name)
+		return nil
+	}
+
+	fmt.Printf("%s のサーバを停止しています (pid: %v)...\n", u.gameCfg.Name, pids)
+	u.requestStop(ctx, pids)
+
+	stopTimeout := u.gameCfg.Run.GetStopTimeout()
+	deadline := time.Now().Add(stopTimeout)
+	for {
+		pids, err = u.process.FindRunning(u.gameCfg)
+		if err != nil {
+			return fmt.Errorf("サーバの実行状態の確認に失敗しました: %w", err)
+		}
+		if len(pids) == 0 {
+			fmt.Printf("%s のサーバを停止しました。\n", u.gameCfg.Name)
+			return nil
+		}
+		if time.Now().After(deadline) {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("停止の待機を中断しました: %w", ctx.Err())
+		case <-time.After(stopPollInterval):
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "%s のサーバが %s 以内に終了しなかったため、強制終了します (pid: %v)\n", u.gameCfg.Name, stopTimeout, pids)
+	for _, pid := range pids {
+		if err := u.launcher.Kill(pid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// requestStop サーバに終了を要求する
+// run.stop_command が失敗した場合は、シグナルで終了を要求する
+func (u *ServerUsecase) requestStop(ctx context.Context, pids []int) {
+	if run := u.gameCfg.Run; run != nil && run.StopCommand != "" {
+		fmt.Printf("停止コマンドを実行しています: %s\n", run.StopCommand)
+		err := u.runner.Run(ctx, u.gameCfg, run.StopCommand)
+		if err == nil {
+			return
+		}
+		fmt.Fprintf(os.Stderr, "%v\n停止コマンドに失敗したため、%s で終了を要求します。\n", err, run.GetStopSignal())
+	}
+
+	for _, pid := range pids {
+		if err := u.launcher.Terminate(pid, u.gameCfg.Run.GetStopSignal()); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+		}
+	}
+}
+
+// Restart はゲームサーバを再起動します。実行中でない場合はそのまま起動します。
+func (u *ServerUsecase) Restart(ctx context.Context) error {
+	if err := u.checkPreServer(); err != nil {
+		return err
+	}
+
+	// 停止から起動までの間に他の操作が割り込まないようにロックする
+	unlock, err := u.locker.Lock(u.gameCfg.Name, "restart")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := u.Stop(ctx); err != nil {
+		return err
+	}
+	return u.Start(ctx)
+}
+
+// WhileStopped はサーバが実行中であれば停止してから fn を実行し、終了後にサーバを再び起動します。
+// fn が失敗した場合もサーバの起動は行います。
+func (u *ServerUsecase) WhileStopped(ctx context.Context, operation string, fn func() error) error {
+	unlock, err := u.locker.Lock(u.gameCfg.Name, operation)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	pids, err := u.process.FindRunning(u.gameCfg)
+	if err != nil {
+		return fmt.Errorf("サーバの実行状態の確認に失敗しました: %w", err)
+	}
+	if len(pids) == 0 {
+		return fn()
+	}
+
+	if err := u.Stop(ctx); err != nil {
+		return err
+	}
+	fnErr := fn()
+
+	// 中断された場合もサーバは元の状態に戻す
+	if err := u.Start(context.WithoutCancel(ctx)); err != nil {
+		if fnErr != nil {
+			return fmt.Errorf("%w (サーバの再起動にも失敗しました: %w)", fnErr, err)
+		}
+		return err
+	}
+	return fnErr
+}
+
+// checkPortsAvailable ports が他のプロセスに使用されていないことを確認する
+// 権限がないなどで確認できない場合は警告のみ表示する
+func (u *ServerUsecase) checkPortsAvailable() error {
+	var busy []string
+	for _, port := range u.gameCfg.Ports {
+		inUse, err := u.ports.InUse(port.Port, port.Protocol)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			continue
+		}
+		if inUse {
+			busy = append(busy, port.String())
+		}
+	}
+	if len(busy) > 0 {
+		return fmt.Errorf("ポート %s は他のプロセスが使用中です。ports の設定を確認してください", strings.Join(busy, ", "))
+	}
+	return nil
+}
+
+// sandboxPaths isolation が有効な場合に、サンドボックス内で書き込みを許可するパスと隠すパスを返す
+func (u *ServerUsecase) sandboxPaths() (domain.SandboxPaths, error) {
+	if !u.gameCfg.Isolation.IsEnabled() {
+		return domain.SandboxPaths{}, nil
+	}
+	if err := u.gameCfg.Isolation.Validate(u.gameCfg.RuntimeEnv, u.gameCfg.Prefix); err != nil {
+		return domain.SandboxPaths{}, err
+	}
+
+	savePaths, err := u.newSnapshot(u.gameCfg).TargetPaths()
+	if err != nil {
+		return domain.SandboxPaths{}, fmt.Errorf("セーブデータのパスの解決に失敗しました: %w", err)
+	}
+
+	return domain.SandboxPaths{
+		Writable: u.gameCfg.SandboxWritablePaths(u.archonCfg, savePaths),
+		Hidden:   domain.SandboxHiddenPaths(u.archonCfg, u.games, u.gameCfg.Name),
+	}, nil
+}
+
+// LogPath はサーバの標準出力を保存するログファイルのパスを返します。
+func (u *ServerUsecase) LogPath() string {
+	return filepath.Join(u.archonCfg.GetStateDir(), "logs", u.gameCfg.Name+".log")
+}
+
+// checkPreServer サーバ操作の処理前チェック
+func (u *ServerUsecase) checkPreServer() error {
+	if u.archonCfg == nil {
+		return fmt.Errorf("archonのコンフィグが定義されていません。")
+	}
+	if u.gameCfg.Run == nil || u.gameCfg.Run.Command == "" {
+		return fmt.Errorf("run.command が設定されていません")
+	}
+	if u.gameCfg.InstallDir == "" {
+		return fmt.Errorf("インストールディレクトリが設定されていません")
+	}
+	return nil
+}
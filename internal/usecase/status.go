@@ -0,0 +1,122 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+// StatusUsecase サーバの状態の表示のユースケース
+type StatusUsecase struct {
+	gameCfg   *domain.GameConfig
+	fs        FileSystem
+	process   ProcessFinder
+	resources ResourceMonitor
+}
+
+// NewStatusUsecase StatusUsecaseのインスタンスを生成する
+func NewStatusUsecase(gameCfg *domain.GameConfig, fs FileSystem, process ProcessFinder, resources ResourceMonitor) *StatusUsecase {
+	return &StatusUsecase{
+		gameCfg:   gameCfg,
+		fs:        fs,
+		process:   process,
+		resources: resources,
+	}
+}
+
+// Execute はサーバの実行状態を表示します。
+// steam が設定されている場合は、steam.branch とインストール済みのブランチも表示します。
+// resources が設定されている場合は、ゲームの cgroup からリソースの使用状況も表示します。
+func (u *StatusUsecase) Execute() error {
+	pids, err := u.process.FindRunning(u.gameCfg)
+	if err != nil {
+		return fmt.Errorf("サーバの実行状態の確認に失敗しました: %w", err)
+	}
+	if len(pids) == 0 {
+		fmt.Printf("%s: 停止中\n", u.gameCfg.Name)
+	} else {
+		fmt.Printf("%s: 実行中 (pid: %v)\n", u.gameCfg.Name, pids)
+	}
+	if u.gameCfg.Steam != nil {
+		u.printBranch()
+	}
+
+	if u.gameCfg.Resources == nil {
+		return nil
+	}
+	usage, err := u.resources.Usage(u.gameCfg)
+	if err != nil {
+		return fmt.Errorf("リソースの使用状況の取得に失敗しました: %w", err)
+	}
+	if usage == nil {
+		fmt.Println("  cgroup がありません。resources を設定してから起動すると表示されます。")
+		return nil
+	}
+	printUsage(usage)
+	return nil
+}
+
+// printBranch steam.branch とインストール済みのブランチを表示する
+func (u *StatusUsecase) printBranch() {
+	branch := u.gameCfg.Steam.GetBranch()
+	path := filepath.Join(u.gameCfg.InstallDir, domain.SteamManifestPath(u.gameCfg.Steam.AppID))
+	data, err := u.fs.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("  ブランチ: %s (未インストール)\n", branch)
+		return
+	}
+	if err != nil {
+		fmt.Printf("  ブランチ: %s (インストール済みのブランチを取得できませんでした: %v)\n", branch, err)
+		return
+	}
+	manifest, err := domain.ParseAppManifest(data)
+	if err != nil {
+		fmt.Printf("  ブランチ: %s (インストール済みのブランチを取得できませんでした: %s のパースに失敗しました: %v)\n", branch, path, err)
+		return
+	}
+
+	if manifest.Branch == branch {
+		fmt.Printf("  ブランチ: %s (ビルドID: %s)\n", branch, manifest.BuildID)
+		return
+	}
+	fmt.Printf("  ブランチ: %s (インストール済み: %s, ビルドID: %s。archon update で切り替わります)\n", branch, manifest.Branch, manifest.BuildID)
+}
+
+// printUsage リソースの使用状況を表示する
+func printUsage(usage *domain.CgroupUsage) {
+	memory := domain.FormatByteSize(usage.MemoryCurrent) + " / " + limitLabel(usage.MemoryMax, true)
+	if usage.MemoryPeak > 0 {
+		memory += " (最大: " + domain.FormatByteSize(usage.MemoryPeak) + ")"
+	}
+	fmt.Printf("  メモリ: %s\n", memory)
+	if usage.OOMKills > 0 {
+		fmt.Printf("  メモリの上限により強制終了されたプロセス: %d\n", usage.OOMKills)
+	}
+
+	cpu := "  CPU時間: " + time.Duration(usage.CPUUsageUsec*int64(time.Microsecond)).Round(time.Millisecond).String()
+	if cores := usage.CPUCores(); cores > 0 {
+		cpu += fmt.Sprintf(" (上限: %s コア, 制限された時間: %s)",
+			strconv.FormatFloat(cores, 'f', -1, 64), time.Duration(usage.CPUThrottledUsec*int64(time.Microsecond)).Round(time.Millisecond))
+	}
+	fmt.Println(cpu)
+
+	fmt.Printf("  プロセス数: %d / %s\n", usage.PidsCurrent, limitLabel(usage.PidsMax, false))
+	fmt.Printf("  I/O: 読み込み %s, 書き込み %s\n", domain.FormatByteSize(usage.IOReadBytes), domain.FormatByteSize(usage.IOWriteBytes))
+}
+
+// limitLabel cgroup の上限の表示。上限なしの場合は "上限なし" を返す
+func limitLabel(value string, isBytes bool) string {
+	if value == "" {
+		return "上限なし"
+	}
+	if n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64); err == nil && isBytes {
+		return domain.FormatByteSize(n)
+	}
+	return value
+}
@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+// steamCmdLockName はsteamcmdのインストールを多重実行しないためのロック名です。
+const steamCmdLockName = ".steamcmd"
+
+// SteamCmdUsecase steamcmd自体の管理のユースケース
+type SteamCmdUsecase struct {
+	archonCfg  *domain.ArchonConfig
+	steamCmd   SteamCmd
+	downloader Downloader
+	fs         FileSystem
+	locker     Locker
+}
+
+// NewSteamCmdUsecase SteamCmdUsecaseのインスタンスを生成する
+// nolint:lll // 初期化なので
+func NewSteamCmdUsecase(archonCfg *domain.ArchonConfig, steamCmd SteamCmd, downloader Downloader, fs FileSystem, locker Locker) *SteamCmdUsecase {
+	return &SteamCmdUsecase{
+		archonCfg:  archonCfg,
+		steamCmd:   steamCmd,
+		downloader: downloader,
+		fs:         fs,
+		locker:     locker,
+	}
+}
+
+// Install はsteamcmdのアーカイブをダウンロードして archon が管理するディレクトリに展開し、steamcmd自体を一度更新します。
+func (u *SteamCmdUsecase) Install(ctx context.Context) error {
+	if u.archonCfg == nil {
+		return fmt.Errorf("archonのコンフィグが定義されていません。")
+	}
+
+	unlock, err := u.locker.Lock(steamCmdLockName, "steamcmd install")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	installDir := u.archonCfg.GetSteamCmdInstallDir()
+	downloadURL := u.archonCfg.GetSteamCmdDownloadURL()
+
+	archiveName, err := archiveNameFromURL(downloadURL)
+	if err != nil {
+		return err
+	}
+	archivePath := filepath.Join(installDir, archiveName)
+
+	fmt.Printf("%s をダウンロードしています...\n", downloadURL)
+	if err := u.downloader.Download(ctx, downloadURL, archivePath); err != nil {
+		return err
+	}
+	defer func(path string) {
+		if err := u.fs.RemoveAll(path); err != nil {
+			fmt.Fprintf(os.Stderr, "ダウンロードしたアーカイブの削除に失敗しました: %v\n", err)
+		}
+	}(archivePath)
+
+	fmt.Printf("%s に展開しています...\n", installDir)
+	if strings.HasSuffix(archiveName, ".zip") {
+		err = u.fs.Unzip(ctx, archivePath, installDir, false)
+	} else {
+		err = u.fs.Untar(ctx, archivePath, installDir)
+	}
+	if err != nil {
+		return fmt.Errorf("steamcmdの展開に失敗しました: %w", err)
+	}
+
+	// steamcmd.path が設定されていても、展開したsteamcmdを更新する
+	fmt.Println("steamcmd を更新しています...")
+	if err := u.steamCmd.SelfUpdate(ctx, installDir); err != nil {
+		return err
+	}
+	if path := u.archonCfg.GetSteamCmdPath(); path != "" {
+		fmt.Printf("steamcmd.path (%s) が設定されているため、archon はそちらを使用します。\n", path)
+	}
+
+	fmt.Printf("steamcmd を %s にインストールしました。\n", installDir)
+	return nil
+}
+
+// archiveNameFromURL はダウンロードURLからアーカイブのファイル名を取得する
+func archiveNameFromURL(rawURL string) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("steamcmd.download_url が不正です: %w", err)
+	}
+
+	name := path.Base(parsed.Path)
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"), strings.HasSuffix(name, ".zip"):
+		return name, nil
+	default:
+		return "", fmt.Errorf("steamcmd.download_url は .tar.gz または .zip のアーカイブを指定してください: %s", rawURL)
+	}
+}
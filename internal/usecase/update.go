@@ -2,66 +2,248 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
 
 	"github.com/nonuplet/grimoire-archon/internal/domain"
 )
 
 // UpdateUsecase updateのユースケース
 type UpdateUsecase struct {
-	gameCfg  *domain.GameConfig
-	steamCmd SteamCmd
-	fs       FileSystem
+	archonCfg   *domain.ArchonConfig
+	gameCfg     *domain.GameConfig
+	provider    InstallProvider
+	fs          FileSystem
+	cli         Cli
+	locker      Locker
+	process     ProcessFinder
+	runner      CommandRunner
+	newSnapshot SnapshotFactory
+	mods        *ModsUsecase
 }
 
 // NewUpdateUsecase UpdateUsecaseのインスタンスを生成
-func NewUpdateUsecase(gameCfg *domain.GameConfig, steamCmd SteamCmd, fs FileSystem) *UpdateUsecase {
+// newSnapshot は更新前のバックアップとロールバックに使用します。mods は workshop.sync_on_update が有効な場合に使用します。
+// nolint:lll // 初期化なので
+func NewUpdateUsecase(archonCfg *domain.ArchonConfig, gameCfg *domain.GameConfig, provider InstallProvider, fs FileSystem, cli Cli, locker Locker, process ProcessFinder, runner CommandRunner, newSnapshot SnapshotFactory, mods *ModsUsecase) *UpdateUsecase {
 	return &UpdateUsecase{
-		gameCfg:  gameCfg,
-		steamCmd: steamCmd,
-		fs:       fs,
+		archonCfg:   archonCfg,
+		gameCfg:     gameCfg,
+		provider:    provider,
+		fs:          fs,
+		cli:         cli,
+		locker:      locker,
+		process:     process,
+		runner:      runner,
+		newSnapshot: newSnapshot,
+		mods:        mods,
 	}
 }
 
 // Execute 更新処理を実行
-// TODO: 現在はSteam経由のダウンロード以外は対応していません Minecraft や Terraria 対応はそのうちやる
+// install.provider に従ってインストール元から更新します。
+// update.backup が有効な場合、更新前にバックアップ対象とインストール済みのバージョンを表すファイルをバックアップし、
+// 更新または更新後の確認に失敗した場合は update.rollback に従って復元します。
+// server_config と preserve のファイルは更新で上書きされないよう、更新後に更新前の内容に戻します。
+// 前回中断された更新で退避したファイルが残っている場合は、更新の前に戻します。
+// workshop.sync_on_update が有効な場合、更新後の確認の前にワークショップのアイテムを同期します。
+// update.mode が staged の場合は install_dir の複製を更新します。(executeStaged を参照)
 func (u *UpdateUsecase) Execute(ctx context.Context) error {
 	// 処理前チェック
 	if err := u.checkPreUpdate(); err != nil {
 		return err
 	}
 
+	// 同じゲームへの操作が同時に実行されないようにロックする
+	unlock, err := u.locker.Lock(u.gameCfg.Name, "update")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// サーバが実行中なら更新しない
+	if err := checkNotRunning(u.process, u.gameCfg); err != nil {
+		return err
+	}
+
+	// 前回中断された更新で退避したファイルが残っていれば戻す
+	if err := u.recoverStash(ctx); err != nil {
+		return err
+	}
+
 	// u.gameCfg.InstallDir がなかった場合ディレクトリを作成
-	if _, err := u.fs.Stat(u.gameCfg.InstallDir); os.IsNotExist(err) {
+	// 初回のインストールは壊れる既存のインストールがないため、staged でもそのまま更新する
+	if _, err := u.fs.Stat(u.gameCfg.InstallDir); errors.Is(err, os.ErrNotExist) {
 		fmt.Printf("インストール先のディレクトリ %s を作成しています...\n", u.gameCfg.InstallDir)
 		if dirErr := u.fs.MkdirAll(u.gameCfg.InstallDir, 0o750); dirErr != nil {
 			return fmt.Errorf("インストールディレクトリの作成に失敗しました: %w", dirErr)
 		}
 	} else if err != nil {
 		return fmt.Errorf("インストールディレクトリの確認に失敗しました: %w", err)
+	} else if u.gameCfg.Update.GetMode() == domain.UpdateStaged {
+		return u.executeStaged(ctx)
+	}
+
+	// 更新前のバックアップ
+	// ロールバックでは更新前のバージョンのファイルを戻すため、更新前に対象を決めておく
+	versionFiles := u.provider.VersionFiles(u.gameCfg)
+	backupPath, err := u.createPreUpdateBackup(ctx, versionFiles)
+	if err != nil {
+		return err
+	}
+
+	// 更新で上書きされる server_config と preserve のファイルを退避する
+	// 退避先は update で戻した後に削除する
+	stashed, err := u.stashPreserved(ctx)
+	if err != nil {
+		return err
 	}
 
-	if err := u.steamCmd.Update(ctx, u.gameCfg.Steam.AppID, u.gameCfg.InstallDir, u.gameCfg.Steam.Platform); err != nil {
-		return fmt.Errorf("更新に失敗しました: %w", err)
+	if err := u.update(ctx, stashed); err != nil {
+		return u.rollback(ctx, backupPath, versionFiles, err)
 	}
 	return nil
 }
 
 // checkPreUpdate アップデート実行前のチェック
 func (u *UpdateUsecase) checkPreUpdate() error {
-	if err := u.steamCmd.Check(); err != nil {
-		return fmt.Errorf("アップデートに失敗しました: %w", err)
-	}
 	if u.gameCfg.InstallDir == "" {
 		return fmt.Errorf("インストールディレクトリが設定されていません")
 	}
-	if u.gameCfg.Steam == nil {
-		return fmt.Errorf("steam設定が見つかりません")
+	if err := u.gameCfg.Install.Validate(u.gameCfg.Steam); err != nil {
+		return err
+	}
+	if err := u.provider.Check(); err != nil {
+		return fmt.Errorf("アップデートに失敗しました: %w", err)
+	}
+	if err := u.gameCfg.Update.Validate(); err != nil {
+		return err
+	}
+	if u.gameCfg.Workshop.SyncOnUpdate() && u.mods == nil {
+		return fmt.Errorf("ワークショップの同期が設定されていません")
+	}
+	if u.backupEnabled() && (u.archonCfg == nil || u.archonCfg.BackupDir == "") {
+		return fmt.Errorf("update.backup を使用するにはバックアップ先を設定してください")
+	}
+
+	return nil
+}
+
+// update インストール元から更新し、退避したファイルを戻してから更新後の確認を行う
+// 更新に失敗した場合や中断された場合も、途中で上書きされた可能性があるため退避したファイルは戻す
+// workshop.sync_on_update が有効な場合は、確認の前にワークショップのアイテムを同期する
+func (u *UpdateUsecase) update(ctx context.Context, stashed []string) error {
+	installErr := u.provider.Install(ctx, u.gameCfg)
+	if err := u.restorePreserved(ctx, stashed); err != nil {
+		if installErr != nil {
+			return fmt.Errorf("更新に失敗しました: %w (%w)", installErr, err)
+		}
+		return err
+	}
+	if installErr != nil {
+		return fmt.Errorf("更新に失敗しました: %w", installErr)
+	}
+	if u.gameCfg.Workshop.SyncOnUpdate() {
+		if err := u.mods.Sync(ctx); err != nil {
+			return fmt.Errorf("ワークショップのアイテムの同期に失敗しました: %w", err)
+		}
+	}
+	return u.checkHealth(ctx)
+}
+
+// backupEnabled 更新前のバックアップが有効かどうか
+func (u *UpdateUsecase) backupEnabled() bool {
+	return u.gameCfg.Update != nil && u.gameCfg.Update.Backup
+}
+
+// createPreUpdateBackup 更新前にバックアップ対象とインストール済みのバージョンを表すファイル、preserve のファイルをバックアップする
+// 作成したバックアップのパスを返す。バックアップが無効な場合や未インストールの場合は空文字列を返す。
+func (u *UpdateUsecase) createPreUpdateBackup(ctx context.Context, versionFiles []string) (string, error) {
+	if !u.backupEnabled() {
+		return "", nil
+	}
+	if installed, err := u.provider.InstalledVersion(u.gameCfg); err != nil {
+		return "", fmt.Errorf("インストール済みのバージョンの確認に失敗しました: %w", err)
+	} else if installed == "" {
+		fmt.Println("インストールされていないため、更新前のバックアップをスキップします。")
+		return "", nil
+	}
+
+	var paths []string
+	for _, file := range append(slices.Clone(versionFiles), u.gameCfg.GetPreserveFiles()...) {
+		if _, err := u.fs.Stat(filepath.Join(u.gameCfg.InstallDir, file)); err == nil {
+			paths = append(paths, file)
+		}
+	}
+	snapCfg := u.gameCfg.WithInstallDirTargets(paths...)
+
+	fmt.Printf("%s の更新前のバックアップを取得します...\n", u.gameCfg.Name)
+	backupUsecase := NewBackupUsecase(u.archonCfg, snapCfg, u.newSnapshot(snapCfg), u.fs, u.cli, u.locker)
+	backupPath, err := backupUsecase.Execute(ctx, domain.BackupOptions{Trigger: domain.TriggerPreUpdate})
+	if err != nil {
+		return "", fmt.Errorf("更新前のバックアップに失敗しました: %w", err)
+	}
+	return backupPath, nil
+}
+
+// checkHealth 更新後の確認
+// インストール済みのバージョンが取得できることを確認し、update.health_check が指定されていれば実行する
+func (u *UpdateUsecase) checkHealth(ctx context.Context) error {
+	installed, err := u.provider.InstalledVersion(u.gameCfg)
+	if err != nil {
+		return fmt.Errorf("更新後のバージョンの確認に失敗しました: %w", err)
 	}
-	if u.gameCfg.Steam.AppID == "" {
-		return fmt.Errorf("steamのappIDが設定されていません")
+	if installed == "" {
+		return fmt.Errorf("更新後のバージョンが見つかりません")
 	}
+	fmt.Printf("インストール済みのバージョン: %s\n", installed)
 
+	if u.gameCfg.Update == nil || u.gameCfg.Update.HealthCheck == "" {
+		return nil
+	}
+	fmt.Printf("更新後の確認コマンドを実行しています: %s\n", u.gameCfg.Update.HealthCheck)
+	if err := u.runner.Run(ctx, u.gameCfg, u.gameCfg.Update.HealthCheck); err != nil {
+		return fmt.Errorf("更新後の確認に失敗しました: %w", err)
+	}
 	return nil
 }
+
+// rollback 更新に失敗した場合、update.rollback に従って更新前のバックアップから復元する
+// 復元した場合も、更新自体は失敗しているため cause を返す
+func (u *UpdateUsecase) rollback(ctx context.Context, backupPath string, versionFiles []string, cause error) error {
+	if backupPath == "" {
+		return cause
+	}
+
+	fmt.Fprintf(os.Stderr, "%v\n", cause)
+	switch u.gameCfg.Update.GetRollback() {
+	case domain.RollbackNever:
+		fmt.Printf("更新前のバックアップ %s から手動で復元できます。\n", backupPath)
+		return cause
+	case domain.RollbackAsk:
+		ok, err := u.cli.AskYesNo(os.Stdin, fmt.Sprintf("更新前のバックアップ %s から復元しますか？", filepath.Base(backupPath)), true)
+		if err != nil {
+			return fmt.Errorf("%w (ユーザーの回答取得に失敗しました: %w)", cause, err)
+		}
+		if !ok {
+			fmt.Printf("更新前のバックアップ %s から手動で復元できます。\n", backupPath)
+			return cause
+		}
+	case domain.RollbackAuto:
+	}
+
+	// 中断された場合も復元は最後まで行う
+	fmt.Printf("更新前のバックアップ %s から復元しています...\n", filepath.Base(backupPath))
+	paths := append(slices.Clone(versionFiles), u.gameCfg.GetPreserveFiles()...)
+	snapCfg := u.gameCfg.WithInstallDirTargets(paths...)
+	restoreUsecase := NewRestoreUsecase(u.archonCfg, snapCfg, u.newSnapshot(snapCfg), u.fs, u.locker)
+	if err := restoreUsecase.Execute(context.WithoutCancel(ctx), backupPath, domain.RestoreOptions{}); err != nil {
+		return fmt.Errorf("%w (ロールバックにも失敗しました: %w)", cause, err)
+	}
+
+	fmt.Printf("%s を更新前の状態に戻しました。\n", u.gameCfg.Name)
+	return cause
+}
@@ -0,0 +1,16 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+// Check はインストール済みのバージョンと、インストール元で配信中の最新バージョンを比較します。
+// 未インストールの場合、InstalledVersion は空になります。
+func (u *UpdateUsecase) Check(ctx context.Context) (*domain.UpdateStatus, error) {
+	if err := u.checkPreUpdate(); err != nil {
+		return nil, err
+	}
+	return u.provider.Status(ctx, u.gameCfg)
+}
@@ -0,0 +1,177 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+// stashDir 更新中に preserve のファイルを退避するディレクトリ
+func (u *UpdateUsecase) stashDir() string {
+	return filepath.Join(u.archonCfg.GetStateDir(), "update", u.gameCfg.Name)
+}
+
+// upstreamDir 前回の更新で配布された preserve のファイルを保存するディレクトリ
+// 次回の更新時に、配布元の既定値が変更されたかを判定するために使う
+func (u *UpdateUsecase) upstreamDir() string {
+	return filepath.Join(u.archonCfg.GetStateDir(), "upstream", u.gameCfg.Name)
+}
+
+// stashPreserved server_config と preserve のファイルを退避する
+// 退避したファイルの一覧(インストールディレクトリからの相対パス)を返す。存在しないファイルは退避しない。
+func (u *UpdateUsecase) stashPreserved(ctx context.Context) ([]string, error) {
+	files := u.gameCfg.GetPreserveFiles()
+	if len(files) == 0 || u.archonCfg == nil {
+		return nil, nil
+	}
+
+	// 前回の退避先は更新前のファイルの唯一の複製の可能性があるため、削除しない (recoverStash で戻す)
+	if _, err := u.fs.Stat(u.stashDir()); err == nil {
+		return nil, fmt.Errorf("前回の更新で退避したファイルが %s に残っています。内容を確認して元に戻してから再実行してください", u.stashDir())
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("退避先のディレクトリの確認に失敗しました: %w", err)
+	}
+
+	var stashed []string
+	for _, file := range files {
+		src := filepath.Join(u.gameCfg.InstallDir, file)
+		if _, err := u.fs.Stat(src); errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("%s の確認に失敗しました: %w", file, err)
+		}
+
+		dst := filepath.Join(u.stashDir(), file)
+		if err := u.fs.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return nil, fmt.Errorf("退避先のディレクトリの作成に失敗しました: %w", err)
+		}
+		if err := u.fs.CopyFileOrDir(ctx, src, dst, true); err != nil {
+			return nil, fmt.Errorf("%s の退避に失敗しました: %w", file, err)
+		}
+		stashed = append(stashed, file)
+	}
+	return stashed, nil
+}
+
+// recoverStash 前回中断された更新で退避したファイルが残っている場合に、インストールディレクトリに戻す
+// steamcmd の実行中に強制終了された場合などは、インストールディレクトリのファイルが配布元の内容で上書きされたままになるため、
+// 新しく退避する前に戻す。戻せなかった場合は退避先を残したままエラーを返す。
+func (u *UpdateUsecase) recoverStash(ctx context.Context) error {
+	if u.archonCfg == nil {
+		return nil
+	}
+	if _, err := u.fs.Stat(u.stashDir()); errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("退避先のディレクトリの確認に失敗しました: %w", err)
+	}
+
+	var stashed []string
+	for _, file := range u.gameCfg.GetPreserveFiles() {
+		if _, err := u.fs.Stat(filepath.Join(u.stashDir(), file)); err == nil {
+			stashed = append(stashed, file)
+		}
+	}
+	if len(stashed) == 0 {
+		return fmt.Errorf("前回の更新で退避したファイルが %s に残っていますが、server_config, preserve に含まれていません。内容を確認してから削除してください", u.stashDir())
+	}
+
+	fmt.Printf("前回中断された更新で退避したファイルを %s から戻しています...\n", u.stashDir())
+	return u.restorePreserved(ctx, stashed)
+}
+
+// restorePreserved 退避したファイルを元に戻し、全て戻せた場合のみ退避先を削除する
+// 更新に失敗した場合や中断された場合も、上書きされた可能性があるため必ず呼び出す。中断されても最後まで戻す。
+// 戻せなかった場合は、退避したファイルが失われないよう退避先を残してパスを表示する。
+// 配布元の既定値が前回の更新から変わっている場合は、新しい項目を取り込めるよう差分を表示する
+func (u *UpdateUsecase) restorePreserved(ctx context.Context, stashed []string) error {
+	ctx = context.WithoutCancel(ctx)
+	for _, file := range stashed {
+		dst := filepath.Join(u.gameCfg.InstallDir, file)
+		ours, oursErr := u.fs.ReadFile(filepath.Join(u.stashDir(), file))
+		theirs, theirsErr := u.fs.ReadFile(dst)
+		if oursErr == nil && theirsErr == nil && bytes.Equal(ours, theirs) {
+			continue
+		}
+		if oursErr == nil && theirsErr == nil {
+			u.noticeUpstreamChange(file, ours, theirs)
+		}
+
+		if err := u.fs.CopyFileOrDir(ctx, filepath.Join(u.stashDir(), file), dst, true); err != nil {
+			fmt.Fprintf(os.Stderr, "更新前の %s は %s に残しています。\n", file, u.stashDir())
+			return fmt.Errorf("%s を更新前の内容に戻せませんでした: %w", file, err)
+		}
+		fmt.Printf("更新により変更された %s を更新前の内容に戻しました。\n", file)
+	}
+
+	u.removeStash()
+	return nil
+}
+
+// noticeUpstreamChange 配布元の既定値 theirs を前回の更新時のもの(base)と比較し、変更があればユーザーの設定 ours と合わせて表示する
+// base から theirs への差分に加えて、配布元で追加・変更された行のうち ours にない行と、配布元で削除された行のうち ours に残っている行を表示する
+// 比較後、theirs を次回の比較用に保存する
+// 更新で上書きされなかったファイル(theirs が更新前の内容と同じ)はユーザーの設定のため、呼び出し側で除外する
+func (u *UpdateUsecase) noticeUpstreamChange(file string, ours, theirs []byte) {
+	basePath := filepath.Join(u.upstreamDir(), file)
+	base, err := u.fs.ReadFile(basePath)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		// 初回は比較対象がないため、保存のみ行う
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "%s の前回の既定値の読み込みに失敗しました: %v\n", file, err)
+	case !bytes.Equal(base, theirs):
+		fmt.Printf("\n%s の配布元の既定値が前回の更新から変更されています。必要に応じて変更を取り込んでください。\n\n", file)
+		fmt.Printf("配布元の変更 (前回の既定値 → 新しい既定値):\n")
+		for _, line := range domain.LineDiff(string(base), string(theirs)) {
+			switch line.Op {
+			case domain.DiffDelete:
+				fmt.Printf("- %s\n", line.Text)
+			case domain.DiffInsert:
+				fmt.Printf("+ %s\n", line.Text)
+			case domain.DiffEqual:
+			}
+		}
+
+		missing, obsolete := domain.UpstreamChanges(string(base), string(ours), string(theirs))
+		if len(missing) > 0 {
+			fmt.Printf("\n現在の設定に含まれていない、配布元で追加・変更された行:\n")
+			for _, line := range missing {
+				fmt.Printf("+ %s\n", line)
+			}
+		}
+		if len(obsolete) > 0 {
+			fmt.Printf("\n配布元で削除・変更されたが、現在の設定に残っている行:\n")
+			for _, line := range obsolete {
+				fmt.Printf("- %s\n", line)
+			}
+		}
+		if len(missing) == 0 && len(obsolete) == 0 {
+			fmt.Printf("\n配布元の変更は現在の設定に反映済みです。\n")
+		}
+		fmt.Printf("\n新しい既定値は %s に保存されています。\n\n", basePath)
+	}
+
+	if err := u.fs.MkdirAll(filepath.Dir(basePath), 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, "%s の既定値の保存に失敗しました: %v\n", file, err)
+		return
+	}
+	if err := u.fs.WriteFile(basePath, theirs, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "%s の既定値の保存に失敗しました: %v\n", file, err)
+	}
+}
+
+// removeStash 退避先のディレクトリを削除する
+func (u *UpdateUsecase) removeStash() {
+	if u.archonCfg == nil {
+		return
+	}
+	if err := u.fs.RemoveAll(u.stashDir()); err != nil {
+		fmt.Fprintf(os.Stderr, "退避先のディレクトリの削除に失敗しました: %v\n", err)
+	}
+}
@@ -0,0 +1,120 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/nonuplet/grimoire-archon/internal/domain"
+)
+
+// executeStaged install_dir の複製を更新し、更新後の確認に成功した場合に install_dir と入れ替える
+// 失敗した場合は複製を削除するだけで、install_dir は変更しない。
+// 入れ替えた更新前の install_dir は PreviousDir に保存し、Rollback で戻せるようにする。
+func (u *UpdateUsecase) executeStaged(ctx context.Context) error {
+	installDir := u.gameCfg.InstallDir
+	stagingDir := domain.StagingDir(installDir)
+	previousDir := domain.PreviousDir(installDir)
+
+	// 前回中断された複製が残っていれば削除する
+	if err := u.fs.RemoveAll(stagingDir); err != nil {
+		return fmt.Errorf("前回の更新用の複製の削除に失敗しました: %w", err)
+	}
+
+	if _, err := u.createPreUpdateBackup(ctx, u.provider.VersionFiles(u.gameCfg)); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s を更新用に %s に複製しています...\n", installDir, stagingDir)
+	if err := u.fs.CloneDir(ctx, installDir, stagingDir, u.gameCfg.Update.Hardlink); err != nil {
+		u.removeStaging(stagingDir)
+		return fmt.Errorf("更新用の複製の作成に失敗しました: %w", err)
+	}
+
+	// 複製に対して、通常の更新と同じ処理を行う
+	staged := u.withInstallDir(stagingDir)
+	stashed, err := staged.stashPreserved(ctx)
+	if err != nil {
+		u.removeStaging(stagingDir)
+		return err
+	}
+
+	if err := staged.update(ctx, stashed); err != nil {
+		u.removeStaging(stagingDir)
+		return fmt.Errorf("%w (%s は変更されていません)", err, installDir)
+	}
+
+	// 入れ替え後、stagingDir には更新前の install_dir が入る
+	if err := u.fs.RemoveAll(previousDir); err != nil {
+		u.removeStaging(stagingDir)
+		return fmt.Errorf("前回の更新前のディレクトリの削除に失敗しました: %w", err)
+	}
+	if err := u.fs.SwapDirs(stagingDir, installDir); err != nil {
+		u.removeStaging(stagingDir)
+		return fmt.Errorf("更新したディレクトリとの入れ替えに失敗しました: %w", err)
+	}
+	if err := u.fs.Rename(stagingDir, previousDir); err != nil {
+		return fmt.Errorf("更新は完了しましたが、更新前のディレクトリの保存に失敗しました: %w", err)
+	}
+
+	fmt.Printf("更新前の %s を %s に保存しました。archon update --rollback %s で戻せます。\n", installDir, previousDir, u.gameCfg.Name)
+	return nil
+}
+
+// Rollback は staged で更新する前の install_dir に戻します。
+// 戻した後は更新後の install_dir が PreviousDir に入るため、もう一度実行すると更新後の状態に戻ります。
+func (u *UpdateUsecase) Rollback(_ context.Context) error {
+	if u.gameCfg.InstallDir == "" {
+		return fmt.Errorf("インストールディレクトリが設定されていません")
+	}
+
+	unlock, err := u.locker.Lock(u.gameCfg.Name, "update --rollback")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := checkNotRunning(u.process, u.gameCfg); err != nil {
+		return err
+	}
+
+	previousDir := domain.PreviousDir(u.gameCfg.InstallDir)
+	if _, err := u.fs.Stat(previousDir); errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("更新前のディレクトリ %s が見つかりません。update.mode が staged の場合のみ戻せます", previousDir)
+	} else if err != nil {
+		return fmt.Errorf("更新前のディレクトリの確認に失敗しました: %w", err)
+	}
+
+	if err := u.fs.SwapDirs(previousDir, u.gameCfg.InstallDir); err != nil {
+		return fmt.Errorf("更新前のディレクトリとの入れ替えに失敗しました: %w", err)
+	}
+
+	if version, err := u.provider.InstalledVersion(u.gameCfg); err == nil && version != "" {
+		fmt.Printf("%s を更新前の状態に戻しました。(バージョン: %s)\n", u.gameCfg.Name, version)
+	} else {
+		fmt.Printf("%s を更新前の状態に戻しました。\n", u.gameCfg.Name)
+	}
+	fmt.Printf("戻す前の %s は %s に保存しました。\n", u.gameCfg.InstallDir, previousDir)
+	return nil
+}
+
+// withInstallDir install_dir を dir に変更した UpdateUsecase のコピーを返す
+// ワークショップの同期も dir に対して行う。
+func (u *UpdateUsecase) withInstallDir(dir string) *UpdateUsecase {
+	cp := *u
+	cp.gameCfg = u.gameCfg.WithInstallDir(dir)
+	if u.mods != nil {
+		mods := *u.mods
+		mods.gameCfg = cp.gameCfg
+		cp.mods = &mods
+	}
+	return &cp
+}
+
+// removeStaging 更新用の複製を削除する
+func (u *UpdateUsecase) removeStaging(stagingDir string) {
+	if err := u.fs.RemoveAll(stagingDir); err != nil {
+		fmt.Fprintf(os.Stderr, "更新用の複製 %s の削除に失敗しました: %v\n", stagingDir, err)
+	}
+}
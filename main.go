@@ -1,7 +1,14 @@
 package main
 
-import "github.com/nonuplet/grimoire-archon/cmd"
+import (
+	"github.com/nonuplet/grimoire-archon/cmd"
+	"github.com/nonuplet/grimoire-archon/internal/infra/sandbox"
+)
 
 func main() {
+	// サーバをサンドボックス内で起動するため、archon 自身が初期化プロセスとして起動された場合
+	if sandbox.IsInit() {
+		sandbox.Init()
+	}
 	cmd.Execute()
 }